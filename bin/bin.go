@@ -0,0 +1,509 @@
+// skinhunter/bin/bin.go
+package bin
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// Ficheros de propiedades .bin del juego (PROP y su variante de parche PTCH).
+//
+//	["PTCH" u64] "PROP" u32 versión
+//	v2+: u32 n, n × (u16 len, string)         ficheros enlazados
+//	u32 n, n × u32                            clase de cada entrada
+//	n × (u32 tamaño, u32 ruta, u16 k, k × campo)
+//	PTCH v3+: u32 n, n × (u32 ruta, u32 tamaño, u8 tipo, string, valor)
+//
+// Un campo es u32 nombre, u8 tipo y el valor. Los nombres de entradas, clases y
+// campos, y los valores hash y link, son FNV-1a de 32 bits del nombre en minúsculas
+// (ver HashName); los valores file son xxh64 de la ruta, como en los WAD.
+
+var (
+	// ErrBadMagic indica que el fichero no empieza por PROP ni PTCH.
+	ErrBadMagic = errors.New("not a PROP/PTCH bin file")
+	// ErrUnsupportedVersion indica una versión de PROP desconocida.
+	ErrUnsupportedVersion = errors.New("unsupported bin version")
+	// ErrCorrupt indica datos truncados o tamaños que no cuadran.
+	ErrCorrupt = errors.New("corrupt bin file")
+)
+
+// UserMessage da el texto para el usuario de un .bin que no se pudo leer.
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrBadMagic):
+		return "This file isn't a property .bin file.", true
+	case errors.Is(err, ErrCorrupt), errors.Is(err, ErrUnsupportedVersion):
+		return "The .bin file is damaged or uses an unsupported version.", true
+	}
+	return "", false
+}
+
+// maxDepth limita el anidamiento de valores para no desbordar la pila con ficheros dañados.
+const maxDepth = 64
+
+// HashName devuelve el FNV-1a de 32 bits de s en minúsculas, como los nombres de un .bin.
+func HashName(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(s)))
+	return h.Sum32()
+}
+
+// Type es el tipo de un valor.
+type Type uint8
+
+const (
+	TypeNone    Type = 0
+	TypeBool    Type = 1
+	TypeI8      Type = 2
+	TypeU8      Type = 3
+	TypeI16     Type = 4
+	TypeU16     Type = 5
+	TypeI32     Type = 6
+	TypeU32     Type = 7
+	TypeI64     Type = 8
+	TypeU64     Type = 9
+	TypeF32     Type = 10
+	TypeVec2    Type = 11
+	TypeVec3    Type = 12
+	TypeVec4    Type = 13
+	TypeMtx44   Type = 14
+	TypeRGBA    Type = 15
+	TypeString  Type = 16
+	TypeHash    Type = 17
+	TypeFile    Type = 18
+	TypeList    Type = 0x80
+	TypeList2   Type = 0x81
+	TypePointer Type = 0x82
+	TypeEmbed   Type = 0x83
+	TypeLink    Type = 0x84
+	TypeOption  Type = 0x85
+	TypeMap     Type = 0x86
+	TypeFlag    Type = 0x87
+)
+
+var typeNames = map[Type]string{
+	TypeNone: "none", TypeBool: "bool", TypeI8: "i8", TypeU8: "u8", TypeI16: "i16", TypeU16: "u16",
+	TypeI32: "i32", TypeU32: "u32", TypeI64: "i64", TypeU64: "u64", TypeF32: "f32",
+	TypeVec2: "vec2", TypeVec3: "vec3", TypeVec4: "vec4", TypeMtx44: "mtx44", TypeRGBA: "rgba",
+	TypeString: "string", TypeHash: "hash", TypeFile: "file",
+	TypeList: "list", TypeList2: "list2", TypePointer: "pointer", TypeEmbed: "embed",
+	TypeLink: "link", TypeOption: "option", TypeMap: "map", TypeFlag: "flag",
+}
+
+// String devuelve el nombre del tipo como lo escribe ritobin.
+func (t Type) String() string {
+	if n, ok := typeNames[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("type(0x%02x)", uint8(t))
+}
+
+// Value es un valor del árbol; el tipo concreto indica su Type.
+type Value interface {
+	Type() Type
+}
+
+// Tipos simples.
+type (
+	None     struct{}
+	Bool     bool
+	I8       int8
+	U8       uint8
+	I16      int16
+	U16      uint16
+	I32      int32
+	U32      uint32
+	I64      int64
+	U64      uint64
+	F32      float32
+	Vec2     [2]float32
+	Vec3     [3]float32
+	Vec4     [4]float32
+	Mtx44    [16]float32
+	RGBA     [4]uint8
+	String   string
+	Hash     uint32 // FNV-1a de un nombre
+	FileHash uint64 // xxh64 de una ruta del WAD
+	Link     uint32 // Ruta (FNV-1a) de otra entrada
+	Flag     bool
+)
+
+func (None) Type() Type     { return TypeNone }
+func (Bool) Type() Type     { return TypeBool }
+func (I8) Type() Type       { return TypeI8 }
+func (U8) Type() Type       { return TypeU8 }
+func (I16) Type() Type      { return TypeI16 }
+func (U16) Type() Type      { return TypeU16 }
+func (I32) Type() Type      { return TypeI32 }
+func (U32) Type() Type      { return TypeU32 }
+func (I64) Type() Type      { return TypeI64 }
+func (U64) Type() Type      { return TypeU64 }
+func (F32) Type() Type      { return TypeF32 }
+func (Vec2) Type() Type     { return TypeVec2 }
+func (Vec3) Type() Type     { return TypeVec3 }
+func (Vec4) Type() Type     { return TypeVec4 }
+func (Mtx44) Type() Type    { return TypeMtx44 }
+func (RGBA) Type() Type     { return TypeRGBA }
+func (String) Type() Type   { return TypeString }
+func (Hash) Type() Type     { return TypeHash }
+func (FileHash) Type() Type { return TypeFile }
+func (Link) Type() Type     { return TypeLink }
+func (Flag) Type() Type     { return TypeFlag }
+
+// Field es un campo de una entrada, embed o pointer.
+type Field struct {
+	Name  uint32 // FNV-1a del nombre
+	Value Value
+}
+
+// List es una lista (list o list2) de valores de tipo Elem.
+type List struct {
+	Kind  Type // TypeList o TypeList2
+	Elem  Type
+	Items []Value
+}
+
+// Type implementa Value.
+func (l *List) Type() Type { return l.Kind }
+
+// Pointer es una estructura opcional; Class 0 es el puntero nulo.
+type Pointer struct {
+	Class  uint32
+	Fields []Field
+}
+
+// Type implementa Value.
+func (*Pointer) Type() Type { return TypePointer }
+
+// Embed es una estructura incrustada.
+type Embed struct {
+	Class  uint32
+	Fields []Field
+}
+
+// Type implementa Value.
+func (*Embed) Type() Type { return TypeEmbed }
+
+// Option es un valor opcional de tipo Elem; Value es nil si está vacío.
+type Option struct {
+	Elem  Type
+	Value Value
+}
+
+// Type implementa Value.
+func (*Option) Type() Type { return TypeOption }
+
+// MapEntry es un par de un Map.
+type MapEntry struct {
+	Key, Value Value
+}
+
+// Map es un diccionario con claves de tipo Key y valores de tipo Elem, en el orden
+// del fichero.
+type Map struct {
+	Key, Elem Type
+	Entries   []MapEntry
+}
+
+// Type implementa Value.
+func (*Map) Type() Type { return TypeMap }
+
+// Entry es un objeto de primer nivel del fichero.
+type Entry struct {
+	Path   uint32 // FNV-1a de la ruta de la entrada, p. ej. "Characters/Ahri/Skins/Skin5"
+	Class  uint32
+	Fields []Field
+}
+
+// Patch es un cambio de un fichero PTCH: asigna Value al campo Name de la entrada Path.
+type Patch struct {
+	Path  uint32
+	Name  string // Ruta del campo dentro de la entrada, p. ej. "skinMeshProperties.texture"
+	Value Value
+}
+
+// File es un .bin decodificado.
+type File struct {
+	IsPatch bool // PTCH en lugar de PROP
+	Version uint32
+	Linked  []string // Otros .bin que carga este
+	Entries []Entry
+	Patches []Patch
+}
+
+// FindField busca el campo name (FNV-1a) en fields.
+func FindField(fields []Field, name uint32) (Value, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Parse decodifica un .bin completo.
+func Parse(b []byte) (*File, error) {
+	r := &reader{b: b}
+	f := &File{}
+	magic := r.bytes(4)
+	if string(magic) == "PTCH" {
+		f.IsPatch = true
+		r.skip(8)
+		magic = r.bytes(4)
+	}
+	if r.err != nil || string(magic) != "PROP" {
+		return nil, ErrBadMagic
+	}
+	f.Version = r.u32()
+	if r.err == nil && (f.Version == 0 || f.Version > 3) {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, f.Version)
+	}
+	if f.Version >= 2 {
+		n := r.count(r.u32(), 2)
+		for i := 0; i < n && r.err == nil; i++ {
+			f.Linked = append(f.Linked, r.str())
+		}
+	}
+	n := r.count(r.u32(), 4)
+	classes := make([]uint32, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		classes = append(classes, r.u32())
+	}
+	for _, class := range classes {
+		if r.err != nil {
+			break
+		}
+		size := r.u32()
+		end := r.pos + int(size)
+		e := Entry{Class: class, Path: r.u32()}
+		e.Fields = r.fields(int(r.u16()), 0)
+		r.expectEnd(end, "entry")
+		f.Entries = append(f.Entries, e)
+	}
+	if f.IsPatch && f.Version >= 3 && r.err == nil {
+		n := r.count(r.u32(), 9)
+		for i := 0; i < n && r.err == nil; i++ {
+			p := Patch{Path: r.u32()}
+			size := r.u32()
+			end := r.pos + int(size)
+			t := Type(r.u8())
+			p.Name = r.str()
+			p.Value = r.value(t, 0)
+			r.expectEnd(end, "patch")
+			f.Patches = append(f.Patches, p)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return f, nil
+}
+
+// reader lee little-endian de b; tras el primer error todas las lecturas devuelven cero.
+type reader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s at offset %d", ErrCorrupt, fmt.Sprintf(format, args...), r.pos)
+	}
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.b) {
+		r.fail("truncated")
+		return nil
+	}
+	out := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return out
+}
+
+func (r *reader) skip(n int) { r.bytes(n) }
+
+func (r *reader) u8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) u64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *reader) f32() float32 { return math.Float32frombits(r.u32()) }
+
+func (r *reader) str() string { return string(r.bytes(int(r.u16()))) }
+
+// count valida que caben n elementos de al menos minSize bytes en lo que queda.
+func (r *reader) count(n uint32, minSize int) int {
+	if r.err != nil {
+		return 0
+	}
+	if int64(n)*int64(minSize) > int64(len(r.b)-r.pos) {
+		r.fail("count %d too large", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *reader) expectEnd(end int, what string) {
+	if r.err == nil && r.pos != end {
+		r.fail("%s size mismatch (ends at %d, expected %d)", what, r.pos, end)
+	}
+}
+
+func (r *reader) fields(n int, depth int) []Field {
+	n = r.count(uint32(n), 5)
+	var fields []Field
+	if n > 0 {
+		fields = make([]Field, 0, n)
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		name := r.u32()
+		t := Type(r.u8())
+		fields = append(fields, Field{Name: name, Value: r.value(t, depth+1)})
+	}
+	return fields
+}
+
+func (r *reader) value(t Type, depth int) Value {
+	if depth > maxDepth {
+		r.fail("values nested too deep")
+		return nil
+	}
+	switch t {
+	case TypeNone:
+		return None{}
+	case TypeBool:
+		return Bool(r.u8() != 0)
+	case TypeI8:
+		return I8(r.u8())
+	case TypeU8:
+		return U8(r.u8())
+	case TypeI16:
+		return I16(r.u16())
+	case TypeU16:
+		return U16(r.u16())
+	case TypeI32:
+		return I32(r.u32())
+	case TypeU32:
+		return U32(r.u32())
+	case TypeI64:
+		return I64(r.u64())
+	case TypeU64:
+		return U64(r.u64())
+	case TypeF32:
+		return F32(r.f32())
+	case TypeVec2:
+		return Vec2{r.f32(), r.f32()}
+	case TypeVec3:
+		return Vec3{r.f32(), r.f32(), r.f32()}
+	case TypeVec4:
+		return Vec4{r.f32(), r.f32(), r.f32(), r.f32()}
+	case TypeMtx44:
+		var m Mtx44
+		for i := range m {
+			m[i] = r.f32()
+		}
+		return m
+	case TypeRGBA:
+		return RGBA{r.u8(), r.u8(), r.u8(), r.u8()}
+	case TypeString:
+		return String(r.str())
+	case TypeHash:
+		return Hash(r.u32())
+	case TypeFile:
+		return FileHash(r.u64())
+	case TypeLink:
+		return Link(r.u32())
+	case TypeFlag:
+		return Flag(r.u8() != 0)
+	case TypeList, TypeList2:
+		l := &List{Kind: t, Elem: Type(r.u8())}
+		if l.Elem >= TypeList && l.Elem != TypePointer && l.Elem != TypeEmbed && l.Elem != TypeLink && l.Elem != TypeFlag {
+			r.fail("list of %s", l.Elem)
+			return l
+		}
+		size := r.u32()
+		end := r.pos + int(size)
+		n := r.count(r.u32(), 1)
+		if n > 0 {
+			l.Items = make([]Value, 0, n)
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			l.Items = append(l.Items, r.value(l.Elem, depth+1))
+		}
+		r.expectEnd(end, "list")
+		return l
+	case TypePointer, TypeEmbed:
+		class := r.u32()
+		if t == TypePointer && class == 0 {
+			return &Pointer{}
+		}
+		size := r.u32()
+		end := r.pos + int(size)
+		fields := r.fields(int(r.u16()), depth)
+		r.expectEnd(end, t.String())
+		if t == TypePointer {
+			return &Pointer{Class: class, Fields: fields}
+		}
+		return &Embed{Class: class, Fields: fields}
+	case TypeOption:
+		o := &Option{Elem: Type(r.u8())}
+		if r.u8() != 0 {
+			o.Value = r.value(o.Elem, depth+1)
+		}
+		return o
+	case TypeMap:
+		m := &Map{Key: Type(r.u8()), Elem: Type(r.u8())}
+		if m.Key >= TypeList {
+			r.fail("map key of %s", m.Key)
+			return m
+		}
+		size := r.u32()
+		end := r.pos + int(size)
+		n := r.count(r.u32(), 2)
+		if n > 0 {
+			m.Entries = make([]MapEntry, 0, n)
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			k := r.value(m.Key, depth+1)
+			m.Entries = append(m.Entries, MapEntry{Key: k, Value: r.value(m.Elem, depth+1)})
+		}
+		r.expectEnd(end, "map")
+		return m
+	}
+	r.fail("unknown value type 0x%02x", uint8(t))
+	return nil
+}
+
+// --- End of bin.go ---
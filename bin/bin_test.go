@@ -0,0 +1,350 @@
+package bin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// enc escribe valores en el formato de los .bin para construir ficheros de prueba.
+type enc struct{ bytes.Buffer }
+
+func (e *enc) u8(v uint8)   { e.WriteByte(v) }
+func (e *enc) u16(v uint16) { binary.Write(e, binary.LittleEndian, v) }
+func (e *enc) u32(v uint32) { binary.Write(e, binary.LittleEndian, v) }
+func (e *enc) u64(v uint64) { binary.Write(e, binary.LittleEndian, v) }
+func (e *enc) f32(v float32) {
+	e.u32(math.Float32bits(v))
+}
+func (e *enc) str(s string) { e.u16(uint16(len(s))); e.WriteString(s) }
+
+// sized escribe u32 con el tamaño de lo que escribe body seguido de ello.
+func (e *enc) sized(body func(*enc)) {
+	var inner enc
+	body(&inner)
+	e.u32(uint32(inner.Len()))
+	e.Write(inner.Bytes())
+}
+
+func (e *enc) value(v Value) {
+	switch v := v.(type) {
+	case None:
+	case Bool:
+		e.u8(b2u(bool(v)))
+	case Flag:
+		e.u8(b2u(bool(v)))
+	case I8:
+		e.u8(uint8(v))
+	case U8:
+		e.u8(uint8(v))
+	case I16:
+		e.u16(uint16(v))
+	case U16:
+		e.u16(uint16(v))
+	case I32:
+		e.u32(uint32(v))
+	case U32:
+		e.u32(uint32(v))
+	case I64:
+		e.u64(uint64(v))
+	case U64:
+		e.u64(uint64(v))
+	case F32:
+		e.f32(float32(v))
+	case Vec2:
+		for _, f := range v {
+			e.f32(f)
+		}
+	case Vec3:
+		for _, f := range v {
+			e.f32(f)
+		}
+	case Vec4:
+		for _, f := range v {
+			e.f32(f)
+		}
+	case Mtx44:
+		for _, f := range v {
+			e.f32(f)
+		}
+	case RGBA:
+		e.Write(v[:])
+	case String:
+		e.str(string(v))
+	case Hash:
+		e.u32(uint32(v))
+	case Link:
+		e.u32(uint32(v))
+	case FileHash:
+		e.u64(uint64(v))
+	case *List:
+		e.u8(uint8(v.Elem))
+		e.sized(func(in *enc) {
+			in.u32(uint32(len(v.Items)))
+			for _, it := range v.Items {
+				in.value(it)
+			}
+		})
+	case *Pointer:
+		e.u32(v.Class)
+		if v.Class != 0 {
+			e.sized(func(in *enc) { in.fields(v.Fields) })
+		}
+	case *Embed:
+		e.u32(v.Class)
+		e.sized(func(in *enc) { in.fields(v.Fields) })
+	case *Option:
+		e.u8(uint8(v.Elem))
+		if v.Value == nil {
+			e.u8(0)
+		} else {
+			e.u8(1)
+			e.value(v.Value)
+		}
+	case *Map:
+		e.u8(uint8(v.Key))
+		e.u8(uint8(v.Elem))
+		e.sized(func(in *enc) {
+			in.u32(uint32(len(v.Entries)))
+			for _, me := range v.Entries {
+				in.value(me.Key)
+				in.value(me.Value)
+			}
+		})
+	default:
+		panic("unhandled value")
+	}
+}
+
+func (e *enc) fields(fields []Field) {
+	e.u16(uint16(len(fields)))
+	for _, f := range fields {
+		e.u32(f.Name)
+		e.u8(uint8(f.Value.Type()))
+		e.value(f.Value)
+	}
+}
+
+func b2u(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// encode construye el .bin de f.
+func encode(f *File) []byte {
+	var e enc
+	if f.IsPatch {
+		e.WriteString("PTCH")
+		e.u64(1)
+	}
+	e.WriteString("PROP")
+	e.u32(f.Version)
+	if f.Version >= 2 {
+		e.u32(uint32(len(f.Linked)))
+		for _, l := range f.Linked {
+			e.str(l)
+		}
+	}
+	e.u32(uint32(len(f.Entries)))
+	for _, en := range f.Entries {
+		e.u32(en.Class)
+	}
+	for _, en := range f.Entries {
+		e.sized(func(in *enc) {
+			in.u32(en.Path)
+			in.fields(en.Fields)
+		})
+	}
+	if f.IsPatch {
+		e.u32(uint32(len(f.Patches)))
+		for _, p := range f.Patches {
+			e.u32(p.Path)
+			e.sized(func(in *enc) {
+				in.u8(uint8(p.Value.Type()))
+				in.str(p.Name)
+				in.value(p.Value)
+			})
+		}
+	}
+	return e.Bytes()
+}
+
+// names es un Resolver de prueba.
+type names map[uint64]string
+
+func (n names) Path(h uint64) (string, bool) {
+	s, ok := n[h]
+	return s, ok
+}
+
+func binNames(ss ...string) names {
+	n := names{}
+	for _, s := range ss {
+		n[uint64(HashName(s))] = s
+	}
+	return n
+}
+
+func skinFile() *File {
+	h := HashName
+	return &File{
+		Version: 3,
+		Linked:  []string{"DATA/Characters/Ahri/Ahri.bin"},
+		Entries: []Entry{
+			{Path: h("Characters/Ahri/Skins/Skin5"), Class: h("SkinCharacterDataProperties"), Fields: []Field{
+				{h("championSkinName"), String("AhriSkin05")},
+				{h("skinScale"), F32(1.25)},
+				{h("skinMeshProperties"), &Embed{Class: h("SkinMeshDataProperties"), Fields: []Field{
+					{h("skeleton"), String("ASSETS/Characters/Ahri/Skins/Skin05/Ahri_Skin05.skl")},
+					{h("simpleSkin"), String("ASSETS/Characters/Ahri/Skins/Skin05/Ahri_Skin05.skn")},
+					{h("texture"), FileHash(0xabcdef)},
+					{h("emissiveColor"), RGBA{1, 2, 3, 255}},
+					{h("boundingBox"), Vec3{1, 2, 3}},
+				}}},
+				{h("iconCircle"), &Option{Elem: TypeString, Value: String("ASSETS/Ahri_Circle_5.dds")}},
+				{h("iconSquare"), &Option{Elem: TypeString}},
+				{h("animationGraph"), Link(h("Characters/Ahri/Animations/Skin5"))},
+				{h("tags"), &List{Kind: TypeList, Elem: TypeHash, Items: []Value{Hash(h("Fox")), Hash(0x1234)}}},
+				{h("nothing"), &Pointer{}},
+				{h("vfx"), &Pointer{Class: h("VfxSettings"), Fields: []Field{{h("enabled"), Flag(true)}, {h("count"), I32(-3)}}}},
+				{h("resourceMap"), &Map{Key: TypeHash, Elem: TypeString, Entries: []MapEntry{
+					{Hash(h("Idle")), String("ASSETS/Characters/Ahri/Skins/Skin05/Animations/Idle.anm")},
+				}}},
+				{h("matrix"), Mtx44{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}},
+				{h("misc"), &List{Kind: TypeList2, Elem: TypeEmbed, Items: []Value{&Embed{Class: h("Empty")}}}},
+				{h("ints"), &Embed{Class: h("Ints"), Fields: []Field{{h("a"), I8(-1)}, {h("b"), U8(2)}, {h("c"), I16(-3)}, {h("d"), U16(4)}, {h("e"), U32(5)}, {h("f"), I64(-6)}, {h("g"), U64(7)}, {h("h"), Bool(true)}, {h("i"), Vec2{1, 2}}, {h("j"), Vec4{1, 2, 3, 4}}, {h("k"), None{}}}}},
+			}},
+			{Path: h("Characters/Ahri/Skins/Skin5/Particles/Ahri_Skin05_Q"), Class: h("VfxSystemDefinitionData"), Fields: []Field{
+				{h("particleName"), String("Ahri_Skin05_Q")},
+			}},
+		},
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	want := skinFile()
+	got, err := Parse(encode(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse mismatch:\n got %+v\nwant %+v", got, want)
+	}
+
+	patch := &File{IsPatch: true, Version: 3, Patches: []Patch{
+		{Path: HashName("Characters/Ahri/Skins/Skin5"), Name: "skinScale", Value: F32(2)},
+	}}
+	got, err = Parse(encode(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsPatch || len(got.Patches) != 1 || got.Patches[0].Name != "skinScale" || got.Patches[0].Value != F32(2) {
+		t.Errorf("patch = %+v", got)
+	}
+
+	v1 := &File{Version: 1, Entries: []Entry{{Path: 1, Class: 2, Fields: []Field{{3, U32(4)}}}}}
+	if got, err := Parse(encode(v1)); err != nil || !reflect.DeepEqual(got, v1) {
+		t.Errorf("v1 = %+v, %v", got, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	good := encode(skinFile())
+	if _, err := Parse([]byte("RW\x03\x03")); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("bad magic: %v", err)
+	}
+	if _, err := Parse([]byte("PROP\x09\x00\x00\x00")); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("bad version: %v", err)
+	}
+	for n := 8; n < len(good); n += 7 {
+		if _, err := Parse(good[:n]); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("truncated at %d: %v", n, err)
+		}
+	}
+	// Tamaño de entrada que no cuadra con su contenido.
+	bad := append([]byte(nil), good...)
+	off := 4 + 4 + 4 + 2 + len("DATA/Characters/Ahri/Ahri.bin") + 4 + 2*4
+	binary.LittleEndian.PutUint32(bad[off:], binary.LittleEndian.Uint32(bad[off:])+1)
+	if _, err := Parse(bad); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("entry size mismatch: %v", err)
+	}
+	// Anidamiento excesivo.
+	var v Value = &Option{Elem: TypeU8, Value: U8(1)}
+	for i := 0; i < maxDepth+2; i++ {
+		v = &Option{Elem: TypeOption, Value: v}
+	}
+	deep := encode(&File{Version: 3, Entries: []Entry{{Path: 1, Class: 2, Fields: []Field{{3, v}}}}})
+	if _, err := Parse(deep); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("deep nesting: %v", err)
+	}
+}
+
+func TestJSONAndAssets(t *testing.T) {
+	f := skinFile()
+	n := Names{
+		Bin: binNames("Characters/Ahri/Skins/Skin5", "SkinCharacterDataProperties", "championSkinName", "skinMeshProperties",
+			"texture", "iconCircle", "resourceMap", "Idle", "Characters/Ahri/Skins/Skin5/Particles/Ahri_Skin05_Q", "nothing"),
+		Files: names{0xabcdef: "assets/characters/ahri/skins/skin05/ahri_skin05_tx_cm.tex"},
+	}
+	out, err := f.JSON(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Type    string
+		Version int
+		Linked  []string
+		Entries map[string]struct {
+			Class  string
+			Fields map[string]any
+		}
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	skin := doc.Entries["Characters/Ahri/Skins/Skin5"]
+	if doc.Type != "PROP" || doc.Version != 3 || skin.Class != "SkinCharacterDataProperties" || skin.Fields["championSkinName"] != "AhriSkin05" {
+		t.Errorf("JSON = %s", out)
+	}
+	mesh := skin.Fields["skinMeshProperties"].(map[string]any)["fields"].(map[string]any)
+	if mesh["texture"] != "assets/characters/ahri/skins/skin05/ahri_skin05_tx_cm.tex" {
+		t.Errorf("file hash not resolved: %v", mesh["texture"])
+	}
+	if skin.Fields["nothing"] != nil || skin.Fields["iconCircle"] != "ASSETS/Ahri_Circle_5.dds" {
+		t.Errorf("pointer/option = %v, %v", skin.Fields["nothing"], skin.Fields["iconCircle"])
+	}
+	if m := skin.Fields["resourceMap"].(map[string]any); m["Idle"] == nil {
+		t.Errorf("map = %v", m)
+	}
+	if !strings.Contains(string(out), fmt.Sprintf(`"0x%08x": 1.25`, HashName("skinScale"))) {
+		t.Errorf("unknown field names should be hex:\n%s", out)
+	}
+
+	got := f.Assets(n)
+	want := []Asset{
+		{AssetAnimation, "ASSETS/Characters/Ahri/Skins/Skin05/Animations/Idle.anm"},
+		{AssetMesh, "ASSETS/Characters/Ahri/Skins/Skin05/Ahri_Skin05.skn"},
+		{AssetParticle, "Characters/Ahri/Skins/Skin5/Particles/Ahri_Skin05_Q"},
+		{AssetSkeleton, "ASSETS/Characters/Ahri/Skins/Skin05/Ahri_Skin05.skl"},
+		{AssetTexture, "ASSETS/Ahri_Circle_5.dds"},
+		{AssetTexture, "assets/characters/ahri/skins/skin05/ahri_skin05_tx_cm.tex"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Assets = %+v\nwant %+v", got, want)
+	}
+
+	// Sin tablas todo sale en hex y sigue siendo JSON válido.
+	f.Entries[0].Fields = append(f.Entries[0].Fields, Field{1, F32(float32(math.NaN()))})
+	out, err = f.JSON(Names{})
+	if err != nil || !json.Valid(out) {
+		t.Errorf("JSON without names: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+// skinhunter/bin/json.go
+package bin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Resolver da el nombre de un hash; *hashes.Table lo implementa.
+type Resolver interface {
+	Path(h uint64) (string, bool)
+}
+
+// Names resuelve los hashes de un .bin. Cualquiera de los dos puede ser nil y entonces
+// los hashes se muestran en hex.
+type Names struct {
+	Bin   Resolver // Entradas, clases, campos y valores hash/link (FNV-1a, hashes.bin*.txt)
+	Files Resolver // Valores file (xxh64, hashes.game.txt)
+}
+
+func (n Names) name(h uint32) string {
+	if n.Bin != nil {
+		if s, ok := n.Bin.Path(uint64(h)); ok {
+			return s
+		}
+	}
+	return fmt.Sprintf("0x%08x", h)
+}
+
+func (n Names) file(h uint64) (string, bool) {
+	if n.Files != nil {
+		if s, ok := n.Files.Path(h); ok {
+			return s, true
+		}
+	}
+	return fmt.Sprintf("0x%016x", h), false
+}
+
+// object es un objeto JSON que conserva el orden de sus claves.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(m.key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// JSON vuelca f como JSON indentado, con los hashes resueltos mediante names. Los
+// campos y entradas salen en el orden del fichero; los hashes desconocidos salen en
+// hex ("0x1a2b3c4d").
+func (f *File) JSON(names Names) ([]byte, error) {
+	kind := "PROP"
+	if f.IsPatch {
+		kind = "PTCH"
+	}
+	linked := f.Linked
+	if linked == nil {
+		linked = []string{}
+	}
+	entries := make(object, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		entries = append(entries, member{names.name(e.Path), object{
+			{"class", names.name(e.Class)},
+			{"fields", fieldsJSON(e.Fields, names)},
+		}})
+	}
+	root := object{{"type", kind}, {"version", f.Version}, {"linked", linked}, {"entries", entries}}
+	if f.IsPatch {
+		patches := make([]any, 0, len(f.Patches))
+		for _, p := range f.Patches {
+			patches = append(patches, object{
+				{"entry", names.name(p.Path)},
+				{"path", p.Name},
+				{"value", valueJSON(p.Value, names)},
+			})
+		}
+		root = append(root, member{"patches", patches})
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func fieldsJSON(fields []Field, names Names) object {
+	o := make(object, 0, len(fields))
+	for _, f := range fields {
+		o = append(o, member{names.name(f.Name), valueJSON(f.Value, names)})
+	}
+	return o
+}
+
+// floatJSON evita los NaN e infinitos, que encoding/json no admite.
+func floatJSON(f float32) any {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return fmt.Sprint(f)
+	}
+	return f
+}
+
+func floatsJSON(fs []float32) []any {
+	out := make([]any, len(fs))
+	for i, f := range fs {
+		out[i] = floatJSON(f)
+	}
+	return out
+}
+
+func valueJSON(v Value, names Names) any {
+	switch v := v.(type) {
+	case nil, None:
+		return nil
+	case F32:
+		return floatJSON(float32(v))
+	case Vec2:
+		return floatsJSON(v[:])
+	case Vec3:
+		return floatsJSON(v[:])
+	case Vec4:
+		return floatsJSON(v[:])
+	case Mtx44:
+		return floatsJSON(v[:])
+	case RGBA:
+		return []uint8{v[0], v[1], v[2], v[3]}
+	case Hash:
+		return names.name(uint32(v))
+	case Link:
+		return names.name(uint32(v))
+	case FileHash:
+		s, _ := names.file(uint64(v))
+		return s
+	case *List:
+		items := make([]any, len(v.Items))
+		for i, it := range v.Items {
+			items[i] = valueJSON(it, names)
+		}
+		return items
+	case *Pointer:
+		if v.Class == 0 {
+			return nil
+		}
+		return object{{"class", names.name(v.Class)}, {"fields", fieldsJSON(v.Fields, names)}}
+	case *Embed:
+		return object{{"class", names.name(v.Class)}, {"fields", fieldsJSON(v.Fields, names)}}
+	case *Option:
+		return valueJSON(v.Value, names)
+	case *Map:
+		o := make(object, 0, len(v.Entries))
+		for _, e := range v.Entries {
+			o = append(o, member{keyString(e.Key, names), valueJSON(e.Value, names)})
+		}
+		return o
+	}
+	return v // Bool, enteros, String y Flag se codifican tal cual
+}
+
+func keyString(v Value, names Names) string {
+	switch k := valueJSON(v, names).(type) {
+	case string:
+		return k
+	case nil:
+		return "null"
+	default:
+		b, _ := json.Marshal(k)
+		return string(b)
+	}
+}
+
+// Tipos de Asset.
+const (
+	AssetMesh      = "mesh"
+	AssetSkeleton  = "skeleton"
+	AssetAnimation = "animation"
+	AssetTexture   = "texture"
+	AssetParticle  = "particle"
+	AssetAudio     = "audio"
+)
+
+var assetKinds = map[string]string{
+	".skn": AssetMesh, ".scb": AssetMesh, ".sco": AssetMesh,
+	".skl": AssetSkeleton,
+	".anm": AssetAnimation,
+	".tex": AssetTexture, ".dds": AssetTexture, ".png": AssetTexture,
+	".bnk": AssetAudio, ".wpk": AssetAudio,
+}
+
+// vfxSystemClass es la clase de las entradas que definen un sistema de partículas.
+var vfxSystemClass = HashName("VfxSystemDefinitionData")
+
+// Asset es un recurso que referencia un .bin.
+type Asset struct {
+	Kind string // AssetMesh, AssetTexture...
+	Path string
+}
+
+// Assets devuelve las mallas, esqueletos, animaciones, texturas y audio que referencia
+// f (por ruta en un string o por hash file resuelto con names), más los sistemas de
+// partículas que define. Sin repetidos y ordenados por tipo y ruta.
+func (f *File) Assets(names Names) []Asset {
+	seen := make(map[string]bool)
+	var out []Asset
+	add := func(kind, p string) {
+		if key := kind + "\x00" + strings.ToLower(p); !seen[key] {
+			seen[key] = true
+			out = append(out, Asset{kind, p})
+		}
+	}
+	addPath := func(p string) {
+		if kind, ok := assetKinds[strings.ToLower(path.Ext(p))]; ok {
+			add(kind, p)
+		}
+	}
+	var walk func(v Value)
+	walkFields := func(fields []Field) {
+		for _, fl := range fields {
+			walk(fl.Value)
+		}
+	}
+	walk = func(v Value) {
+		switch v := v.(type) {
+		case String:
+			addPath(string(v))
+		case FileHash:
+			if p, ok := names.file(uint64(v)); ok {
+				addPath(p)
+			}
+		case *List:
+			for _, it := range v.Items {
+				walk(it)
+			}
+		case *Pointer:
+			walkFields(v.Fields)
+		case *Embed:
+			walkFields(v.Fields)
+		case *Option:
+			walk(v.Value)
+		case *Map:
+			for _, e := range v.Entries {
+				walk(e.Key)
+				walk(e.Value)
+			}
+		}
+	}
+	for _, e := range f.Entries {
+		if e.Class == vfxSystemClass {
+			add(AssetParticle, names.name(e.Path))
+		}
+		walkFields(e.Fields)
+	}
+	for _, p := range f.Patches {
+		walk(p.Value)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// --- End of json.go ---
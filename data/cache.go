@@ -0,0 +1,322 @@
+// skinhunter/data/cache.go
+package data
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Caché en disco de las respuestas JSON del catálogo (champion-summary, skins,
+// champions/{id}...). Cada URL se guarda como <sha1>.json + <sha1>.meta y se
+// revalida con ETag / If-Modified-Since. Si la red falla se sirve la copia local.
+
+// revalidateTimeout es más corto que el del httpClient para que, con caché, un
+// arranque sin red no se quede colgado esperando. Solo cubre la conexión y la
+// llegada de las cabeceras: un 200 grande se sigue leyendo con el timeout normal.
+const revalidateTimeout = 5 * time.Second
+
+// offlineRecheck es cuánto tiempo se sirve la caché sin probar la red tras un fallo.
+const offlineRecheck = 30 * time.Second
+
+// revalidateClient se usa cuando hay copia en disco.
+var revalidateClient = newRevalidateClient()
+
+func newRevalidateClient() *http.Client {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = (&net.Dialer{Timeout: revalidateTimeout, KeepAlive: 30 * time.Second}).DialContext
+	tr.TLSHandshakeTimeout = revalidateTimeout
+	tr.ResponseHeaderTimeout = revalidateTimeout
+	return &http.Client{Timeout: httpClient.Timeout, Transport: tr}
+}
+
+type cacheMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+// CacheStatus describe si la sesión actual está usando datos de la caché por falta de red.
+type CacheStatus struct {
+	Offline  bool
+	DataTime time.Time // Fecha de la copia más antigua servida sin red
+}
+
+// hostState es el estado de red de un servidor (esquema + host de la URL): un
+// fallo de CommunityDragon no afecta a un mirror ni a Supabase, y viceversa.
+type hostState struct {
+	offlineUntil time.Time // Hasta entonces no se reintenta la red para lo que ya está en caché
+	offline      bool      // Se han servido copias locales de este servidor
+	dataTime     time.Time // Copia más antigua servida sin red
+}
+
+var (
+	diskCacheDir   string
+	diskCacheMutex sync.Mutex
+	hostStates     = make(map[string]*hostState)
+)
+
+func init() {
+	if dir, err := os.UserCacheDir(); err == nil {
+		diskCacheDir = filepath.Join(dir, "skinhunter", "catalog")
+	} else {
+		log.Printf("WARN: No user cache dir, catalog cache disabled: %v", err)
+	}
+}
+
+// SetCacheDir cambia el directorio de la caché del catálogo ("" la desactiva).
+func SetCacheDir(dir string) {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	diskCacheDir = dir
+}
+
+// GetCacheStatus devuelve el estado offline de la sesión: offline si algún
+// servidor lo está, con la fecha de la copia más antigua servida sin red.
+func GetCacheStatus() CacheStatus {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	var st CacheStatus
+	for _, h := range hostStates {
+		if !h.offline {
+			continue
+		}
+		st.Offline = true
+		if st.DataTime.IsZero() || h.dataTime.Before(st.DataTime) {
+			st.DataTime = h.dataTime
+		}
+	}
+	return st
+}
+
+// hostKey identifica el servidor de rawURL para el estado offline.
+func hostKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// hostLocked devuelve (creándolo) el estado de host. Requiere diskCacheMutex.
+func hostLocked(host string) *hostState {
+	h := hostStates[host]
+	if h == nil {
+		h = &hostState{}
+		hostStates[host] = h
+	}
+	return h
+}
+
+// hostOffline indica si host falló hace menos de offlineRecheck.
+func hostOffline(host string) bool {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	h := hostStates[host]
+	return h != nil && time.Now().Before(h.offlineUntil)
+}
+
+func cachePaths(url string) (bodyPath, metaPath string, ok bool) {
+	diskCacheMutex.Lock()
+	dir := diskCacheDir
+	diskCacheMutex.Unlock()
+	if dir == "" {
+		return "", "", false
+	}
+	sum := sha1.Sum([]byte(url))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(dir, key+".json"), filepath.Join(dir, key+".meta"), true
+}
+
+func readCacheEntry(url string) ([]byte, *cacheMeta) {
+	bodyPath, metaPath, ok := cachePaths(url)
+	if !ok {
+		return nil, nil
+	}
+	mb, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, nil
+	}
+	var meta cacheMeta
+	if err := json.Unmarshal(mb, &meta); err != nil || meta.URL != url {
+		return nil, nil
+	}
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return nil, nil
+	}
+	return body, &meta
+}
+
+// writeCacheEntry guarda body y meta; con body nil solo actualiza meta (respuesta 304).
+func writeCacheEntry(body []byte, meta cacheMeta) {
+	bodyPath, metaPath, ok := cachePaths(meta.URL)
+	if !ok {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(bodyPath), 0o755); err != nil {
+		log.Printf("WARN: Cannot create cache dir: %v", err)
+		return
+	}
+	if body != nil {
+		if err := writeFileAtomic(bodyPath, body); err != nil {
+			log.Printf("WARN: Cannot write cache entry for %s: %v", meta.URL, err)
+			return
+		}
+	}
+	mb, _ := json.Marshal(meta)
+	if err := writeFileAtomic(metaPath, mb); err != nil {
+		log.Printf("WARN: Cannot write cache meta for %s: %v", meta.URL, err)
+	}
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// markUnreachable registra que no se pudo contactar con host: durante
+// offlineRecheck lo que esté en caché se sirve sin probar la red. Así, si se
+// arranca sin red, solo la primera petición a cada servidor espera al timeout.
+func markUnreachable(host string) {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	hostLocked(host).offlineUntil = time.Now().Add(offlineRecheck)
+}
+
+// markOffline registra que se sirvió una copia local de host con fecha fetchedAt.
+func markOffline(host string, fetchedAt time.Time) {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	h := hostLocked(host)
+	h.offline = true
+	if h.dataTime.IsZero() || fetchedAt.Before(h.dataTime) {
+		h.dataTime = fetchedAt
+	}
+}
+
+// resetCacheStatus olvida el estado offline (al cambiar de fuente o reintentar la carga).
+func resetCacheStatus() {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	hostStates = make(map[string]*hostState)
+}
+
+// markOnline se llama tras una respuesta válida (200/304) de host: sus datos
+// vuelven a estar al día. No toca el estado de otros servidores.
+func markOnline(host string) {
+	diskCacheMutex.Lock()
+	defer diskCacheMutex.Unlock()
+	delete(hostStates, host)
+}
+
+// cachedGet descarga url revalidando contra la copia en disco. Si ctx se cancela
+// (el usuario salió de la vista) se devuelve ctx.Err() sin tocar el estado offline.
+// Los fallos transitorios se reintentan con backoff (errors.go); con copia local
+// se hacen menos intentos y, si siguen fallando, se sirve la copia.
+func cachedGet(ctx context.Context, url string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	host := hostKey(url)
+	cached, meta := readCacheEntry(url)
+	attempts := retryAttempts
+	if cached != nil {
+		if hostOffline(host) {
+			markOffline(host, meta.FetchedAt)
+			return cached, nil
+		}
+		attempts = revalidateAttempts
+	}
+
+	var body []byte
+	err := retry(ctx, "GET "+url, attempts, func() error {
+		var err error
+		body, err = fetchRevalidate(ctx, url, cached, meta)
+		return err
+	})
+	if err == nil {
+		return body, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if cached != nil && IsTransient(err) {
+		log.Printf("WARN: %v; using cached copy of %s from %s", err, url, meta.FetchedAt.Format(time.RFC3339))
+		markUnreachable(host)
+		markOffline(host, meta.FetchedAt)
+		return cached, nil
+	}
+	if errors.Is(err, ErrOffline) {
+		markUnreachable(host)
+	}
+	return nil, err
+}
+
+// fetchRevalidate hace un único GET de url; cached/meta son la copia en disco (o nil).
+// Devuelve la copia si el servidor responde 304.
+func fetchRevalidate(ctx context.Context, url string, cached []byte, meta *cacheMeta) ([]byte, error) {
+	client := httpClient
+	if cached != nil {
+		client = revalidateClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOffline, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		markOnline(hostKey(url))
+		meta.FetchedAt = time.Now()
+		writeCacheEntry(nil, *meta)
+		return cached, nil
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading %s: %v", ErrOffline, url, err)
+		}
+		writeCacheEntry(body, cacheMeta{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+		})
+		markOnline(hostKey(url))
+		return body, nil
+	default:
+		return nil, newStatusError(url, resp)
+	}
+}
+
+// --- End of cache.go ---
@@ -0,0 +1,256 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useTempCache apunta la caché del catálogo a un directorio temporal.
+func useTempCache(t *testing.T) {
+	t.Helper()
+	SetCacheDir(t.TempDir())
+	resetCacheStatus()
+	t.Cleanup(func() {
+		SetCacheDir("")
+		resetCacheStatus()
+	})
+}
+
+// etagServer sirve body con ETag/Last-Modified y responde 304 si el cliente los envía.
+type etagServer struct {
+	*httptest.Server
+	status      atomic.Int32 // Si no es 0, se responde con este código
+	notModified atomic.Int32
+	lastINM     atomic.Value
+	lastIMS     atomic.Value
+}
+
+const (
+	testETag         = `"v1"`
+	testLastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	testBody         = `{"version":"14.9.1.2"}`
+)
+
+func newETagServer(t *testing.T) *etagServer {
+	s := &etagServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lastINM.Store(r.Header.Get("If-None-Match"))
+		s.lastIMS.Store(r.Header.Get("If-Modified-Since"))
+		if code := s.status.Load(); code != 0 {
+			w.WriteHeader(int(code))
+			return
+		}
+		if r.Header.Get("If-None-Match") == testETag {
+			s.notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", testETag)
+		w.Header().Set("Last-Modified", testLastModified)
+		w.Write([]byte(testBody))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestCachedGetRevalidatesWithETag(t *testing.T) {
+	useTempCache(t)
+	srv := newETagServer(t)
+	ctx := context.Background()
+
+	body, err := cachedGet(ctx, srv.URL)
+	if err != nil || string(body) != testBody {
+		t.Fatalf("first get = %q, %v", body, err)
+	}
+	if inm := srv.lastINM.Load(); inm != "" {
+		t.Errorf("first request sent If-None-Match %q", inm)
+	}
+
+	body, err = cachedGet(ctx, srv.URL)
+	if err != nil || string(body) != testBody {
+		t.Fatalf("second get = %q, %v", body, err)
+	}
+	if inm := srv.lastINM.Load(); inm != testETag {
+		t.Errorf("If-None-Match = %q, want %q", inm, testETag)
+	}
+	if ims := srv.lastIMS.Load(); ims != testLastModified {
+		t.Errorf("If-Modified-Since = %q, want %q", ims, testLastModified)
+	}
+	if srv.notModified.Load() != 1 {
+		t.Errorf("expected one 304 response, got %d", srv.notModified.Load())
+	}
+	if GetCacheStatus().Offline {
+		t.Error("304 should not mark the session offline")
+	}
+}
+
+func TestCachedGetFallsBackOn5xx(t *testing.T) {
+	fastRetries(t)
+	useTempCache(t)
+	srv := newETagServer(t)
+	ctx := context.Background()
+	if _, err := cachedGet(ctx, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.status.Store(http.StatusServiceUnavailable)
+	body, err := cachedGet(ctx, srv.URL)
+	if err != nil || string(body) != testBody {
+		t.Fatalf("get with 503 = %q, %v; want cached copy", body, err)
+	}
+	st := GetCacheStatus()
+	if !st.Offline || st.DataTime.IsZero() {
+		t.Errorf("status = %+v, want offline with data time", st)
+	}
+
+	// Una respuesta válida posterior quita el aviso de offline.
+	resetOfflineWindow()
+	srv.status.Store(0)
+	if _, err := cachedGet(ctx, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if GetCacheStatus().Offline {
+		t.Error("successful revalidation should clear the offline status")
+	}
+}
+
+func TestCachedGetFallsBackOnNetworkError(t *testing.T) {
+	fastRetries(t)
+	useTempCache(t)
+	srv := newETagServer(t)
+	ctx := context.Background()
+	if _, err := cachedGet(ctx, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	url := srv.URL
+	srv.Close()
+
+	body, err := cachedGet(ctx, url)
+	if err != nil || string(body) != testBody {
+		t.Fatalf("get without network = %q, %v; want cached copy", body, err)
+	}
+	if !GetCacheStatus().Offline {
+		t.Error("expected offline status after network error")
+	}
+
+	// Sin copia en disco el error llega tipado.
+	if _, err := cachedGet(ctx, url+"/missing.json"); !IsTransient(err) {
+		t.Errorf("uncached get without network = %v, want transient error", err)
+	}
+}
+
+func TestCachedGet404IsNotServedFromCache(t *testing.T) {
+	useTempCache(t)
+	srv := newETagServer(t)
+	ctx := context.Background()
+	if _, err := cachedGet(ctx, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	srv.status.Store(http.StatusNotFound)
+	if _, err := cachedGet(ctx, srv.URL); err == nil {
+		t.Fatal("expected ErrNotFound for 404 even with cached copy")
+	}
+}
+
+func TestReadCacheEntryRejectsMismatchedURL(t *testing.T) {
+	useTempCache(t)
+	const url = "https://example.invalid/v1/skins.json"
+	writeCacheEntry([]byte(testBody), cacheMeta{URL: url, ETag: testETag, FetchedAt: time.Now()})
+	if body, meta := readCacheEntry(url); body == nil || meta == nil || meta.ETag != testETag {
+		t.Fatalf("readCacheEntry = %q, %+v", body, meta)
+	}
+
+	_, metaPath, _ := cachePaths(url)
+	mb, _ := json.Marshal(cacheMeta{URL: "https://example.invalid/other.json", FetchedAt: time.Now()})
+	if err := os.WriteFile(metaPath, mb, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if body, meta := readCacheEntry(url); body != nil || meta != nil {
+		t.Errorf("entry with mismatched meta URL should be ignored, got %q", body)
+	}
+}
+
+// resetOfflineWindow deja que la siguiente petición vuelva a probar la red.
+func resetOfflineWindow() {
+	diskCacheMutex.Lock()
+	for _, h := range hostStates {
+		h.offlineUntil = time.Time{}
+	}
+	diskCacheMutex.Unlock()
+}
+
+func TestCachedGetSkipsNetworkWhileHostUnreachable(t *testing.T) {
+	fastRetries(t)
+	useTempCache(t)
+	srv := newETagServer(t)
+	url := srv.URL
+	srv.Close()
+
+	// Arranque sin red: hay copia de skins.json, pero la primera petición
+	// (content-metadata.json, sin copia) falla al conectar.
+	cachedURL := url + "/v1/skins.json"
+	writeCacheEntry([]byte(testBody), cacheMeta{URL: cachedURL, FetchedAt: time.Now().Add(-time.Hour)})
+	ctx := context.Background()
+	if _, err := cachedGet(ctx, url+"/content-metadata.json"); !errors.Is(err, ErrOffline) {
+		t.Fatalf("uncached get without network = %v, want ErrOffline", err)
+	}
+
+	// Se sirve la copia sin volver a intentar la red, aunque el cliente tarde en fallar.
+	saved := revalidateClient
+	var dials atomic.Int32
+	revalidateClient = &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		dials.Add(1)
+		return nil, errors.New("should not be called")
+	})}
+	t.Cleanup(func() { revalidateClient = saved })
+	body, err := cachedGet(ctx, cachedURL)
+	if err != nil || string(body) != testBody {
+		t.Fatalf("cached get while offline = %q, %v", body, err)
+	}
+	if dials.Load() != 0 {
+		t.Errorf("network tried %d times while the host is known to be unreachable", dials.Load())
+	}
+	if !GetCacheStatus().Offline {
+		t.Error("serving the cached copy should mark the session offline")
+	}
+}
+
+func TestCacheStatusIsPerHost(t *testing.T) {
+	fastRetries(t)
+	useTempCache(t)
+	down := newETagServer(t)
+	up := newETagServer(t)
+	ctx := context.Background()
+	if _, err := cachedGet(ctx, down.URL); err != nil {
+		t.Fatal(err)
+	}
+	down.status.Store(http.StatusServiceUnavailable)
+	if _, err := cachedGet(ctx, down.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !GetCacheStatus().Offline {
+		t.Fatal("expected offline status after 503 with cached copy")
+	}
+
+	// Una respuesta válida de otro servidor no quita el aviso.
+	if _, err := cachedGet(ctx, up.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !GetCacheStatus().Offline {
+		t.Error("success on another host should not clear the offline status")
+	}
+	if !hostOffline(hostKey(down.URL)) || hostOffline(hostKey(up.URL)) {
+		t.Error("offline window should only apply to the failing host")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,112 @@
+// skinhunter/data/champion_filter.go
+package data
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChampionRoles son los roles de ChampionSummary.Roles, tal como vienen en el JSON.
+var ChampionRoles = []string{"assassin", "fighter", "mage", "marksman", "support", "tank"}
+
+// ChampionSort es el criterio de orden de FilterChampions.
+type ChampionSort int
+
+const (
+	SortChampionsByName      ChampionSort = iota
+	SortChampionsByID                     // Orden de publicación
+	SortChampionsBySkinCount              // Más skins primero; empates por nombre
+)
+
+func (s ChampionSort) String() string {
+	switch s {
+	case SortChampionsByID:
+		return "Release"
+	case SortChampionsBySkinCount:
+		return "Skin count"
+	}
+	return "Name"
+}
+
+// ChampionFilter son los filtros de la barra del grid de campeones. El valor cero no filtra nada.
+type ChampionFilter struct {
+	Roles []string // Basta con tener uno de ellos; vacío = cualquiera
+	Name  string   // Texto libre; se compara con NormalizeSearchText contra nombre y alias
+}
+
+// IsZero indica si el filtro deja pasar todos los campeones.
+func (f ChampionFilter) IsZero() bool {
+	return len(f.Roles) == 0 && strings.TrimSpace(f.Name) == ""
+}
+
+// nameMatcher devuelve la comprobación del filtro de nombre, con la consulta ya normalizada.
+func (f ChampionFilter) nameMatcher() func(ChampionSummary) bool {
+	q := NormalizeSearchText(f.Name)
+	if q == "" {
+		return func(ChampionSummary) bool { return true }
+	}
+	qCompact := strings.ReplaceAll(q, " ", "")
+	return func(c ChampionSummary) bool {
+		for _, s := range []string{c.Name, c.Alias} {
+			n := NormalizeSearchText(s)
+			if strings.Contains(n, q) || strings.Contains(strings.ReplaceAll(n, " ", ""), qCompact) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (f ChampionFilter) matchRoles(c ChampionSummary) bool {
+	if len(f.Roles) == 0 {
+		return true
+	}
+	for _, want := range f.Roles {
+		for _, r := range c.Roles {
+			if strings.EqualFold(r, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterChampions devuelve una copia de champs con los que pasan f, ordenados por by.
+// skinCounts (de SkinCountsByChampion) solo se usa con SortChampionsBySkinCount.
+func FilterChampions(champs []ChampionSummary, f ChampionFilter, by ChampionSort, skinCounts map[int]int) []ChampionSummary {
+	matchName := f.nameMatcher()
+	out := make([]ChampionSummary, 0, len(champs))
+	for _, c := range champs {
+		if f.matchRoles(c) && matchName(c) {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch by {
+		case SortChampionsByID:
+			return a.ID < b.ID
+		case SortChampionsBySkinCount:
+			if ca, cb := skinCounts[a.ID], skinCounts[b.ID]; ca != cb {
+				return ca > cb
+			}
+		}
+		return NormalizeSearchText(a.Name) < NormalizeSearchText(b.Name)
+	})
+	return out
+}
+
+// SkinCountsByChampion cuenta las skins (sin la base) de cada campeón del catálogo.
+func SkinCountsByChampion() map[int]int {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	counts := make(map[int]int)
+	for _, s := range allSkinsMap {
+		if !s.IsBase {
+			counts[GetChampionIDFromSkinID(s.ID)]++
+		}
+	}
+	return counts
+}
+
+// --- End of champion_filter.go ---
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterChampions(t *testing.T) {
+	champs := []ChampionSummary{
+		{ID: 103, Name: "Ahri", Alias: "Ahri", Roles: []string{"mage", "assassin"}},
+		{ID: 62, Name: "Wukong", Alias: "MonkeyKing", Roles: []string{"fighter", "tank"}},
+		{ID: 145, Name: "Kai'Sa", Alias: "Kaisa", Roles: []string{"marksman"}},
+		{ID: 20, Name: "Nunu & Willump", Alias: "Nunu", Roles: []string{"tank", "mage"}},
+		{ID: 99, Name: "Lux", Alias: "Lux", Roles: []string{"mage", "support"}},
+	}
+	counts := map[int]int{103: 20, 99: 20, 145: 12, 62: 8}
+	ids := func(cs []ChampionSummary) []int {
+		out := make([]int, len(cs))
+		for i, c := range cs {
+			out[i] = c.ID
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		filter ChampionFilter
+		by     ChampionSort
+		want   []int
+	}{
+		{"all by name", ChampionFilter{}, SortChampionsByName, []int{103, 145, 99, 20, 62}},
+		{"all by ID", ChampionFilter{}, SortChampionsByID, []int{20, 62, 99, 103, 145}},
+		{"all by skin count", ChampionFilter{}, SortChampionsBySkinCount, []int{103, 99, 145, 62, 20}},
+		{"mages", ChampionFilter{Roles: []string{"mage"}}, SortChampionsByName, []int{103, 99, 20}},
+		{"tank or marksman", ChampionFilter{Roles: []string{"Tank", "marksman"}}, SortChampionsByID, []int{20, 62, 145}},
+		{"name", ChampionFilter{Name: "kai"}, SortChampionsByName, []int{145}},
+		{"name ignores punctuation", ChampionFilter{Name: "kaisa"}, SortChampionsByName, []int{145}},
+		{"alias", ChampionFilter{Name: "monkey"}, SortChampionsByName, []int{62}},
+		{"compact", ChampionFilter{Name: "nunuw"}, SortChampionsByName, []int{20}},
+		{"role and name", ChampionFilter{Roles: []string{"mage"}, Name: "lux"}, SortChampionsByName, []int{99}},
+		{"blank name", ChampionFilter{Name: "  "}, SortChampionsByID, []int{20, 62, 99, 103, 145}},
+		{"nothing", ChampionFilter{Roles: []string{"support"}, Name: "ahri"}, SortChampionsByName, []int{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ids(FilterChampions(champs, tc.filter, tc.by, counts)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSkinCountsByChampion(t *testing.T) {
+	setTestCatalog(t, nil, []Skin{{ID: 103000}, {ID: 103001}, {ID: 103015}, {ID: 62000}, {ID: 62001}}, nil)
+	want := map[int]int{103: 2, 62: 1}
+	if got := SkinCountsByChampion(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SkinCountsByChampion() = %v, want %v", got, want)
+	}
+}
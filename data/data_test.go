@@ -0,0 +1,261 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPatchFromVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"14.9.584.1234", "14.9"},
+		{"14.10.1", "14.10"},
+		{"14.9", "14.9"},
+		{"", "latest"},
+		{"latest", "latest"},
+		{"pbe", "pbe"},
+	}
+	for _, tc := range tests {
+		if got := PatchFromVersion(tc.version); got != tc.want {
+			t.Errorf("PatchFromVersion(%q) = %q, want %q", tc.version, got, tc.want)
+		}
+	}
+}
+
+// patchServer sirve un layout de CommunityDragon mínimo bajo /<patch>/ y guarda las rutas pedidas.
+type patchServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	paths []string
+	meta  string
+}
+
+func newPatchServer(t *testing.T, meta string) *patchServer {
+	s := &patchServer{meta: meta}
+	files := map[string]string{
+		"/v1/champion-summary.json": `[{"id":103,"name":"Ahri","alias":"Ahri"}]`,
+		"/v1/skins.json":            `{"103000":{"name":"Ahri"}}`,
+		"/v1/skinlines.json":        `[{"id":9,"name":"Star Guardian"}]`,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/content-metadata.json") {
+			w.Write([]byte(s.meta))
+			return
+		}
+		for suffix, body := range files {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				w.Write([]byte(body))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *patchServer) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+func TestFetchCDragonVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    string
+		want    string
+		wantErr bool
+	}{
+		{"full version", `{"version":"14.9.584.1234"}`, "14.9.584.1234", false},
+		{"extra fields", `{"version":"14.10.1","channel":"live"}`, "14.10.1", false},
+		{"no version", `{"channel":"live"}`, "", true},
+		{"invalid JSON", `not json`, "", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			useTempCache(t)
+			srv := newPatchServer(t, tc.meta)
+			src, err := NewSourceFromLocation(srv.URL, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := fetchCDragonVersion(context.Background(), src)
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Errorf("fetchCDragonVersion = %q, %v; want %q (error %v)", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPinnedPatchResolvesUnderPatchDir(t *testing.T) {
+	useTempCache(t)
+	srv := newPatchServer(t, `{"version":"14.9.584.1234"}`)
+	src, err := NewSourceFromLocation(srv.URL+"/"+versionPlaceholder, "14.9", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDataSource(src)
+	t.Cleanup(func() { SetDataSource(nil) })
+
+	if err := InitData(context.Background()); err != nil {
+		t.Fatalf("InitData: %v", err)
+	}
+	if got := GetCDragonVersion(); got != "14.9.584.1234" {
+		t.Errorf("GetCDragonVersion = %q", got)
+	}
+	if got := GetPatch(); got != "14.9" {
+		t.Errorf("GetPatch = %q, want 14.9", got)
+	}
+	paths := srv.requested()
+	if len(paths) < 4 {
+		t.Fatalf("requested %v, want content-metadata, champion-summary, skins and skinlines", paths)
+	}
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/14.9/") {
+			t.Errorf("fetched %s outside the pinned patch", p)
+		}
+	}
+	if got, want := Asset("/lol-game-data/assets/ASSETS/Characters/Ahri/Tile.png"),
+		srv.URL+"/14.9"+gameDataPluginPath+"/assets/characters/ahri/tile.png"; got != want {
+		t.Errorf("Asset = %q, want %q", got, want)
+	}
+}
+
+func TestGetPatchWithoutData(t *testing.T) {
+	SetDataSource(nil)
+	if got := GetPatch(); got != "latest" {
+		t.Errorf("GetPatch before loading = %q, want latest", got)
+	}
+}
+
+func TestGetSkinLinesReadsCache(t *testing.T) {
+	lines := []SkinLine{{ID: 9, Name: "Star Guardian"}}
+	setTestCatalog(t, []ChampionSummary{{ID: 103, Name: "Ahri", Alias: "Ahri"}}, []Skin{{ID: 103000, Name: "Ahri"}}, lines)
+
+	// Con la caché llena no se pasa por InitData: un ctx cancelado no importa.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := GetSkinLines(ctx)
+	if err != nil || len(got) != 1 || got[0].Name != "Star Guardian" {
+		t.Fatalf("GetSkinLines = %+v, %v", got, err)
+	}
+	got[0].Name = "changed"
+	if again, _ := GetSkinLines(ctx); again[0].Name != "Star Guardian" {
+		t.Error("GetSkinLines returned the cache slice instead of a copy")
+	}
+}
+
+func TestGetSkinsForSkinLine(t *testing.T) {
+	line := func(ids ...int) []struct{ ID int } {
+		var ls []struct{ ID int }
+		for _, id := range ids {
+			ls = append(ls, struct{ ID int }{id})
+		}
+		return ls
+	}
+	setTestCatalog(t,
+		[]ChampionSummary{{ID: 103, Name: "Ahri", Alias: "Ahri"}, {ID: 21, Name: "Miss Fortune", Alias: "MissFortune"}, {ID: 145, Name: "Kai'Sa", Alias: "Kaisa"}},
+		[]Skin{
+			{ID: 103000, Name: "Ahri"},
+			{ID: 103015, Name: "Star Guardian Ahri", SkinLines: line(9), Chromas: []Chroma{{ID: 103016}}},
+			{ID: 103027, Name: "Arcade Ahri", SkinLines: line(12)},
+			{ID: 21020, Name: "Star Guardian Miss Fortune", SkinLines: line(12, 9)},
+			{ID: 145015, Name: "Star Guardian Kai'Sa", SkinLines: line(9, 30)},
+			{ID: 145001, Name: "Bullet Angel Kai'Sa"},
+		},
+		[]SkinLine{{ID: 9, Name: "Star Guardian"}, {ID: 12, Name: "Arcade"}, {ID: 30, Name: "Empty"}},
+	)
+
+	tests := []struct {
+		line int
+		want []int
+	}{
+		{9, []int{21020, 103015, 145015}},
+		{12, []int{21020, 103027}},
+		{30, []int{145015}},
+		{99, nil},
+	}
+	for _, tc := range tests {
+		skins, err := GetSkinsForSkinLine(tc.line)
+		if err != nil {
+			t.Fatalf("GetSkinsForSkinLine(%d): %v", tc.line, err)
+		}
+		var ids []int
+		for _, s := range skins {
+			ids = append(ids, s.ID)
+			for _, ch := range s.Chromas {
+				if ch.OriginSkinID != s.ID {
+					t.Errorf("chroma %d of skin %d has OriginSkinID %d", ch.ID, s.ID, ch.OriginSkinID)
+				}
+			}
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tc.want) {
+			t.Errorf("GetSkinsForSkinLine(%d) = %v, want %v", tc.line, ids, tc.want)
+		}
+	}
+}
+
+func TestFetchChampionDetailsCancel(t *testing.T) {
+	useTempCache(t)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`{"id":103,"name":"Ahri"}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) }) // Antes de srv.Close, que espera a los handlers
+	src, err := NewSourceFromLocation(srv.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDataSource(src)
+	t.Cleanup(func() { SetDataSource(nil) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := FetchChampionDetails(ctx, 103)
+		done <- err
+	}()
+	<-started
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("FetchChampionDetails did not return after cancel")
+	}
+
+	cacheMutex.RLock()
+	_, cached := championDetailCache[103]
+	cacheMutex.RUnlock()
+	if cached {
+		t.Error("cancelled load was stored in the champion cache")
+	}
+	if body, _ := readCacheEntry(srv.URL + gameDataPluginPath + "/v1/champions/103.json"); body != nil {
+		t.Error("cancelled load was written to the disk cache")
+	}
+	if GetCacheStatus().Offline {
+		t.Error("cancellation should not mark the session offline")
+	}
+}
@@ -0,0 +1,144 @@
+// skinhunter/data/errors.go
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errores tipados de la capa de datos. Las funciones públicas los envuelven con
+// %w, así que la UI puede usar errors.Is para elegir el mensaje (ui.ErrorMessage).
+var (
+	// ErrOffline indica que no se pudo contactar con el servidor (sin red, DNS, timeout).
+	ErrOffline = errors.New("network unavailable")
+	// ErrNotFound indica que el recurso no existe en la fuente (404, fichero ausente).
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited indica que el servidor pidió bajar el ritmo (429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrBadPayload indica que la respuesta llegó pero no se pudo interpretar.
+	ErrBadPayload = errors.New("unexpected data format")
+)
+
+// StatusError es una respuesta HTTP con un código inesperado. Unwrap la relaciona
+// con ErrNotFound o ErrRateLimited cuando aplica.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration // Cabecera Retry-After, si la había
+}
+
+func (e *StatusError) Error() string { return fmt.Sprintf("bad status %s for %s", e.Status, e.URL) }
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+func newStatusError(url string, resp *http.Response) *StatusError {
+	se := &StatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		se.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return se
+}
+
+// badPayload envuelve un error de parseo de name como ErrBadPayload.
+func badPayload(name string, err error) error {
+	return fmt.Errorf("%w: %s: %v", ErrBadPayload, name, err)
+}
+
+// UserMessage devuelve el texto para el usuario de los errores de este paquete
+// (ok = false si err no es de la capa de datos). Lo usa ui.ErrorMessage.
+func UserMessage(err error) (msg string, ok bool) {
+	var se *StatusError
+	switch {
+	case errors.Is(err, ErrOffline):
+		return "Can't reach the server. Check your internet connection and try again.", true
+	case errors.Is(err, ErrRateLimited):
+		return "The server is receiving too many requests right now. Wait a moment and try again.", true
+	case errors.Is(err, ErrNotFound):
+		return "This data isn't available for the selected patch or language. Try another one in Settings.", true
+	case errors.Is(err, ErrBadPayload):
+		return "The server sent data that couldn't be read. Try again later or choose another data source in Settings.", true
+	case errors.As(err, &se) && se.StatusCode >= 500:
+		return fmt.Sprintf("The data server is having problems (%s). Try again in a few minutes.", se.Status), true
+	}
+	return "", false
+}
+
+// IsTransient indica si err puede desaparecer reintentando: sin red, 429, 408 o 5xx.
+func IsTransient(err error) bool {
+	if errors.Is(err, ErrOffline) || errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusRequestTimeout || se.StatusCode >= 500
+	}
+	return false
+}
+
+// --- Reintentos con backoff exponencial ---
+
+const (
+	retryAttempts      = 4
+	revalidateAttempts = 2 // Con copia en disco: mejor servirla pronto que esperar al servidor
+)
+
+// Variables para que los tests no tengan que esperar segundos.
+var (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// retryDelay devuelve la espera antes del reintento attempt (0, 1, 2...):
+// "full jitter" sobre base*2^attempt, acotado a retryMaxDelay. Un Retry-After
+// del servidor se respeta si no supera retryMaxDelay.
+func retryDelay(attempt int, err error) time.Duration {
+	ceil := retryBaseDelay << attempt
+	if ceil > retryMaxDelay || ceil <= 0 {
+		ceil = retryMaxDelay
+	}
+	d := time.Duration(rand.Int64N(int64(ceil))) + 1
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > d && se.RetryAfter <= retryMaxDelay {
+		d = se.RetryAfter
+	}
+	return d
+}
+
+// retry ejecuta fn hasta attempts veces mientras devuelva un error
+// transitorio. La espera entre intentos se interrumpe si ctx se cancela.
+func retry(ctx context.Context, what string, attempts int, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil || !IsTransient(err) || attempt+1 >= attempts {
+			return err
+		}
+		d := retryDelay(attempt, err)
+		log.Printf("WARN: %s failed (attempt %d/%d), retrying in %s: %v", what, attempt+1, attempts, d.Round(time.Millisecond), err)
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// --- End of errors.go ---
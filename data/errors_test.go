@@ -0,0 +1,198 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries reduce las esperas del backoff mientras dura el test.
+func fastRetries(t *testing.T) {
+	base, max := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { retryBaseDelay, retryMaxDelay = base, max })
+}
+
+// statusServer responde con statuses[i] a la petición i y con el último a partir de ahí.
+func statusServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(calls.Add(1)) - 1
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[i])
+		if statuses[i] == http.StatusOK {
+			fmt.Fprint(w, `{"ok":true}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func getOnce(ctx context.Context, url string) error {
+	_, err := fetchRevalidate(ctx, url, nil, nil)
+	return err
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		status int
+		header http.Header
+		want   bool
+		is     error
+	}{
+		{http.StatusServiceUnavailable, nil, true, nil},
+		{http.StatusTooManyRequests, http.Header{"Retry-After": {"2"}}, true, ErrRateLimited},
+		{http.StatusNotFound, nil, false, ErrNotFound},
+	}
+	for _, tc := range tests {
+		srv, _ := statusServer(t, tc.header, tc.status)
+		err := getOnce(context.Background(), srv.URL)
+		if err == nil {
+			t.Fatalf("status %d: expected error", tc.status)
+		}
+		if got := IsTransient(err); got != tc.want {
+			t.Errorf("status %d: IsTransient = %v, want %v", tc.status, got, tc.want)
+		}
+		if tc.is != nil && !errors.Is(err, tc.is) {
+			t.Errorf("status %d: error %v is not %v", tc.status, err, tc.is)
+		}
+	}
+	if !IsTransient(fmt.Errorf("%w: dial tcp: refused", ErrOffline)) {
+		t.Error("ErrOffline should be transient")
+	}
+	if IsTransient(badPayload("skins.json", errors.New("eof"))) {
+		t.Error("ErrBadPayload should not be transient")
+	}
+	if IsTransient(context.Canceled) {
+		t.Error("context.Canceled should not be transient")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		ceil := retryBaseDelay << attempt
+		if ceil > retryMaxDelay {
+			ceil = retryMaxDelay
+		}
+		for i := 0; i < 50; i++ {
+			if d := retryDelay(attempt, errors.New("x")); d <= 0 || d > ceil {
+				t.Fatalf("attempt %d: delay %s outside (0, %s]", attempt, d, ceil)
+			}
+		}
+	}
+
+	srv, _ := statusServer(t, http.Header{"Retry-After": {"3"}}, http.StatusTooManyRequests)
+	err := getOnce(context.Background(), srv.URL)
+	var se *StatusError
+	if !errors.As(err, &se) || se.RetryAfter != 3*time.Second {
+		t.Fatalf("expected StatusError with RetryAfter=3s, got %#v", err)
+	}
+	if d := retryDelay(0, err); d != 3*time.Second {
+		t.Errorf("Retry-After not honoured: got %s", d)
+	}
+	se.RetryAfter = time.Hour
+	if d := retryDelay(0, se); d > retryMaxDelay {
+		t.Errorf("Retry-After above retryMaxDelay should be ignored, got %s", d)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	fastRetries(t)
+	tests := []struct {
+		name      string
+		header    http.Header
+		statuses  []int
+		wantErr   error
+		wantCalls int32
+	}{
+		{"503 then ok", nil, []int{503, 503, 200}, nil, 3},
+		{"503 forever", nil, []int{503}, nil, retryAttempts},
+		{"429 with Retry-After", http.Header{"Retry-After": {"1"}}, []int{429, 200}, nil, 2},
+		{"404 not retried", nil, []int{404}, ErrNotFound, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, calls := statusServer(t, tc.header, tc.statuses...)
+			start := time.Now()
+			err := retry(context.Background(), "test", retryAttempts, func() error {
+				return getOnce(context.Background(), srv.URL)
+			})
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tc.wantCalls)
+			}
+			last := tc.statuses[len(tc.statuses)-1]
+			switch {
+			case tc.wantErr != nil && !errors.Is(err, tc.wantErr):
+				t.Errorf("err = %v, want %v", err, tc.wantErr)
+			case last == http.StatusOK && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case last == http.StatusServiceUnavailable && !IsTransient(err):
+				t.Errorf("expected transient error after exhausting attempts, got %v", err)
+			}
+			if tc.header.Get("Retry-After") != "" && time.Since(start) > 900*time.Millisecond {
+				// Retry-After (1s) supera retryMaxDelay en el test: se ignora y se usa el backoff.
+				t.Errorf("Retry-After above retryMaxDelay should not be waited for")
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	err := retry(ctx, "test", retryAttempts, func() error {
+		calls++
+		cancel()
+		return fmt.Errorf("%w: boom", ErrOffline)
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("err = %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+}
+
+func TestCachedGetRetriesWithoutCache(t *testing.T) {
+	fastRetries(t)
+	useTempCache(t)
+	srv, calls := statusServer(t, nil, 503, 200)
+	body, err := cachedGet(context.Background(), srv.URL)
+	if err != nil || string(body) != `{"ok":true}` {
+		t.Fatalf("cachedGet = %q, %v", body, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestUserMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		wantOK bool
+		want   string // Fragmento esperado del mensaje
+	}{
+		{"offline", fmt.Errorf("loading: %w", ErrOffline), true, "internet connection"},
+		{"404", &StatusError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, true, "selected patch"},
+		{"429", &StatusError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}, true, "too many requests"},
+		{"503", &StatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, true, "503 Service Unavailable"},
+		{"bad payload", ErrRichDataMismatch, true, "couldn't be read"},
+		{"400", &StatusError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}, false, ""},
+		{"other", errors.New("boom"), false, ""},
+	}
+	for _, tc := range tests {
+		msg, ok := UserMessage(tc.err)
+		if ok != tc.wantOK || !strings.Contains(msg, tc.want) {
+			t.Errorf("%s: UserMessage = %q, %v; want %v containing %q", tc.name, msg, ok, tc.wantOK, tc.want)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+// skinhunter/data/query.go
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lenguaje de consultas para filtrar skins, por ejemplo:
+//
+//	rarity:epic legacy:true chromas:>3 line:"Star Guardian" champion:ahri
+//
+// Los términos separados por espacios se combinan con AND; "OR" une alternativas,
+// "-" niega un término y los paréntesis agrupan. Un término sin campo busca en el
+// nombre de la skin. Textos y nombres se comparan con NormalizeSearchText.
+//
+// Campos:
+//
+//	name:<texto>         el nombre contiene el texto
+//	champion:<nombre|id> campeón por nombre o alias exacto ("kaisa", "monkeyking") o por ID
+//	line:<nombre|id>     alguna skin line cuyo nombre contiene el texto, o con ese ID
+//	rarity:[op]<rareza>  standard < epic < legendary < mythic < ultimate
+//	legacy:<bool>        skin legacy
+//	base:<bool>          skin base del campeón
+//	chromas:[op]<n|bool> número de chromas; true/false equivale a >0 / 0
+//	id:[op]<n>           ID de la skin
+//
+// op es uno de =, >, >=, <, <= (por defecto =).
+
+// QueryError es un error de sintaxis en una consulta. Pos es el byte de la consulta
+// donde empieza el término erróneo.
+type QueryError struct {
+	Pos int
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Rarezas ordenadas de menor a mayor, con los nombres que devuelve Rarity.
+var rarityRanks = map[string]int{"standard": 0, "epic": 1, "legendary": 2, "mythic": 3, "ultimate": 4}
+
+type queryOp int
+
+const (
+	opEq queryOp = iota
+	opGt
+	opGe
+	opLt
+	opLe
+)
+
+func (op queryOp) compare(a, b int) bool {
+	switch op {
+	case opGt:
+		return a > b
+	case opGe:
+		return a >= b
+	case opLt:
+		return a < b
+	case opLe:
+		return a <= b
+	}
+	return a == b
+}
+
+// SkinQuery es una consulta ya analizada. Se puede reutilizar con distintos catálogos.
+type SkinQuery struct {
+	text string
+	root queryNode
+}
+
+// String devuelve el texto original de la consulta.
+func (q *SkinQuery) String() string { return q.text }
+
+type queryNode interface {
+	match(env *queryEnv, s *Skin) bool
+}
+
+type (
+	andNode []queryNode
+	orNode  []queryNode
+	notNode struct{ n queryNode }
+	// termNode compara un campo; text va normalizado y num guarda el valor numérico
+	// (ID, recuento o rango de rareza) cuando lo hay.
+	termNode struct {
+		field  string
+		op     queryOp
+		text   string
+		num    int
+		hasNum bool
+	}
+)
+
+func (n andNode) match(env *queryEnv, s *Skin) bool {
+	for _, c := range n {
+		if !c.match(env, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(env *queryEnv, s *Skin) bool {
+	for _, c := range n {
+		if c.match(env, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notNode) match(env *queryEnv, s *Skin) bool { return !n.n.match(env, s) }
+
+func (t termNode) match(env *queryEnv, s *Skin) bool {
+	switch t.field {
+	case "name":
+		return strings.Contains(NormalizeSearchText(s.Name), t.text)
+	case "champion":
+		champID := GetChampionIDFromSkinID(s.ID)
+		if t.hasNum {
+			return champID == t.num
+		}
+		for _, name := range env.champions[champID] {
+			if name == t.text {
+				return true
+			}
+		}
+		return false
+	case "line":
+		for _, sl := range s.SkinLines {
+			if t.hasNum && sl.ID == t.num || !t.hasNum && strings.Contains(env.lines[sl.ID], t.text) {
+				return true
+			}
+		}
+		return false
+	case "rarity":
+		name, _ := rarityInfo(*s)
+		rank, ok := rarityRanks[strings.ToLower(name)]
+		return ok && t.op.compare(rank, t.num)
+	case "legacy":
+		return s.IsLegacy == (t.num == 1)
+	case "base":
+		return s.IsBase == (t.num == 1)
+	case "chromas":
+		return t.op.compare(len(s.Chromas), t.num)
+	case "id":
+		return t.op.compare(s.ID, t.num)
+	}
+	return false
+}
+
+// queryEnv son los nombres normalizados del catálogo que necesitan champion: y line:.
+type queryEnv struct {
+	champions map[int][]string // ID de campeón -> nombre y alias
+	lines     map[int]string   // ID de skin line -> nombre
+}
+
+// --- Análisis ---
+
+type queryToken struct {
+	pos    int
+	kind   byte // '(' ')' '-' 'w' (palabra) 'o' (OR)
+	field  string
+	value  string
+	quoted bool
+}
+
+// tokenizeQuery separa la consulta en paréntesis, negaciones, OR y términos
+// campo:valor o sueltos. Las comillas permiten valores con espacios.
+func tokenizeQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '(' || c == ')':
+			toks = append(toks, queryToken{pos: i, kind: c})
+			i++
+			continue
+		case c == '-' && i+1 < len(s) && s[i+1] != ' ':
+			toks = append(toks, queryToken{pos: i, kind: '-'})
+			i++
+			continue
+		}
+		start := i
+		tok := queryToken{pos: start, kind: 'w'}
+		// Un campo es una palabra de letras seguida de ':'.
+		j := i
+		for j < len(s) && ('a' <= s[j] && s[j] <= 'z' || 'A' <= s[j] && s[j] <= 'Z') {
+			j++
+		}
+		if j > i && j < len(s) && s[j] == ':' {
+			tok.field = strings.ToLower(s[i:j])
+			i = j + 1
+		}
+		var b strings.Builder
+		for i < len(s) && !strings.ContainsRune(" \t\n\r()", rune(s[i])) {
+			if s[i] == '"' {
+				end := strings.IndexByte(s[i+1:], '"')
+				if end < 0 {
+					return nil, &QueryError{Pos: i, Msg: "unterminated quote"}
+				}
+				b.WriteString(s[i+1 : i+1+end])
+				tok.quoted = true
+				i += end + 2
+				continue
+			}
+			b.WriteByte(s[i])
+			i++
+		}
+		tok.value = b.String()
+		if tok.field == "" && !tok.quoted && tok.value == "OR" {
+			tok.kind = 'o'
+		}
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
+
+type queryParser struct {
+	toks []queryToken
+	i    int
+	end  int // Longitud de la consulta, para errores al final
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.i < len(p.toks) {
+		return &p.toks[p.i]
+	}
+	return nil
+}
+
+// ParseSkinQuery analiza una consulta. Los errores son *QueryError.
+func ParseSkinQuery(s string) (*SkinQuery, error) {
+	toks, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, &QueryError{Pos: 0, Msg: "empty query"}
+	}
+	p := &queryParser{toks: toks, end: len(s)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		return nil, &QueryError{Pos: t.pos, Msg: "unexpected ')'"}
+	}
+	return &SkinQuery{text: s, root: root}, nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	var alts orNode
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, n)
+		if t := p.peek(); t == nil || t.kind != 'o' {
+			break
+		}
+		p.i++
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return alts, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var all andNode
+	for {
+		t := p.peek()
+		if t == nil || t.kind == ')' || t.kind == 'o' {
+			break
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, n)
+	}
+	if len(all) == 0 {
+		pos := p.end
+		if t := p.peek(); t != nil {
+			pos = t.pos
+		}
+		return nil, &QueryError{Pos: pos, Msg: "expected a search term"}
+	}
+	if len(all) == 1 {
+		return all[0], nil
+	}
+	return all, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	t := p.peek()
+	p.i++
+	switch t.kind {
+	case '-':
+		if next := p.peek(); next == nil || next.kind == ')' || next.kind == 'o' {
+			return nil, &QueryError{Pos: t.pos, Msg: "'-' must be followed by a term"}
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case '(':
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.peek(); c == nil || c.kind != ')' {
+			return nil, &QueryError{Pos: t.pos, Msg: "missing ')'"}
+		}
+		p.i++
+		return n, nil
+	}
+	return parseTerm(*t)
+}
+
+// parseTerm valida el valor de un término según su campo.
+func parseTerm(t queryToken) (queryNode, error) {
+	errorf := func(format string, args ...any) error {
+		return &QueryError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+	}
+	field := t.field
+	if field == "" {
+		field = "name"
+	}
+	term := termNode{field: field}
+	value := t.value
+	switch field {
+	case "rarity", "chromas", "id":
+		term.op, value = splitQueryOp(value)
+	}
+	if strings.TrimSpace(value) == "" {
+		return nil, errorf("%s: missing value", field)
+	}
+
+	switch field {
+	case "name":
+		term.text = NormalizeSearchText(value)
+		if term.text == "" {
+			return nil, errorf("%q has no searchable characters", value)
+		}
+	case "champion", "line":
+		if n, err := strconv.Atoi(value); err == nil && !t.quoted {
+			term.num, term.hasNum = n, true
+			break
+		}
+		term.text = NormalizeSearchText(value)
+		if field == "champion" {
+			term.text = strings.ReplaceAll(term.text, " ", "") // Se compara en forma compacta
+		}
+	case "rarity":
+		rank, ok := rarityRanks[strings.ToLower(value)]
+		if !ok {
+			return nil, errorf("unknown rarity %q (use standard, epic, legendary, mythic or ultimate)", value)
+		}
+		term.num = rank
+	case "legacy", "base":
+		b, ok := parseQueryBool(value)
+		if !ok {
+			return nil, errorf("%s: expected true or false, got %q", field, value)
+		}
+		if b {
+			term.num = 1
+		}
+	case "chromas":
+		if b, ok := parseQueryBool(value); ok && term.op == opEq {
+			term.op, term.num = opGt, 0
+			if !b {
+				term.op = opEq
+			}
+			break
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, errorf("chromas: expected a number or true/false, got %q", value)
+		}
+		term.num = n
+	case "id":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errorf("id: expected a number, got %q", value)
+		}
+		term.num = n
+	default:
+		return nil, errorf("unknown field %q", t.field)
+	}
+	return term, nil
+}
+
+func splitQueryOp(v string) (queryOp, string) {
+	for _, p := range []struct {
+		prefix string
+		op     queryOp
+	}{{">=", opGe}, {"<=", opLe}, {">", opGt}, {"<", opLt}, {"=", opEq}} {
+		if strings.HasPrefix(v, p.prefix) {
+			return p.op, v[len(p.prefix):]
+		}
+	}
+	return opEq, v
+}
+
+func parseQueryBool(v string) (bool, bool) {
+	switch strings.ToLower(v) {
+	case "true", "yes", "1":
+		return true, true
+	case "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// --- Evaluación ---
+
+// QuerySkins devuelve las skins del catálogo que cumplen q, ordenadas por ID
+// (es decir, por campeón). Requiere que InitData haya cargado el catálogo.
+func QuerySkins(q *SkinQuery) ([]Skin, error) {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	if len(allSkinsMap) == 0 {
+		return nil, fmt.Errorf("skins map not initialized")
+	}
+	env := &queryEnv{champions: make(map[int][]string, len(championListCache)), lines: make(map[int]string, len(skinLinesCache))}
+	for _, ch := range championListCache {
+		env.champions[ch.ID] = []string{
+			strings.ReplaceAll(NormalizeSearchText(ch.Name), " ", ""),
+			strings.ReplaceAll(NormalizeSearchText(ch.Alias), " ", ""),
+		}
+	}
+	for _, sl := range skinLinesCache {
+		env.lines[sl.ID] = NormalizeSearchText(sl.Name)
+	}
+
+	skins := make([]Skin, 0)
+	for _, s := range allSkinsMap {
+		if !q.root.match(env, &s) {
+			continue
+		}
+		if len(s.Chromas) > 0 {
+			pcs := make([]Chroma, len(s.Chromas))
+			for i, ch := range s.Chromas {
+				pcs[i] = ch
+				pcs[i].OriginSkinID = s.ID
+			}
+			s.Chromas = pcs
+		}
+		skins = append(skins, s)
+	}
+	sort.Slice(skins, func(i, j int) bool { return skins[i].ID < skins[j].ID })
+	return skins, nil
+}
+
+// --- End of query.go ---
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func queryTestCatalog(t *testing.T) {
+	lines := func(ids ...int) []struct{ ID int } {
+		sl := make([]struct{ ID int }, len(ids))
+		for i, id := range ids {
+			sl[i].ID = id
+		}
+		return sl
+	}
+	chromas := func(n int) []Chroma { return make([]Chroma, n) }
+	setTestCatalog(t,
+		[]ChampionSummary{
+			{ID: 103, Name: "Ahri", Alias: "Ahri"},
+			{ID: 145, Name: "Kai'Sa", Alias: "Kaisa"},
+			{ID: 62, Name: "Wukong", Alias: "MonkeyKing"},
+		},
+		[]Skin{
+			{ID: 103000, Name: "Ahri"},
+			{ID: 103001, Name: "Dynasty Ahri", IsLegacy: true, Rarity: "/x/raritygem_epic.png"},
+			{ID: 103015, Name: "Star Guardian Ahri", Rarity: "/x/raritygem_epic.png", Chromas: chromas(5), SkinLines: lines(9)},
+			{ID: 103027, Name: "Spirit Blossom Ahri", Rarity: "/x/raritygem_legendary.png", Chromas: chromas(2)},
+			{ID: 145001, Name: "Bullet Angel Kai'Sa", Rarity: "/x/raritygem_ultimate.png", IsLegacy: true},
+			{ID: 145014, Name: "Star Guardian Kai'Sa", Rarity: "/x/raritygem_epic.png", Chromas: chromas(4), SkinLines: lines(9, 12)},
+			{ID: 62001, Name: "Volcanic Wukong", IsLegacy: true},
+		},
+		[]SkinLine{{ID: 9, Name: "Star Guardian"}, {ID: 12, Name: "Star Guardian Sanctum"}},
+	)
+}
+
+func TestQuerySkins(t *testing.T) {
+	queryTestCatalog(t)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{`rarity:epic legacy:true`, []int{103001}},
+		{`rarity:epic chromas:>3 line:"Star Guardian" champion:ahri`, []int{103015}},
+		{`rarity:>=legendary`, []int{103027, 145001}},
+		{`rarity:ultimate legacy:yes`, []int{145001}},
+		{`line:"star guardian"`, []int{103015, 145014}},
+		{`line:12`, []int{145014}},
+		{`champion:kaisa`, []int{145001, 145014}},
+		{`champion:"kai'sa" -legacy:true`, []int{145014}},
+		{`champion:monkeyking`, []int{62001}},
+		{`champion:62`, []int{62001}},
+		{`chromas:true`, []int{103015, 103027, 145014}},
+		{`chromas:false champion:ahri`, []int{103000, 103001}},
+		{`base:true`, []int{103000}},
+		{`star guardian`, []int{103015, 145014}},
+		{`"spirit blossom" OR volcanic`, []int{62001, 103027}},
+		{`(champion:ahri OR champion:wukong) legacy:true`, []int{62001, 103001}},
+		{`-(rarity:standard OR legacy:true)`, []int{103015, 103027, 145014}},
+		{`id:<103010 champion:ahri`, []int{103000, 103001}},
+		{`rarity:mythic`, []int{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := ParseSkinQuery(tc.query)
+			if err != nil {
+				t.Fatalf("ParseSkinQuery: %v", err)
+			}
+			skins, err := QuerySkins(q)
+			if err != nil {
+				t.Fatalf("QuerySkins: %v", err)
+			}
+			got := make([]int, len(skins))
+			for i, s := range skins {
+				got[i] = s.ID
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSkinQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantPos int
+	}{
+		{``, 0},
+		{`   `, 0},
+		{`rarity:shiny`, 0},
+		{`legacy:maybe`, 0},
+		{`chromas:>many`, 0},
+		{`id:abc`, 0},
+		{`champion:ahri color:red`, 14},
+		{`line:"Star Guardian`, 5},
+		{`(rarity:epic`, 0},
+		{`rarity:epic)`, 11},
+		{`rarity:epic OR`, 14},
+		{`legacy:`, 0},
+		{`ahri -`, 5},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseSkinQuery(tc.query)
+			var qe *QueryError
+			if !errors.As(err, &qe) {
+				t.Fatalf("err = %v, want *QueryError", err)
+			}
+			if qe.Pos != tc.wantPos {
+				t.Errorf("Pos = %d, want %d (%v)", qe.Pos, tc.wantPos, err)
+			}
+		})
+	}
+}
+
+func TestQuerySkinsFillsChromaOrigin(t *testing.T) {
+	setTestCatalog(t, nil, []Skin{{ID: 1001, Name: "Goth Annie", Chromas: []Chroma{{ID: 1002}}}}, nil)
+	q, err := ParseSkinQuery("chromas:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	skins, err := QuerySkins(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skins) != 1 || skins[0].Chromas[0].OriginSkinID != 1001 {
+		t.Fatalf("skins = %+v, want chroma with OriginSkinID 1001", skins)
+	}
+}
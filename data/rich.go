@@ -0,0 +1,94 @@
+// skinhunter/data/rich.go
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RichChampionData es el JSON por campeón del bucket de Supabase (api_json/{id}.json).
+// Tiene la misma forma que v1/champions/{id}.json de CommunityDragon pero con los
+// nombres de chromas completos, que skins.json no siempre trae.
+type RichChampionData struct {
+	ID                 int        `json:"id"`
+	Name               string     `json:"name"`
+	Alias              string     `json:"alias"`
+	Title              string     `json:"title"`
+	ShortBio           string     `json:"shortBio"`
+	SquarePortraitPath string     `json:"squarePortraitPath"`
+	Roles              []string   `json:"roles"`
+	Skins              []RichSkin `json:"skins"`
+}
+
+// RichSkin es una skin dentro de RichChampionData.
+type RichSkin struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	IsBase      bool         `json:"isBase"`
+	IsLegacy    bool         `json:"isLegacy"`
+	RarityGem   string       `json:"rarityGemPath"`
+	TilePath    string       `json:"tilePath"`
+	SplashPath  string       `json:"splashPath"`
+	Description string       `json:"description"`
+	Chromas     []RichChroma `json:"chromas"`
+}
+
+// RichChroma es un chroma dentro de RichSkin.
+type RichChroma struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	ChromaPath  string   `json:"chromaPath"`
+	Colors      []string `json:"colors"`
+	Description string   `json:"description"`
+}
+
+// ErrRichDataMismatch se devuelve (envuelto) cuando el JSON de Supabase no tiene la forma esperada.
+// Es un caso de ErrBadPayload.
+var ErrRichDataMismatch = fmt.Errorf("%w: supabase champion JSON does not match expected format", ErrBadPayload)
+
+func parseRichChampionData(champID int, raw []byte) (*RichChampionData, error) {
+	var rd RichChampionData
+	if err := json.Unmarshal(raw, &rd); err != nil {
+		return nil, fmt.Errorf("%w: %d.json: %v", ErrRichDataMismatch, champID, err)
+	}
+	if rd.ID != 0 && rd.ID != champID {
+		return nil, fmt.Errorf("%w: %d.json describes champion %d", ErrRichDataMismatch, champID, rd.ID)
+	}
+	if len(rd.Skins) == 0 {
+		return nil, fmt.Errorf("%w: %d.json has no skins", ErrRichDataMismatch, champID)
+	}
+	for _, s := range rd.Skins {
+		if GetChampionIDFromSkinID(s.ID) != champID {
+			return nil, fmt.Errorf("%w: %d.json contains skin %d of another champion", ErrRichDataMismatch, champID, s.ID)
+		}
+	}
+	rd.ID = champID
+	return &rd, nil
+}
+
+// Skin busca una skin por ID.
+func (rd *RichChampionData) Skin(skinID int) (RichSkin, bool) {
+	for _, s := range rd.Skins {
+		if s.ID == skinID {
+			return s, true
+		}
+	}
+	return RichSkin{}, false
+}
+
+// ChromaNames devuelve chromaID -> nombre para los chromas de skinID.
+func (rd *RichChampionData) ChromaNames(skinID int) map[int]string {
+	names := make(map[int]string)
+	s, ok := rd.Skin(skinID)
+	if !ok {
+		return names
+	}
+	for _, ch := range s.Chromas {
+		if ch.ID != 0 && ch.Name != "" {
+			names[ch.ID] = ch.Name
+		}
+	}
+	return names
+}
+
+// --- End of rich.go ---
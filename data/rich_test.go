@@ -0,0 +1,55 @@
+package data
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRichChampionData(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{"valid", `{"id":1,"name":"Annie","skins":[{"id":1000,"isBase":true},{"id":1001,"chromas":[{"id":1002,"name":"Ruby"}]}]}`, false},
+		{"missing id is accepted", `{"skins":[{"id":1000}]}`, false},
+		{"invalid JSON", `{"id":1,"skins":[`, true},
+		{"wrong champion id", `{"id":2,"skins":[{"id":1000}]}`, true},
+		{"no skins", `{"id":1,"skins":[]}`, true},
+		{"skin of another champion", `{"id":1,"skins":[{"id":1000},{"id":2001}]}`, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rd, err := parseRichChampionData(1, []byte(tc.raw))
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if rd.ID != 1 {
+					t.Errorf("ID = %d, want 1", rd.ID)
+				}
+				return
+			}
+			if !errors.Is(err, ErrRichDataMismatch) {
+				t.Fatalf("err = %v, want ErrRichDataMismatch", err)
+			}
+			if !errors.Is(err, ErrBadPayload) {
+				t.Errorf("err = %v should also be ErrBadPayload", err)
+			}
+		})
+	}
+}
+
+func TestRichChampionDataChromaNames(t *testing.T) {
+	rd, err := parseRichChampionData(1, []byte(`{"id":1,"skins":[{"id":1001,"chromas":[{"id":1002,"name":"Ruby"},{"id":1003,"name":""},{"id":0,"name":"Bad"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := rd.ChromaNames(1001)
+	if len(names) != 1 || names[1002] != "Ruby" {
+		t.Errorf("ChromaNames = %v, want only 1002:Ruby", names)
+	}
+	if got := rd.ChromaNames(1099); len(got) != 0 {
+		t.Errorf("ChromaNames of unknown skin = %v, want empty", got)
+	}
+}
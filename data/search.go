@@ -0,0 +1,331 @@
+// skinhunter/data/search.go
+package data
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Búsqueda global (OmniSearch) sobre campeones, skins, chromas y skin lines.
+// El índice se construye en memoria a partir de las cachés del catálogo la
+// primera vez que se busca y se descarta cuando cambian (InitData, SetDataSource).
+// Las comparaciones ignoran mayúsculas, acentos y puntuación ("kaisa" = "Kai'Sa").
+
+// SearchKind es el tipo de un resultado; el orden de las constantes es el de los grupos en la UI.
+type SearchKind int
+
+const (
+	SearchChampion SearchKind = iota
+	SearchSkinLine
+	SearchSkin
+	SearchChroma
+)
+
+func (k SearchKind) String() string {
+	switch k {
+	case SearchChampion:
+		return "Champions"
+	case SearchSkinLine:
+		return "Skin Lines"
+	case SearchSkin:
+		return "Skins"
+	case SearchChroma:
+		return "Chromas"
+	}
+	return "Unknown"
+}
+
+// SearchResult es una coincidencia. Solo están rellenos los campos de su Kind
+// (Skin también para chromas: es la skin a la que pertenece el chroma).
+type SearchResult struct {
+	Kind     SearchKind
+	Name     string
+	Score    int
+	Champion ChampionSummary
+	Skin     Skin
+	Chroma   Chroma
+	SkinLine SkinLine
+}
+
+// Puntuaciones de coincidencia, de mejor a peor.
+const (
+	scoreExact      = 100
+	scorePrefix     = 80
+	scoreWordPrefix = 60
+	scoreAcronym    = 55
+	scoreSubstring  = 40
+	scoreCompact    = 35
+	scoreFuzzy      = 20
+)
+
+type searchEntry struct {
+	result SearchResult
+	keys   []searchKey // Nombre y, en campeones, el alias
+}
+
+type searchKey struct {
+	norm    string   // "nunu willump"
+	compact string   // "nunuwillump"
+	words   []string // ["nunu", "willump"]
+	acronym string   // "nw"
+}
+
+var (
+	searchMutex sync.Mutex
+	searchIdx   []searchEntry
+)
+
+// invalidateSearchIndex descarta el índice; se reconstruye en la siguiente búsqueda.
+func invalidateSearchIndex() {
+	searchMutex.Lock()
+	searchIdx = nil
+	searchMutex.Unlock()
+}
+
+// NormalizeSearchText pasa s a minúsculas sin acentos y con la puntuación
+// convertida en espacios simples ("Kai'Sa" -> "kaisa", "Nunu & Willump" -> "nunu willump").
+// Los apóstrofes se eliminan sin dejar espacio para que "kaisa" y "kai'sa" coincidan.
+func NormalizeSearchText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == '\'' || r == '’' || r == '.':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+func newSearchKey(s string) searchKey {
+	n := NormalizeSearchText(s)
+	k := searchKey{norm: n, compact: strings.ReplaceAll(n, " ", ""), words: strings.Fields(n)}
+	var acr strings.Builder
+	for _, w := range k.words {
+		acr.WriteRune([]rune(w)[0])
+	}
+	k.acronym = acr.String()
+	return k
+}
+
+// camelAcronym devuelve las iniciales de un identificador CamelCase ("MissFortune" -> "mf").
+func camelAcronym(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i == 0 || unicode.IsUpper(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// buildSearchIndex crea las entradas a partir de las cachés. Se llama con searchMutex tomado.
+func buildSearchIndex() []searchEntry {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	entries := make([]searchEntry, 0, len(championListCache)+len(allSkinsMap)*2+len(skinLinesCache))
+	for _, ch := range championListCache {
+		e := searchEntry{result: SearchResult{Kind: SearchChampion, Name: ch.Name, Champion: ch}, keys: []searchKey{newSearchKey(ch.Name)}}
+		if ch.Alias != "" && !strings.EqualFold(ch.Alias, ch.Name) {
+			alias := newSearchKey(ch.Alias)
+			alias.acronym = camelAcronym(ch.Alias) // "MonkeyKing" -> "mk"
+			e.keys = append(e.keys, alias)
+		}
+		entries = append(entries, e)
+	}
+	for _, sl := range skinLinesCache {
+		entries = append(entries, searchEntry{result: SearchResult{Kind: SearchSkinLine, Name: sl.Name, SkinLine: sl}, keys: []searchKey{newSearchKey(sl.Name)}})
+	}
+	for _, s := range allSkinsMap {
+		if s.IsBase {
+			continue // El nombre de la skin base es el del campeón
+		}
+		entries = append(entries, searchEntry{result: SearchResult{Kind: SearchSkin, Name: s.Name, Skin: s}, keys: []searchKey{newSearchKey(s.Name)}})
+		for _, ch := range s.Chromas {
+			if strings.TrimSpace(ch.Name) == "" {
+				continue
+			}
+			ch.OriginSkinID = s.ID
+			entries = append(entries, searchEntry{result: SearchResult{Kind: SearchChroma, Name: ch.Name, Skin: s, Chroma: ch}, keys: []searchKey{newSearchKey(ch.Name)}})
+		}
+	}
+	return entries
+}
+
+// Search devuelve hasta limit resultados por tipo para query, ordenados por tipo
+// (SearchKind) y, dentro de cada tipo, por relevancia y nombre. limit <= 0 = sin límite.
+// Requiere que InitData haya cargado el catálogo; si no, devuelve nil.
+func Search(query string, limit int) []SearchResult {
+	q := newSearchKey(query)
+	if q.norm == "" {
+		return nil
+	}
+	searchMutex.Lock()
+	if searchIdx == nil {
+		searchIdx = buildSearchIndex()
+	}
+	idx := searchIdx
+	searchMutex.Unlock()
+
+	var results []SearchResult
+	for _, e := range idx {
+		best := 0
+		for _, k := range e.keys {
+			if s := matchScore(q, k); s > best {
+				best = s
+			}
+		}
+		if best > 0 {
+			r := e.result
+			r.Score = best
+			results = append(results, r)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Name) != len(b.Name) {
+			return len(a.Name) < len(b.Name)
+		}
+		return a.Name < b.Name
+	})
+	if limit <= 0 {
+		return results
+	}
+	trimmed := results[:0]
+	perKind := make(map[SearchKind]int)
+	for _, r := range results {
+		if perKind[r.Kind] < limit {
+			perKind[r.Kind]++
+			trimmed = append(trimmed, r)
+		}
+	}
+	return trimmed
+}
+
+// matchScore puntúa la consulta q contra la clave k (0 = no coincide).
+func matchScore(q, k searchKey) int {
+	switch {
+	case k.norm == q.norm || k.compact == q.compact:
+		return scoreExact
+	case strings.HasPrefix(k.norm, q.norm):
+		return scorePrefix
+	case wordsPrefix(q.words, k.words):
+		return scoreWordPrefix
+	case len(q.compact) >= 2 && len(q.words) == 1 && q.compact == k.acronym:
+		return scoreAcronym
+	case strings.Contains(k.norm, q.norm):
+		return scoreSubstring
+	case len(q.compact) >= 3 && strings.Contains(k.compact, q.compact):
+		return scoreCompact
+	case fuzzyWords(q.words, k.words):
+		return scoreFuzzy
+	}
+	return 0
+}
+
+// wordsPrefix indica si cada palabra de la consulta es prefijo de una palabra distinta de la clave.
+func wordsPrefix(q, k []string) bool {
+	if len(q) == 0 || len(q) > len(k) {
+		return false
+	}
+	used := make([]bool, len(k))
+	for _, qw := range q {
+		found := false
+		for i, kw := range k {
+			if !used[i] && strings.HasPrefix(kw, qw) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// fuzzyWords tolera erratas: cada palabra de la consulta de 4+ letras debe estar a
+// distancia de edición <= 1 (<= 2 desde 7 letras) de alguna palabra de la clave;
+// las palabras más cortas tienen que ser prefijo exacto.
+func fuzzyWords(q, k []string) bool {
+	if len(q) == 0 {
+		return false
+	}
+	for _, qw := range q {
+		maxDist := 0
+		switch n := len([]rune(qw)); {
+		case n >= 7:
+			maxDist = 2
+		case n >= 4:
+			maxDist = 1
+		}
+		found := false
+		for _, kw := range k {
+			if strings.HasPrefix(kw, qw) || (maxDist > 0 && editDistanceWithin(qw, kw, maxDist)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// editDistanceWithin indica si la distancia de edición entre a y b es <= max.
+// Cuenta una transposición de letras vecinas ("ahir" -> "ahri") como un solo cambio.
+func editDistanceWithin(a, b string, max int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return false
+	}
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)] <= max
+}
+
+// --- End of search.go ---
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"strconv"
+	"testing"
+)
+
+// setTestCatalog carga un catálogo mínimo en las cachés y lo restaura al terminar.
+func setTestCatalog(t *testing.T, champs []ChampionSummary, skins []Skin, lines []SkinLine) {
+	t.Helper()
+	cacheMutex.Lock()
+	prevChamps, prevSkins, prevLines := championListCache, allSkinsMap, skinLinesCache
+	championListCache = champs
+	allSkinsMap = make(map[string]Skin, len(skins))
+	for _, s := range skins {
+		s.IsBase = s.ID%1000 == 0
+		allSkinsMap[strconv.Itoa(s.ID)] = s
+	}
+	skinLinesCache = lines
+	cacheMutex.Unlock()
+	invalidateSearchIndex()
+	t.Cleanup(func() {
+		cacheMutex.Lock()
+		championListCache, allSkinsMap, skinLinesCache = prevChamps, prevSkins, prevLines
+		cacheMutex.Unlock()
+		invalidateSearchIndex()
+	})
+}
+
+func searchTestCatalog(t *testing.T) {
+	setTestCatalog(t,
+		[]ChampionSummary{
+			{ID: 145, Name: "Kai'Sa", Alias: "Kaisa"},
+			{ID: 20, Name: "Nunu & Willump", Alias: "Nunu"},
+			{ID: 21, Name: "Miss Fortune", Alias: "MissFortune"},
+			{ID: 62, Name: "Wukong", Alias: "MonkeyKing"},
+			{ID: 103, Name: "Ahri", Alias: "Ahri"},
+		},
+		[]Skin{
+			{ID: 103000, Name: "Ahri"},
+			{ID: 103001, Name: "Dynasty Ahri", Chromas: []Chroma{{ID: 103002, Name: "Dynasty Ahri (Ruby)"}}},
+			{ID: 103015, Name: "Star Guardian Ahri", SkinLines: []struct{ ID int }{{ID: 9}}},
+			{ID: 21001, Name: "Cowgirl Miss Fortune"},
+			{ID: 145001, Name: "Bullet Angel Kai'Sa"},
+		},
+		[]SkinLine{{ID: 9, Name: "Star Guardian"}, {ID: 10, Name: "Pokémon Fans"}},
+	)
+}
+
+func TestNormalizeSearchText(t *testing.T) {
+	tests := map[string]string{
+		"Kai'Sa":          "kaisa",
+		"Nunu & Willump":  "nunu willump",
+		"  Pokémon  Fans": "pokemon fans",
+		"Dr. Mundo":       "dr mundo",
+		"K/DA ALL OUT":    "k da all out",
+	}
+	for in, want := range tests {
+		if got := NormalizeSearchText(in); got != want {
+			t.Errorf("NormalizeSearchText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	searchTestCatalog(t)
+	tests := []struct {
+		query    string
+		wantKind SearchKind
+		wantName string
+	}{
+		{"kaisa", SearchChampion, "Kai'Sa"},
+		{"nunu willump", SearchChampion, "Nunu & Willump"},
+		{"mf", SearchChampion, "Miss Fortune"},
+		{"monkey king", SearchChampion, "Wukong"},
+		{"pokemon", SearchSkinLine, "Pokémon Fans"},
+		{"star guard", SearchSkinLine, "Star Guardian"},
+		{"dynasty", SearchSkin, "Dynasty Ahri"},
+		{"ruby", SearchChroma, "Dynasty Ahri (Ruby)"},
+		{"ahir", SearchChampion, "Ahri"}, // Errata
+	}
+	for _, tc := range tests {
+		results := Search(tc.query, 5)
+		found := false
+		for _, r := range results {
+			if r.Kind == tc.wantKind {
+				found = r.Name == tc.wantName
+				break // Solo cuenta el primero de su grupo
+			}
+		}
+		if !found {
+			t.Errorf("Search(%q): first %v result is not %q; got %+v", tc.query, tc.wantKind, tc.wantName, names(results))
+		}
+	}
+}
+
+func names(rs []SearchResult) []string {
+	out := make([]string, len(rs))
+	for i, r := range rs {
+		out[i] = r.Kind.String() + ":" + r.Name
+	}
+	return out
+}
+
+func TestSearchGroupsAndLimits(t *testing.T) {
+	searchTestCatalog(t)
+	results := Search("ahri", 1)
+	seen := make(map[SearchKind]int)
+	last := SearchKind(-1)
+	for _, r := range results {
+		if r.Kind < last {
+			t.Fatalf("results not grouped by kind: %v", names(results))
+		}
+		last = r.Kind
+		seen[r.Kind]++
+	}
+	for k, n := range seen {
+		if n > 1 {
+			t.Errorf("%v has %d results, limit is 1", k, n)
+		}
+	}
+	for _, r := range Search("ahri", 0) {
+		if r.Kind == SearchSkin && r.Skin.IsBase {
+			t.Errorf("base skin %q should not be a skin result", r.Name)
+		}
+	}
+	if Search("  ", 5) != nil {
+		t.Error("blank query should return nil")
+	}
+}
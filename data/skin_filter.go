@@ -0,0 +1,99 @@
+// skinhunter/data/skin_filter.go
+package data
+
+import (
+	"sort"
+	"strings"
+)
+
+// SkinSort es el criterio de orden de FilterSkins.
+type SkinSort int
+
+const (
+	SortSkinsByID     SkinSort = iota // Orden de publicación (el del juego)
+	SortSkinsByName                   // Alfabético
+	SortSkinsByRarity                 // De mayor a menor rareza; empates por ID
+)
+
+func (s SkinSort) String() string {
+	switch s {
+	case SortSkinsByName:
+		return "Name"
+	case SortSkinsByRarity:
+		return "Rarity"
+	}
+	return "Release"
+}
+
+// SkinRarities son los nombres de rareza de Rarity, de menor a mayor.
+var SkinRarities = []string{"Standard", "Epic", "Legendary", "Mythic", "Ultimate"}
+
+// SkinFilter son los filtros de la barra del grid de skins. El valor cero no filtra nada.
+type SkinFilter struct {
+	Rarities   []string // Nombres de SkinRarities; vacío = cualquiera
+	LegacyOnly bool
+	HasChromas bool
+}
+
+// IsZero indica si el filtro deja pasar todas las skins.
+func (f SkinFilter) IsZero() bool {
+	return len(f.Rarities) == 0 && !f.LegacyOnly && !f.HasChromas
+}
+
+// Match indica si skin pasa el filtro.
+func (f SkinFilter) Match(skin Skin) bool {
+	if f.LegacyOnly && !skin.IsLegacy {
+		return false
+	}
+	if f.HasChromas && len(skin.Chromas) == 0 {
+		return false
+	}
+	if len(f.Rarities) == 0 {
+		return true
+	}
+	name, _ := rarityInfo(skin)
+	for _, r := range f.Rarities {
+		if strings.EqualFold(r, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RarityTier devuelve la posición de la rareza de skin en SkinRarities, o -1 si es desconocida.
+func RarityTier(skin Skin) int {
+	name, _ := rarityInfo(skin)
+	if rank, ok := rarityRanks[strings.ToLower(name)]; ok {
+		return rank
+	}
+	return -1
+}
+
+// FilterSkins devuelve una copia de skins con las que pasan f, ordenadas por by.
+// No modifica el slice original.
+func FilterSkins(skins []Skin, f SkinFilter, by SkinSort) []Skin {
+	out := make([]Skin, 0, len(skins))
+	for _, s := range skins {
+		if f.Match(s) {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch by {
+		case SortSkinsByName:
+			na, nb := NormalizeSearchText(a.Name), NormalizeSearchText(b.Name)
+			if na != nb {
+				return na < nb
+			}
+		case SortSkinsByRarity:
+			if ta, tb := RarityTier(a), RarityTier(b); ta != tb {
+				return ta > tb
+			}
+		}
+		return a.ID < b.ID
+	})
+	return out
+}
+
+// --- End of skin_filter.go ---
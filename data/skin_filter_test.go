@@ -0,0 +1,50 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterSkins(t *testing.T) {
+	skins := []Skin{
+		{ID: 99027, Name: "Spirit Blossom Lux", Rarity: "/x/raritygem_legendary.png"},
+		{ID: 99000, Name: "Lux"},
+		{ID: 99007, Name: "Elementalist Lux", Rarity: "/x/raritygem_ultimate.png", Chromas: make([]Chroma, 9)},
+		{ID: 99001, Name: "Sorceress Lux", IsLegacy: true},
+		{ID: 99014, Name: "Battle Academia Lux", Rarity: "/x/raritygem_epic.png", Chromas: make([]Chroma, 3)},
+		{ID: 99002, Name: "Spellthief Lux", IsLegacy: true, Rarity: "/x/raritygem_epic.png"},
+	}
+	ids := func(skins []Skin) []int {
+		out := make([]int, len(skins))
+		for i, s := range skins {
+			out[i] = s.ID
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		filter SkinFilter
+		by     SkinSort
+		want   []int
+	}{
+		{"no filter by ID", SkinFilter{}, SortSkinsByID, []int{99000, 99001, 99002, 99007, 99014, 99027}},
+		{"by name", SkinFilter{}, SortSkinsByName, []int{99014, 99007, 99000, 99001, 99002, 99027}},
+		{"by rarity", SkinFilter{}, SortSkinsByRarity, []int{99007, 99027, 99002, 99014, 99000, 99001}},
+		{"legendaries", SkinFilter{Rarities: []string{"Legendary"}}, SortSkinsByID, []int{99027}},
+		{"epic or ultimate", SkinFilter{Rarities: []string{"epic", "Ultimate"}}, SortSkinsByID, []int{99002, 99007, 99014}},
+		{"legacy", SkinFilter{LegacyOnly: true}, SortSkinsByID, []int{99001, 99002}},
+		{"with chromas", SkinFilter{HasChromas: true}, SortSkinsByRarity, []int{99007, 99014}},
+		{"legacy epic", SkinFilter{LegacyOnly: true, Rarities: []string{"Epic"}}, SortSkinsByID, []int{99002}},
+		{"nothing", SkinFilter{LegacyOnly: true, HasChromas: true}, SortSkinsByID, []int{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ids(FilterSkins(skins, tc.filter, tc.by)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+	if skins[0].ID != 99027 {
+		t.Errorf("FilterSkins reordered its input")
+	}
+}
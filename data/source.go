@@ -0,0 +1,255 @@
+// skinhunter/data/source.go
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DataSource abstrae de dónde salen los JSON del catálogo y las URLs de los assets.
+// Las implementaciones devuelven el JSON crudo; el parseo y la normalización
+// (ensureLeadingSlash, IsBase, OriginSkinID...) se hacen en data.go.
+// Los métodos que leen JSON respetan la cancelación y el deadline de ctx.
+type DataSource interface {
+	// Name identifica la fuente en logs y en la UI.
+	Name() string
+	// Locale es el idioma de los JSON ("default" = en_us, "es_mx", "ko_kr"...).
+	Locale() string
+	ChampionSummary(ctx context.Context) ([]byte, error)
+	Skins(ctx context.Context) ([]byte, error)
+	ChampionDetail(ctx context.Context, championID int) ([]byte, error)
+	SkinLines(ctx context.Context) ([]byte, error)
+	// ContentMetadata devuelve content-metadata.json (versión del juego de los datos).
+	ContentMetadata(ctx context.Context) ([]byte, error)
+	// Asset resuelve una ruta de game-data ("/lol-game-data/assets/...") a una URL/URI cargable.
+	Asset(path string) string
+	// StaticAsset resuelve una ruta del plugin de assets estáticos (iconos de legacy, chroma...).
+	StaticAsset(path string) string
+}
+
+const (
+	cDragonRawRoot         = "https://raw.communitydragon.org"
+	gameDataPluginRoot     = "/plugins/rcp-be-lol-game-data/global/"
+	gameDataPluginPath     = gameDataPluginRoot + DefaultLocale // Los assets (imágenes) siempre salen de default
+	staticAssetsPluginPath = "/plugins/rcp-fe-lol-static-assets/global/default"
+	versionPlaceholder     = "{version}"
+	DefaultLocale          = "default"
+)
+
+// patchDirRe acepta los directorios de versión de CommunityDragon: "latest", "pbe" o "14.9".
+var patchDirRe = regexp.MustCompile(`^(latest|pbe|\d+\.\d+)$`)
+
+// Locales son los directorios de idioma que publica CommunityDragon para rcp-be-lol-game-data.
+var Locales = []string{
+	DefaultLocale, "ar_ae", "cs_cz", "de_de", "el_gr", "en_au", "en_gb", "en_ph", "en_sg",
+	"es_ar", "es_es", "es_mx", "fr_fr", "hu_hu", "id_id", "it_it", "ja_jp", "ko_kr", "pl_pl",
+	"pt_br", "ro_ro", "ru_ru", "th_th", "tr_tr", "vi_vn", "zh_cn", "zh_my", "zh_tw",
+}
+
+// NormalizeLocale pasa "es_MX"/"es-mx"/"en_us"/"" a la forma de CommunityDragon ("es_mx", "default").
+func NormalizeLocale(locale string) (string, error) {
+	l := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "-", "_")
+	if l == "" || l == "en_us" {
+		return DefaultLocale, nil
+	}
+	for _, known := range Locales {
+		if l == known {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown locale %q", locale)
+}
+
+// --- Fuente HTTP (CommunityDragon o cualquier mirror con el mismo layout) ---
+
+// httpSource lee de una raíz con el layout de raw.communitydragon.org/<version>.
+// Las respuestas JSON pasan por la caché en disco (cache.go).
+type httpSource struct {
+	name   string
+	root   string
+	locale string
+}
+
+// NewCommunityDragonSource devuelve la fuente por defecto (raw.communitydragon.org/latest, en inglés).
+func NewCommunityDragonSource() DataSource {
+	return &httpSource{name: "CommunityDragon", root: cDragonBase, locale: DefaultLocale}
+}
+
+// NewCommunityDragonSourceFor fija la fuente a un directorio de parche (p.ej. "14.9") y un idioma.
+func NewCommunityDragonSourceFor(version, locale string) (DataSource, error) {
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" || version == "latest" {
+		return &httpSource{name: "CommunityDragon", root: cDragonBase, locale: locale}, nil
+	}
+	if !ValidPatchDir(version) {
+		return nil, fmt.Errorf("invalid patch %q (expected e.g. 14.9, latest or pbe)", version)
+	}
+	return &httpSource{name: "CommunityDragon " + version, root: cDragonRawRoot + "/" + version, locale: locale}, nil
+}
+
+// ValidPatchDir indica si v es un directorio de versión válido de CommunityDragon.
+func ValidPatchDir(v string) bool { return patchDirRe.MatchString(v) }
+
+func (s *httpSource) Name() string   { return s.name }
+func (s *httpSource) Locale() string { return s.locale }
+func (s *httpSource) gameDataURL() string {
+	return strings.TrimSuffix(s.root, "/") + gameDataPluginPath
+}
+func (s *httpSource) localeDataURL() string {
+	return strings.TrimSuffix(s.root, "/") + gameDataPluginRoot + s.locale
+}
+func (s *httpSource) ChampionSummary(ctx context.Context) ([]byte, error) {
+	return cachedGet(ctx, s.localeDataURL()+"/v1/champion-summary.json")
+}
+func (s *httpSource) Skins(ctx context.Context) ([]byte, error) {
+	return cachedGet(ctx, s.localeDataURL()+"/v1/skins.json")
+}
+func (s *httpSource) ChampionDetail(ctx context.Context, championID int) ([]byte, error) {
+	return cachedGet(ctx, fmt.Sprintf("%s/v1/champions/%d.json", s.localeDataURL(), championID))
+}
+func (s *httpSource) SkinLines(ctx context.Context) ([]byte, error) {
+	return cachedGet(ctx, s.localeDataURL()+"/v1/skinlines.json")
+}
+func (s *httpSource) ContentMetadata(ctx context.Context) ([]byte, error) {
+	return cachedGet(ctx, strings.TrimSuffix(s.root, "/")+"/content-metadata.json")
+}
+func (s *httpSource) Asset(path string) string {
+	return s.gameDataURL() + strings.ToLower(assetRelativePath(path))
+}
+func (s *httpSource) StaticAsset(path string) string {
+	return strings.TrimSuffix(s.root, "/") + staticAssetsPluginPath + ensureLeadingSlash(path)
+}
+
+// --- Fuente en disco (mirror local o fixtures) ---
+
+// dirSource lee de un directorio con el mismo layout que la raíz de CommunityDragon,
+// es decir <dir>/plugins/rcp-be-lol-game-data/global/<locale>/v1/skins.json, etc.
+// Si el mirror no tiene el idioma pedido se usa global/default.
+type dirSource struct {
+	root   string
+	locale string
+}
+
+func (s *dirSource) Name() string   { return "Local: " + s.root }
+func (s *dirSource) Locale() string { return s.locale }
+func (s *dirSource) file(rel string) string {
+	return filepath.Join(s.root, filepath.FromSlash(gameDataPluginPath+rel))
+}
+
+// readLocalized lee rel del directorio del idioma. Leer de disco no se puede
+// interrumpir, así que ctx solo se comprueba antes de empezar.
+func (s *dirSource) readLocalized(ctx context.Context, rel string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.locale != DefaultLocale {
+		b, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(gameDataPluginRoot+s.locale+rel)))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return b, err
+		}
+	}
+	return readLocalFile(s.file(rel))
+}
+
+// readLocalFile lee path traduciendo "no existe" a ErrNotFound.
+func readLocalFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return b, err
+}
+func (s *dirSource) ChampionSummary(ctx context.Context) ([]byte, error) {
+	return s.readLocalized(ctx, "/v1/champion-summary.json")
+}
+func (s *dirSource) Skins(ctx context.Context) ([]byte, error) {
+	return s.readLocalized(ctx, "/v1/skins.json")
+}
+func (s *dirSource) ChampionDetail(ctx context.Context, championID int) ([]byte, error) {
+	return s.readLocalized(ctx, fmt.Sprintf("/v1/champions/%d.json", championID))
+}
+func (s *dirSource) SkinLines(ctx context.Context) ([]byte, error) {
+	return s.readLocalized(ctx, "/v1/skinlines.json")
+}
+func (s *dirSource) ContentMetadata(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return readLocalFile(filepath.Join(s.root, "content-metadata.json"))
+}
+func (s *dirSource) Asset(path string) string {
+	return fileURI(s.file(strings.ToLower(assetRelativePath(path))))
+}
+func (s *dirSource) StaticAsset(path string) string {
+	return fileURI(filepath.Join(s.root, filepath.FromSlash(staticAssetsPluginPath+ensureLeadingSlash(path))))
+}
+
+func fileURI(p string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(p)}
+	if !strings.HasPrefix(u.Path, "/") { // Rutas de Windows (C:/...)
+		u.Path = "/" + u.Path
+	}
+	return u.String()
+}
+
+// NewSourceFromLocation crea la fuente configurada en settings: vacío usa
+// CommunityDragon, una URL http(s) usa un mirror remoto y cualquier otra cosa
+// se trata como un directorio local. version fija el parche ("" = latest); en
+// mirrors y directorios se sustituye en el marcador {version} si lo hay.
+// locale elige el idioma de los JSON ("" = default/en_us).
+func NewSourceFromLocation(location, version, locale string) (DataSource, error) {
+	location = strings.TrimSpace(location)
+	version = strings.TrimSpace(version)
+	if location == "" {
+		return NewCommunityDragonSourceFor(version, locale)
+	}
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(location, versionPlaceholder) {
+		if version == "" {
+			version = "latest"
+		}
+		if !ValidPatchDir(version) {
+			return nil, fmt.Errorf("invalid patch %q (expected e.g. 14.9, latest or pbe)", version)
+		}
+		location = strings.ReplaceAll(location, versionPlaceholder, version)
+	}
+	ll := strings.ToLower(location)
+	if strings.HasPrefix(ll, "http://") || strings.HasPrefix(ll, "https://") {
+		if _, err := url.Parse(location); err != nil {
+			return nil, fmt.Errorf("invalid data source URL %q: %w", location, err)
+		}
+		return &httpSource{name: "Mirror: " + location, root: location, locale: locale}, nil
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("data source directory %q: %w", location, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("data source %q is not a directory", location)
+	}
+	return &dirSource{root: location, locale: locale}, nil
+}
+
+// assetRelativePath convierte una ruta del JSON en una ruta relativa a la raíz de game-data.
+func assetRelativePath(path string) string {
+	path = ensureLeadingSlash(path)
+	if strings.HasPrefix(strings.ToLower(path), jsonAssetPathPrefix) {
+		path = ensureLeadingSlash(path[len(jsonAssetPathPrefix):])
+	}
+	return path
+}
+
+// --- End of source.go ---
@@ -0,0 +1,248 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", DefaultLocale, false},
+		{"  ", DefaultLocale, false},
+		{"en_us", DefaultLocale, false},
+		{"en-US", DefaultLocale, false},
+		{"default", DefaultLocale, false},
+		{"es-MX", "es_mx", false},
+		{"es_MX", "es_mx", false},
+		{" ko_kr ", "ko_kr", false},
+		{"xx_yy", "", true},
+		{"es", "", true},
+	}
+	for _, tc := range tests {
+		got, err := NormalizeLocale(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("NormalizeLocale(%q) = %q, %v; want %q (error %v)", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// writeGameData crea <root>/plugins/rcp-be-lol-game-data/global/<locale>/v1/<name>.
+func writeGameData(t *testing.T, root, locale, name, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(gameDataPluginRoot+locale+"/v1/"+name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSourceFromLocation(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.json")
+	if err := os.WriteFile(file, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		location string
+		wantName string
+		wantErr  bool
+	}{
+		{"", "CommunityDragon", false},
+		{"  ", "CommunityDragon", false},
+		{"https://mirror.example/cdragon", "Mirror: https://mirror.example/cdragon", false},
+		{"HTTP://mirror.example", "Mirror: HTTP://mirror.example", false},
+		{dir, "Local: " + dir, false},
+		{file, "", true},
+		{filepath.Join(dir, "missing"), "", true},
+	}
+	for _, tc := range tests {
+		src, err := NewSourceFromLocation(tc.location, "", "")
+		if (err != nil) != tc.wantErr {
+			t.Errorf("NewSourceFromLocation(%q) error = %v, want error %v", tc.location, err, tc.wantErr)
+			continue
+		}
+		if err == nil && src.Name() != tc.wantName {
+			t.Errorf("NewSourceFromLocation(%q).Name() = %q, want %q", tc.location, src.Name(), tc.wantName)
+		}
+	}
+}
+
+func TestSourceAssetURLs(t *testing.T) {
+	mirror := &httpSource{name: "Mirror", root: "https://mirror.example/"}
+	if got, want := mirror.Asset("/lol-game-data/assets/ASSETS/Characters/Ahri/Tile.png"),
+		"https://mirror.example"+gameDataPluginPath+"/assets/characters/ahri/tile.png"; got != want {
+		t.Errorf("httpSource.Asset = %q, want %q", got, want)
+	}
+	if got, want := mirror.StaticAsset("images/icon.png"),
+		"https://mirror.example"+staticAssetsPluginPath+"/images/icon.png"; got != want {
+		t.Errorf("httpSource.StaticAsset = %q, want %q", got, want)
+	}
+
+	dir := &dirSource{root: "/srv/cdragon"}
+	if got := dir.Asset("/lol-game-data/assets/ASSETS/Tile.png"); !strings.HasPrefix(got, "file:///") ||
+		!strings.HasSuffix(got, gameDataPluginPath+"/assets/tile.png") {
+		t.Errorf("dirSource.Asset = %q", got)
+	}
+}
+
+func TestInitDataFromDirSource(t *testing.T) {
+	root := t.TempDir()
+	writeGameData(t, root, DefaultLocale, "champion-summary.json",
+		`[{"id":-1,"name":"None","alias":"None"},{"id":103,"name":"Ahri","alias":"Ahri","squarePortraitPath":"x/103.png"}]`)
+	writeGameData(t, root, DefaultLocale, "skins.json",
+		`{"103000":{"name":"Ahri"},"103001":{"name":"Dynasty Ahri","chromas":[{"id":103002,"chromaPath":"c.png"},{"id":0}]},"bad":{"name":"x"}}`)
+
+	src, err := NewSourceFromLocation(root, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDataSource(src)
+	t.Cleanup(func() { SetDataSource(nil) })
+
+	if err := InitData(context.Background()); err != nil {
+		t.Fatalf("InitData: %v", err)
+	}
+	champs, err := FetchAllChampions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(champs) != 1 || champs[0].Key != "ahri" || champs[0].SquarePortraitPath != "/x/103.png" {
+		t.Fatalf("champions = %+v", champs)
+	}
+	skins, err := GetAllSkinsMap(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skins) != 2 || !skins["103000"].IsBase || skins["103001"].IsBase {
+		t.Fatalf("skins = %+v", skins)
+	}
+	if chromas := skins["103001"].Chromas; len(chromas) != 1 || chromas[0].OriginSkinID != 103001 || chromas[0].ChromaPath != "/c.png" {
+		t.Fatalf("chromas = %+v", chromas)
+	}
+	if CurrentDataSource() != src {
+		t.Fatal("CurrentDataSource did not return the configured source")
+	}
+}
+
+func TestDirSourceLocaleFallback(t *testing.T) {
+	root := t.TempDir()
+	writeGameData(t, root, DefaultLocale, "skins.json", "default-skins")
+	writeGameData(t, root, DefaultLocale, "skinlines.json", "default-lines")
+	writeGameData(t, root, "es_mx", "skins.json", "es-skins")
+
+	tests := []struct {
+		name    string
+		locale  string
+		read    func(DataSource) ([]byte, error)
+		want    string
+		wantErr error
+	}{
+		{"localized file", "es-MX", func(s DataSource) ([]byte, error) { return s.Skins(context.Background()) }, "es-skins", nil},
+		{"missing in locale falls back to default", "es_mx", func(s DataSource) ([]byte, error) { return s.SkinLines(context.Background()) }, "default-lines", nil},
+		{"locale dir missing falls back to default", "ko_kr", func(s DataSource) ([]byte, error) { return s.Skins(context.Background()) }, "default-skins", nil},
+		{"default locale", "", func(s DataSource) ([]byte, error) { return s.Skins(context.Background()) }, "default-skins", nil},
+		{"missing everywhere", "es_mx", func(s DataSource) ([]byte, error) { return s.ChampionSummary(context.Background()) }, "", ErrNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := NewSourceFromLocation(root, "", tc.locale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tc.read(src)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tc.want {
+				t.Errorf("got %q, %v; want %q", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestDirSourceHonoursCancelledContext(t *testing.T) {
+	root := t.TempDir()
+	writeGameData(t, root, DefaultLocale, "skins.json", "x")
+	src, err := NewSourceFromLocation(root, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := src.Skins(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestValidPatchDir(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"latest", true},
+		{"pbe", true},
+		{"14.9", true},
+		{"14.10", true},
+		{"14.9.584.1234", false},
+		{"14", false},
+		{"../14.9", false},
+		{"14.9/", false},
+		{"LATEST", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if got := ValidPatchDir(tc.version); got != tc.want {
+			t.Errorf("ValidPatchDir(%q) = %v, want %v", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestPinnedSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		version  string
+		wantRoot string // Prefijo esperado de Asset; "" si se espera error
+	}{
+		{"latest", "", "latest", cDragonBase},
+		{"unpinned", "", "", cDragonBase},
+		{"pbe", "", "pbe", cDragonRawRoot + "/pbe"},
+		{"patch", "", "14.9", cDragonRawRoot + "/14.9"},
+		{"invalid patch", "", "14.9.584", ""},
+		{"path traversal", "", "../x", ""},
+		{"mirror placeholder", "https://mirror.example/" + versionPlaceholder, "14.9", "https://mirror.example/14.9"},
+		{"mirror placeholder latest", "https://mirror.example/" + versionPlaceholder, "", "https://mirror.example/latest"},
+		{"mirror invalid patch", "https://mirror.example/" + versionPlaceholder, "v14", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := NewSourceFromLocation(tc.location, tc.version, "")
+			if tc.wantRoot == "" {
+				if err == nil {
+					t.Fatalf("expected error for version %q, got source %s", tc.version, src.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := src.Asset("/lol-game-data/assets/ASSETS/X.png"), tc.wantRoot+gameDataPluginPath+"/assets/x.png"; got != want {
+				t.Errorf("Asset = %q, want %q", got, want)
+			}
+		})
+	}
+}
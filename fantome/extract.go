@@ -0,0 +1,283 @@
+// skinhunter/fantome/extract.go
+package fantome
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skinhunter/installs"
+	"skinhunter/wad"
+)
+
+// ReadFile devuelve el fichero hash (wad.HashPath de su ruta) del paquete path: de un
+// .fantome (WAD empaquetados, carpetas de WAD o RAW/) o de un WAD suelto. Si no está
+// devuelve wad.ErrNotFound.
+func ReadFile(path string, hash uint64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", installs.ErrPackageMissing, path)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, st.Size())
+	if err != nil {
+		r, wadErr := wad.NewReader(f, st.Size())
+		if wadErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotExportable, wadErr)
+		}
+		return r.Extract(hash)
+	}
+	for _, zf := range zr.File {
+		name := strings.ReplaceAll(zf.Name, "\\", "/")
+		dir, rest, _ := strings.Cut(name, "/")
+		if zf.FileInfo().IsDir() || rest == "" || !safePath(name) {
+			continue
+		}
+		switch strings.ToUpper(dir) {
+		case "WAD":
+			wadName, inner, _ := strings.Cut(rest, "/")
+			if !strings.HasSuffix(strings.ToLower(wadName), wadSuffix) {
+				continue
+			}
+			if inner != "" {
+				if wad.HashPath(inner) == hash {
+					return readEntry(zf)
+				}
+				continue
+			}
+			data, err := extractPacked(f, zf, hash)
+			if errors.Is(err, wad.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", zf.Name, err)
+			}
+			return data, nil
+		case "RAW":
+			if wad.HashPath(rest) == hash {
+				return readEntry(zf)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %016x", wad.ErrNotFound, hash)
+}
+
+// extractPacked busca hash en el WAD empaquetado zf; solo lo abre (ver openPacked) si
+// la tabla lo tiene.
+func extractPacked(f *os.File, zf *zip.File, hash uint64) ([]byte, error) {
+	entries, err := wadEntries(zf)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, e := range entries {
+		if e.PathHash == hash {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %016x", wad.ErrNotFound, hash)
+	}
+	r, err := openPacked(f, zf)
+	if err != nil {
+		return nil, err
+	}
+	return r.Extract(hash)
+}
+
+// openPacked abre el WAD empaquetado zf del zip f. Si está guardado sin comprimir se
+// lee directamente del fichero; si no, hay que descomprimirlo entero en memoria.
+func openPacked(f *os.File, zf *zip.File) (*wad.Reader, error) {
+	var ra io.ReaderAt
+	if off, err := zf.DataOffset(); err == nil && zf.Method == zip.Store {
+		ra = io.NewSectionReader(f, off, int64(zf.UncompressedSize64))
+	} else {
+		data, err := readEntry(zf)
+		if err != nil {
+			return nil, err
+		}
+		ra = bytes.NewReader(data)
+	}
+	return wad.NewReader(ra, int64(zf.UncompressedSize64))
+}
+
+// File es un fichero de un paquete tal como va a un WAD. Walk no lee sus datos: se leen
+// después, de uno en uno, con Source.Read.
+type File struct {
+	Wad   string    // WAD al que va ("Ahri.wad.client"), "RAW" o el nombre de un WAD suelto
+	Entry wad.Entry // PathHash, Size y Compression de lo que devuelve Source.Read
+
+	zip   int       // Índice en el zip; -1 si el paquete es un WAD suelto
+	src   wad.Entry // Entrada en el WAD de origen; vacía en los ficheros sueltos
+	loose bool      // Fichero suelto de RAW/ o de una carpeta de WAD
+}
+
+// Walk llama a fn con cada fichero del paquete path (un .fantome o un WAD suelto), en
+// el orden del paquete; si fn devuelve un error se para y lo devuelve. De los WAD
+// empaquetados solo se lee la tabla. Las entradas de WAD con subchunks se anuncian sin
+// comprimir: sus subchunks solo valen en su WAD y Source.Read las descomprime.
+func Walk(path string, fn func(File) error) error {
+	s, err := OpenSource(path)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if s.zr == nil {
+		return walkWad(filepath.Base(path), -1, s.wads[-1].Entries, fn)
+	}
+	for i, zf := range s.zr.File {
+		name := strings.ReplaceAll(zf.Name, "\\", "/")
+		dir, rest, _ := strings.Cut(name, "/")
+		if zf.FileInfo().IsDir() || rest == "" || !safePath(name) {
+			continue
+		}
+		wadName, inner := "RAW", rest
+		switch strings.ToUpper(dir) {
+		case "WAD":
+			wadName, inner, _ = strings.Cut(rest, "/")
+			if !strings.HasSuffix(strings.ToLower(wadName), wadSuffix) {
+				continue
+			}
+			if inner == "" {
+				entries, err := wadEntries(zf)
+				if err != nil {
+					return fmt.Errorf("%s: %w", zf.Name, err)
+				}
+				if err := walkWad(wadName, i, entries, fn); err != nil {
+					return err
+				}
+				continue
+			}
+		case "RAW":
+			// Fichero suelto, como los de las carpetas de WAD
+		default:
+			continue
+		}
+		if zf.UncompressedSize64 > wad.MaxEntrySize {
+			return fmt.Errorf("%s too large", zf.Name)
+		}
+		e := wad.Entry{PathHash: wad.HashPath(inner), Size: uint32(zf.UncompressedSize64), Compression: wad.CompressionNone}
+		if err := fn(File{Wad: wadName, Entry: e, zip: i, loose: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkWad(name string, zipIndex int, entries []wad.Entry, fn func(File) error) error {
+	for _, e := range entries {
+		f := File{Wad: name, Entry: e, zip: zipIndex, src: e}
+		if subchunked(e) {
+			f.Entry = wad.Entry{PathHash: e.PathHash, Size: e.Size, Compression: wad.CompressionNone}
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func subchunked(e wad.Entry) bool {
+	return e.Compression == wad.CompressionZstdMulti || e.SubchunkCount > 0
+}
+
+// Source lee los datos de los File de un paquete. No es seguro para uso concurrente.
+type Source struct {
+	f    *os.File
+	zr   *zip.Reader         // nil si el paquete es un WAD suelto
+	wads map[int]*wad.Reader // WAD abiertos por índice en el zip; -1 es el WAD suelto
+}
+
+// OpenSource abre el paquete path para leer los ficheros que da Walk. Hay que llamar a
+// Close al terminar: los WAD empaquetados comprimidos se quedan en memoria hasta entonces.
+func OpenSource(path string) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", installs.ErrPackageMissing, path)
+		}
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s := &Source{f: f, wads: make(map[int]*wad.Reader)}
+	if s.zr, err = zip.NewReader(f, st.Size()); err != nil {
+		r, wadErr := wad.NewReader(f, st.Size())
+		if wadErr != nil {
+			f.Close()
+			return nil, fmt.Errorf("%w: %v", ErrNotExportable, wadErr)
+		}
+		s.zr, s.wads[-1] = nil, r
+	}
+	return s, nil
+}
+
+// Read devuelve los datos de f tal como los describe f.Entry.
+func (s *Source) Read(f File) ([]byte, error) {
+	if f.loose {
+		zf := s.zr.File[f.zip]
+		data, err := readEntry(zf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", zf.Name, err)
+		}
+		if len(data) != int(f.Entry.Size) {
+			return nil, fmt.Errorf("%w: %s is %d bytes, want %d", wad.ErrCorrupt, zf.Name, len(data), f.Entry.Size)
+		}
+		return data, nil
+	}
+	r := s.wads[f.zip]
+	if r == nil {
+		var err error
+		if r, err = openPacked(s.f, s.zr.File[f.zip]); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.zr.File[f.zip].Name, err)
+		}
+		s.wads[f.zip] = r
+	}
+	var data []byte
+	var err error
+	if subchunked(f.src) {
+		data, err = r.ReadEntry(f.src)
+	} else {
+		data, err = r.ReadRaw(f.src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Wad, err)
+	}
+	return data, nil
+}
+
+// Close cierra el paquete.
+func (s *Source) Close() error {
+	return s.f.Close()
+}
+
+// readEntry lee entera la entrada zf, hasta wad.MaxEntrySize.
+func readEntry(zf *zip.File) ([]byte, error) {
+	if zf.UncompressedSize64 > wad.MaxEntrySize {
+		return nil, fmt.Errorf("%s too large", zf.Name)
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, wad.MaxEntrySize))
+}
+
+// --- End of extract.go ---
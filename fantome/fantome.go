@@ -0,0 +1,478 @@
+// skinhunter/fantome/fantome.go
+package fantome
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"skinhunter/installs"
+	"skinhunter/wad"
+)
+
+// Paquetes de mods .fantome: un zip con META/info.json (y opcionalmente
+// META/image.png) más el contenido en WAD/ y/o RAW/. Cada WAD puede venir como
+// fichero (WAD/Ahri.wad.client) o como carpeta con los ficheros sueltos
+// (WAD/Ahri.wad.client/assets/...).
+
+var (
+	// ErrNotFantome indica que el fichero no es un zip.
+	ErrNotFantome = errors.New("not a .fantome package")
+	// ErrMissingInfo indica que falta META/info.json o que no tiene nombre.
+	ErrMissingInfo = errors.New("package has no valid META/info.json")
+	// ErrNoContent indica que el paquete no tiene nada en WAD/ ni en RAW/.
+	ErrNoContent = errors.New("package has no WAD or RAW content")
+	// ErrUnsafePath indica una ruta que saldría del paquete al extraerla ("..", absoluta).
+	ErrUnsafePath = errors.New("package contains an unsafe path")
+	// ErrNotExportable indica que el paquete instalado no es ni un .fantome ni un WAD.
+	ErrNotExportable = errors.New("installed package can't be exported")
+)
+
+// UserMessage describe por qué no se pudo importar o exportar un .fantome (ok = false si err no es de aquí).
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrNotFantome), errors.Is(err, ErrMissingInfo):
+		return "This file isn't a valid .fantome mod (it needs a META/info.json with a Name).", true
+	case errors.Is(err, ErrNoContent):
+		return "This .fantome mod has no WAD or RAW content to install.", true
+	case errors.Is(err, ErrUnsafePath):
+		return "This .fantome mod contains unsafe file paths and was not imported.", true
+	case errors.Is(err, ErrNotExportable):
+		return "This installed package can't be exported as a .fantome.", true
+	}
+	return "", false
+}
+
+const (
+	infoPath  = "META/info.json"
+	imagePath = "META/image.png"
+	wadSuffix = ".wad.client"
+	maxMeta   = 16 << 20 // Límite para info.json e image.png
+
+	// maxWadEntries limita la tabla de un WAD empaquetado (32 MiB): la cabecera del zip
+	// puede mentir sobre el tamaño descomprimido.
+	maxWadEntries = 1 << 20
+)
+
+// Info son los metadatos de META/info.json.
+type Info struct {
+	Name        string `json:"Name"`
+	Author      string `json:"Author"`
+	Version     string `json:"Version"`
+	Description string `json:"Description"`
+}
+
+// Package es un .fantome ya validado.
+type Package struct {
+	Info  Info
+	Image []byte   // PNG de META/image.png; nil si no tiene
+	Wads  []string // Nombres de los WAD que incluye, p. ej. "Ahri.wad.client", ordenados
+	Raw   bool     // Tiene ficheros en RAW/
+}
+
+// Read abre y valida el .fantome de path.
+func Read(path string) (*Package, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotFantome, err)
+	}
+	defer zr.Close()
+	return ReadZip(&zr.Reader)
+}
+
+// ReadZip valida el contenido de zr y devuelve sus metadatos.
+func ReadZip(zr *zip.Reader) (*Package, error) {
+	pkg := &Package{}
+	wads := make(map[string]bool)
+	var info *zip.File
+	for _, f := range zr.File {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		if !safePath(name) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsafePath, f.Name)
+		}
+		dir, rest, _ := strings.Cut(name, "/")
+		switch strings.ToUpper(dir) {
+		case "META":
+			switch {
+			case strings.EqualFold(name, infoPath):
+				info = f
+			case strings.EqualFold(name, imagePath):
+				img, err := readSmall(f)
+				if err != nil {
+					return nil, fmt.Errorf("read %s: %w", f.Name, err)
+				}
+				pkg.Image = img
+			}
+		case "WAD":
+			wadName, inner, _ := strings.Cut(rest, "/")
+			if !strings.HasSuffix(strings.ToLower(wadName), wadSuffix) {
+				continue
+			}
+			if inner == "" && !f.FileInfo().IsDir() {
+				// WAD empaquetado: se comprueban cabecera y tabla sin descomprimir los datos.
+				if err := checkWad(f); err != nil {
+					return nil, fmt.Errorf("%s: %w", f.Name, err)
+				}
+			}
+			wads[wadName] = true
+		case "RAW":
+			if rest != "" && !f.FileInfo().IsDir() {
+				pkg.Raw = true
+			}
+		}
+	}
+	if info == nil {
+		return nil, ErrMissingInfo
+	}
+	raw, err := readSmall(info)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMissingInfo, err)
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")) // Algunos editores guardan con BOM
+	if err := json.Unmarshal(raw, &pkg.Info); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMissingInfo, err)
+	}
+	if strings.TrimSpace(pkg.Info.Name) == "" {
+		return nil, fmt.Errorf("%w: empty Name", ErrMissingInfo)
+	}
+	for w := range wads {
+		pkg.Wads = append(pkg.Wads, w)
+	}
+	sort.Strings(pkg.Wads)
+	if len(pkg.Wads) == 0 && !pkg.Raw {
+		return nil, ErrNoContent
+	}
+	return pkg, nil
+}
+
+// safePath indica si name se puede extraer sin salir del directorio de destino.
+func safePath(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func readSmall(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxMeta {
+		return nil, fmt.Errorf("%s too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxMeta))
+}
+
+// checkWad lee la cabecera y la tabla de entradas del WAD f.
+func checkWad(f *zip.File) error {
+	_, err := wadEntries(f)
+	return err
+}
+
+// wadEntries devuelve la tabla de entradas del WAD f leyendo solo su principio (las
+// entradas de un zip no admiten acceso aleatorio).
+func wadEntries(f *zip.File) ([]wad.Entry, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	const headerSize = 4 + 256 + 8 + 4
+	head := make([]byte, headerSize)
+	if _, err := io.ReadFull(rc, head); err != nil {
+		return nil, fmt.Errorf("%w: %v", wad.ErrCorrupt, err)
+	}
+	if head[0] != 'R' || head[1] != 'W' {
+		return nil, wad.ErrBadMagic
+	}
+	count := int64(binary.LittleEndian.Uint32(head[headerSize-4:]))
+	size := int64(f.UncompressedSize64)
+	if count > maxWadEntries {
+		return nil, fmt.Errorf("%w: too many entries (%d)", wad.ErrCorrupt, count)
+	}
+	if count > (size-headerSize)/32 {
+		return nil, fmt.Errorf("%w: %d entries do not fit in %d bytes", wad.ErrCorrupt, count, size)
+	}
+	// La tabla crece según llegan los datos: un WAD truncado no reserva la tabla entera.
+	buf := bytes.NewBuffer(head)
+	if _, err := io.CopyN(buf, rc, count*32); err != nil {
+		return nil, fmt.Errorf("%w: read entry table: %v", wad.ErrCorrupt, err)
+	}
+	r, err := wad.NewReader(bytes.NewReader(buf.Bytes()), size)
+	if err != nil {
+		return nil, err
+	}
+	return r.Entries, nil
+}
+
+// WadContents son los ficheros que un paquete sustituye dentro de un WAD del juego.
+type WadContents struct {
+	Name   string   // "Ahri.wad.client", o "RAW" para los ficheros sueltos de RAW/
+	Hashes []uint64 // wad.HashPath de cada ruta, ordenados
+}
+
+// Contents devuelve lo que sustituye el paquete path: un .fantome (WAD empaquetados,
+// carpetas de WAD y RAW/) o un WAD suelto.
+func Contents(path string) ([]WadContents, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		r, wadErr := wad.Open(path)
+		if wadErr != nil {
+			if errors.Is(wadErr, os.ErrNotExist) {
+				return nil, fmt.Errorf("%w: %s", installs.ErrPackageMissing, path)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrNotExportable, wadErr)
+		}
+		defer r.Close()
+		return []WadContents{wadHashes(filepath.Base(path), r.Entries)}, nil
+	}
+	defer zr.Close()
+	byName := make(map[string]map[uint64]bool)
+	add := func(name string, h uint64) {
+		if byName[name] == nil {
+			byName[name] = make(map[uint64]bool)
+		}
+		byName[name][h] = true
+	}
+	for _, f := range zr.File {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		dir, rest, _ := strings.Cut(name, "/")
+		if f.FileInfo().IsDir() || rest == "" || !safePath(name) {
+			continue
+		}
+		switch strings.ToUpper(dir) {
+		case "WAD":
+			wadName, inner, _ := strings.Cut(rest, "/")
+			if !strings.HasSuffix(strings.ToLower(wadName), wadSuffix) {
+				continue
+			}
+			if inner != "" {
+				add(wadName, wad.HashPath(inner))
+				continue
+			}
+			entries, err := wadEntries(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			for _, e := range entries {
+				add(wadName, e.PathHash)
+			}
+		case "RAW":
+			add("RAW", wad.HashPath(rest))
+		}
+	}
+	out := make([]WadContents, 0, len(byName))
+	for name, set := range byName {
+		wc := WadContents{Name: name}
+		for h := range set {
+			wc.Hashes = append(wc.Hashes, h)
+		}
+		sort.Slice(wc.Hashes, func(i, j int) bool { return wc.Hashes[i] < wc.Hashes[j] })
+		out = append(out, wc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func wadHashes(name string, entries []wad.Entry) WadContents {
+	wc := WadContents{Name: name, Hashes: make([]uint64, len(entries))}
+	for i, e := range entries {
+		wc.Hashes[i] = e.PathHash
+	}
+	return wc
+}
+
+// Import valida el .fantome src, lo copia a dir y lo registra en registry. Importar
+// dos veces el mismo fichero sustituye la entrada anterior. Paquete e imagen se copian
+// primero a temporales y solo se mueven a su sitio cuando el registro acepta la
+// entrada: un Import fallido no toca lo ya instalado.
+func Import(src, dir string, registry *installs.Registry) (installs.Entry, error) {
+	if err := registry.CheckReady(); err != nil {
+		return installs.Entry{}, err
+	}
+	pkg, err := Read(src)
+	if err != nil {
+		return installs.Entry{}, err
+	}
+	sum, err := installs.FileChecksum(src)
+	if err != nil {
+		return installs.Entry{}, err
+	}
+	modID := ModID(sum)
+	in, err := os.Open(src)
+	if err != nil {
+		return installs.Entry{}, err
+	}
+	tmp, err := writeTemp(dir, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+	in.Close()
+	if err != nil {
+		return installs.Entry{}, err
+	}
+	defer os.Remove(tmp) // No hace nada una vez movido
+	e := installs.Entry{
+		ModID:       modID,
+		Name:        strings.TrimSpace(pkg.Info.Name),
+		PackagePath: filepath.Join(dir, strconv.Itoa(modID)+".fantome"),
+		Checksum:    sum,
+		Mod: &installs.ModInfo{
+			Author:      strings.TrimSpace(pkg.Info.Author),
+			Version:     strings.TrimSpace(pkg.Info.Version),
+			Description: strings.TrimSpace(pkg.Info.Description),
+			Wads:        pkg.Wads,
+		},
+	}
+	var tmpImg string
+	if pkg.Image != nil {
+		tmpImg, err = writeTemp(dir, func(w io.Writer) error {
+			_, err := w.Write(pkg.Image)
+			return err
+		})
+		if err == nil {
+			defer os.Remove(tmpImg)
+			e.Mod.ImagePath = filepath.Join(dir, strconv.Itoa(modID)+".png")
+		}
+	}
+	if err := registry.Add(e); err != nil {
+		return installs.Entry{}, err
+	}
+	if err := os.Rename(tmp, e.PackagePath); err != nil {
+		return installs.Entry{}, fmt.Errorf("install %s: %w", e.PackagePath, err)
+	}
+	if tmpImg != "" {
+		if err := os.Rename(tmpImg, e.Mod.ImagePath); err != nil {
+			log.Printf("WARN: Fantome: could not save the image of %s: %v", e.Name, err)
+		}
+	}
+	e, _ = registry.Get(e.ItemID())
+	return e, nil
+}
+
+// ModID deriva el ID de un mod de su checksum (SHA-256 en hex): siempre positivo y
+// distinto de cero.
+func ModID(checksum string) int {
+	if len(checksum) >= 7 {
+		if n, err := strconv.ParseInt(checksum[:7], 16, 64); err == nil && n != 0 {
+			return int(n)
+		}
+	}
+	return 1
+}
+
+// Export guarda la instalación e como .fantome en dest. Si su paquete ya es un
+// .fantome se copia tal cual; si es un WAD suelto se empaqueta como WAD/<wadName>
+// con un info.json hecho a partir de la entrada.
+func Export(e installs.Entry, dest, wadName string) error {
+	if _, err := Read(e.PackagePath); err == nil {
+		return copyFile(e.PackagePath, dest)
+	}
+	r, err := wad.Open(e.PackagePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", installs.ErrPackageMissing, e.PackagePath)
+		}
+		return fmt.Errorf("%w: %v", ErrNotExportable, err)
+	}
+	r.Close()
+	if !strings.HasSuffix(strings.ToLower(wadName), wadSuffix) {
+		wadName += wadSuffix
+	}
+	info := Info{Name: e.Name, Version: "1.0", Description: "Exported from Skin Hunter"}
+	if e.Mod != nil {
+		info.Author, info.Version, info.Description = e.Mod.Author, e.Mod.Version, e.Mod.Description
+	}
+	return writeAtomic(dest, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		iw, err := zw.Create(infoPath)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(iw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			return err
+		}
+		// Los WAD ya van comprimidos por dentro: se guardan sin volver a comprimir.
+		ww, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join("WAD", wadName), Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(e.PackagePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(ww, f); err != nil {
+			return err
+		}
+		return zw.Close()
+	})
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeAtomic(dest, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
+
+// writeAtomic escribe dest con write a través de un fichero temporal + rename.
+func writeAtomic(dest string, write func(w io.Writer) error) error {
+	tmp, err := writeTemp(filepath.Dir(dest), write)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// writeTemp escribe con write un fichero temporal nuevo en dir y devuelve su ruta.
+func writeTemp(dir string, write func(w io.Writer) error) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, ".fantome-*")
+	if err != nil {
+		return "", err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// --- End of fantome.go ---
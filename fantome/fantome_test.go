@@ -0,0 +1,376 @@
+package fantome
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skinhunter/installs"
+	"skinhunter/wad"
+)
+
+// testWad devuelve un WAD pequeño válido.
+func testWad(t *testing.T) []byte {
+	t.Helper()
+	w := wad.NewWriter()
+	if err := w.Add(wad.HashPath("data/characters/ahri/skins/skin0.bin"), []byte("PROP"), wad.CompressionZstd); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeZip crea un zip en dir con files (ruta -> contenido).
+func writeZip(t *testing.T, dir, name string, files map[string][]byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for p, data := range files {
+		fw, err := zw.Create(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestReadValidates(t *testing.T) {
+	dir := t.TempDir()
+	info := []byte("\xef\xbb\xbf{\"Name\":\"Arcade Ahri\",\"Author\":\"someone\",\"Version\":\"1.2\",\"Description\":\"test\"}")
+	good := writeZip(t, dir, "good.fantome", map[string][]byte{
+		"META/info.json":                     info,
+		"META/image.png":                     []byte("png"),
+		"WAD/Ahri.wad.client":                testWad(t),
+		"WAD/Map11.wad.client/data/x.bin":    []byte("x"),
+		"RAW/assets/characters/ahri/a.dds":   []byte("dds"),
+		"WAD/readme.txt":                     []byte("ignored"),
+		"WAD/Map11.wad.client/data/y/z.bin":  []byte("z"),
+		"RAW/assets/characters/ahri/b.dds":   []byte("dds"),
+		"META/something-else.txt":            []byte("ignored"),
+		"WAD/Ahri.wad.client.bak/unused.bin": []byte("ignored"),
+	})
+	pkg, err := Read(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Info.Name != "Arcade Ahri" || pkg.Info.Author != "someone" || string(pkg.Image) != "png" || !pkg.Raw {
+		t.Errorf("Read = %+v", pkg)
+	}
+	if len(pkg.Wads) != 2 || pkg.Wads[0] != "Ahri.wad.client" || pkg.Wads[1] != "Map11.wad.client" {
+		t.Errorf("Wads = %v", pkg.Wads)
+	}
+
+	bad := []struct {
+		name  string
+		files map[string][]byte
+		want  error
+	}{
+		{"noinfo", map[string][]byte{"WAD/Ahri.wad.client": testWad(t)}, ErrMissingInfo},
+		{"noname", map[string][]byte{"META/info.json": []byte(`{"Author":"x"}`), "WAD/Ahri.wad.client": testWad(t)}, ErrMissingInfo},
+		{"empty", map[string][]byte{"META/info.json": []byte(`{"Name":"x"}`)}, ErrNoContent},
+		{"badwad", map[string][]byte{"META/info.json": []byte(`{"Name":"x"}`), "WAD/Ahri.wad.client": []byte("not a wad at all")}, wad.ErrCorrupt},
+		{"slip", map[string][]byte{"META/info.json": []byte(`{"Name":"x"}`), "RAW/../../evil.dll": []byte("x")}, ErrUnsafePath},
+	}
+	for _, tc := range bad {
+		if _, err := Read(writeZip(t, dir, tc.name+".fantome", tc.files)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	notZip := filepath.Join(dir, "x.fantome")
+	os.WriteFile(notZip, []byte("hello"), 0o644)
+	if _, err := Read(notZip); !errors.Is(err, ErrNotFantome) {
+		t.Errorf("not a zip: %v", err)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	dir := t.TempDir()
+	src := writeZip(t, dir, "mod.fantome", map[string][]byte{
+		"META/info.json":      []byte(`{"Name":"Arcade Ahri","Author":"someone","Version":"1.0"}`),
+		"META/image.png":      []byte("png"),
+		"WAD/Ahri.wad.client": testWad(t),
+	})
+	reg, _ := installs.Open("")
+	e, err := Import(src, filepath.Join(dir, "mods"), reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.IsMod() || e.ItemID() >= 0 || !reg.IsInstalled(e.ItemID()) || e.Mod.Author != "someone" || len(e.Mod.Wads) != 1 {
+		t.Errorf("imported entry = %+v", e)
+	}
+	if err := reg.Verify(e.ItemID()); err != nil {
+		t.Errorf("Verify imported mod: %v", err)
+	}
+	again, err := Import(src, filepath.Join(dir, "mods"), reg)
+	if err != nil || again.ItemID() != e.ItemID() || len(reg.List()) != 1 {
+		t.Errorf("re-import should replace the entry: %v, %d entries", err, len(reg.List()))
+	}
+
+	out := filepath.Join(dir, "export", "copy.fantome")
+	if err := Export(e, out, ""); err != nil {
+		t.Fatal(err)
+	}
+	if sum, _ := installs.FileChecksum(out); sum != e.Checksum {
+		t.Errorf("exported .fantome differs from the imported one")
+	}
+
+	if err := reg.Remove(e.ItemID()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(e.Mod.ImagePath); !os.IsNotExist(err) {
+		t.Errorf("mod image left on disk: %v", err)
+	}
+}
+
+func TestExportWad(t *testing.T) {
+	dir := t.TempDir()
+	wadPath := filepath.Join(dir, "103015.client")
+	if err := os.WriteFile(wadPath, testWad(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := installs.Entry{ChampionID: 103, SkinID: 103015, Name: "Star Guardian Ahri", PackagePath: wadPath}
+	out := filepath.Join(dir, "sg.fantome")
+	if err := Export(e, out, "Ahri"); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := Read(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Info.Name != "Star Guardian Ahri" || len(pkg.Wads) != 1 || pkg.Wads[0] != "Ahri.wad.client" {
+		t.Errorf("exported package = %+v", pkg)
+	}
+
+	e.PackagePath = filepath.Join(dir, "missing.client")
+	if err := Export(e, out, "Ahri"); !errors.Is(err, installs.ErrPackageMissing) {
+		t.Errorf("missing package: %v", err)
+	}
+	os.WriteFile(e.PackagePath, []byte("junk"), 0o644)
+	if err := Export(e, out, "Ahri"); !errors.Is(err, ErrNotExportable) {
+		t.Errorf("junk package: %v", err)
+	}
+}
+
+func TestContents(t *testing.T) {
+	dir := t.TempDir()
+	src := writeZip(t, dir, "mod.fantome", map[string][]byte{
+		"META/info.json":                  []byte(`{"Name":"x"}`),
+		"WAD/Ahri.wad.client":             testWad(t),
+		"WAD/Map11.wad.client/data/x.bin": []byte("x"),
+		"RAW/assets/a.dds":                []byte("a"),
+	})
+	got, err := Contents(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name string
+		hash uint64
+	}{
+		{"Ahri.wad.client", wad.HashPath("data/characters/ahri/skins/skin0.bin")},
+		{"Map11.wad.client", wad.HashPath("data/x.bin")},
+		{"RAW", wad.HashPath("assets/a.dds")},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Contents = %+v", got)
+	}
+	for i, w := range want {
+		if got[i].Name != w.name || len(got[i].Hashes) != 1 || got[i].Hashes[0] != w.hash {
+			t.Errorf("Contents[%d] = %+v, want %s with %016x", i, got[i], w.name, w.hash)
+		}
+	}
+
+	bare := filepath.Join(dir, "103015.client")
+	os.WriteFile(bare, testWad(t), 0o644)
+	if got, err := Contents(bare); err != nil || len(got) != 1 || len(got[0].Hashes) != 1 {
+		t.Errorf("Contents(bare WAD) = %+v, %v", got, err)
+	}
+	if _, err := Contents(filepath.Join(dir, "missing")); !errors.Is(err, installs.ErrPackageMissing) {
+		t.Errorf("missing package: %v", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	skin0 := wad.HashPath("data/characters/ahri/skins/skin0.bin")
+	src := writeZip(t, dir, "mod.fantome", map[string][]byte{
+		"META/info.json":                  []byte(`{"Name":"x"}`),
+		"WAD/Ahri.wad.client":             testWad(t),
+		"WAD/Map11.wad.client/data/x.bin": []byte("x"),
+		"RAW/assets/a.dds":                []byte("a"),
+	})
+	for h, want := range map[uint64]string{skin0: "PROP", wad.HashPath("data/x.bin"): "x", wad.HashPath("assets/a.dds"): "a"} {
+		if got, err := ReadFile(src, h); err != nil || string(got) != want {
+			t.Errorf("ReadFile(%016x) = %q, %v, want %q", h, got, err, want)
+		}
+	}
+	if _, err := ReadFile(src, 42); !errors.Is(err, wad.ErrNotFound) {
+		t.Errorf("missing file: %v", err)
+	}
+
+	// WAD guardado sin comprimir dentro del zip: se lee sin descomprimir el zip.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, _ := zw.CreateHeader(&zip.FileHeader{Name: "WAD/Ahri.wad.client", Method: zip.Store})
+	fw.Write(testWad(t))
+	zw.Close()
+	stored := filepath.Join(dir, "stored.fantome")
+	os.WriteFile(stored, buf.Bytes(), 0o644)
+	if got, err := ReadFile(stored, skin0); err != nil || string(got) != "PROP" {
+		t.Errorf("stored WAD: %q, %v", got, err)
+	}
+
+	bare := filepath.Join(dir, "103015.client")
+	os.WriteFile(bare, testWad(t), 0o644)
+	if got, err := ReadFile(bare, skin0); err != nil || string(got) != "PROP" {
+		t.Errorf("bare WAD: %q, %v", got, err)
+	}
+	if _, err := ReadFile(filepath.Join(dir, "missing"), skin0); !errors.Is(err, installs.ErrPackageMissing) {
+		t.Errorf("missing package: %v", err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	src := writeZip(t, dir, "mod.fantome", map[string][]byte{
+		"META/info.json":                  []byte(`{"Name":"x"}`),
+		"WAD/Ahri.wad.client":             testWad(t),
+		"WAD/Map11.wad.client/data/x.bin": []byte("x"),
+		"RAW/assets/a.dds":                []byte("a"),
+	})
+	got := make(map[string]File)
+	err := Walk(src, func(f File) error {
+		got[f.Wad] = f
+		return nil
+	})
+	if err != nil || len(got) != 3 {
+		t.Fatalf("Walk = %v, %v", got, err)
+	}
+	if f := got["Ahri.wad.client"]; f.Entry.PathHash != wad.HashPath("data/characters/ahri/skins/skin0.bin") || f.Entry.Compression != wad.CompressionZstd || f.Entry.Size != 4 {
+		t.Errorf("packed entry = %+v", f.Entry)
+	}
+	s, err := OpenSource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	read := func(f File) string {
+		data, err := s.Read(f)
+		if err != nil {
+			t.Errorf("Read(%s): %v", f.Wad, err)
+		}
+		return string(data)
+	}
+	if f := got["Ahri.wad.client"]; len(read(f)) != int(f.Entry.CompressedSize) {
+		t.Errorf("packed entry should be read as stored")
+	}
+	if f := got["Map11.wad.client"]; f.Entry.PathHash != wad.HashPath("data/x.bin") || f.Entry.Compression != wad.CompressionNone || read(f) != "x" {
+		t.Errorf("loose file = %+v", f)
+	}
+	if f := got["RAW"]; f.Entry.PathHash != wad.HashPath("assets/a.dds") || f.Entry.Size != 1 || read(f) != "a" {
+		t.Errorf("RAW file = %+v", f)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	if err := Walk(src, func(File) error { n++; return stop }); !errors.Is(err, stop) || n != 1 {
+		t.Errorf("Walk should stop at the first error: %v after %d files", err, n)
+	}
+	bare := filepath.Join(dir, "103015.client")
+	os.WriteFile(bare, testWad(t), 0o644)
+	n = 0
+	if err := Walk(bare, func(f File) error { n++; return nil }); err != nil || n != 1 {
+		t.Errorf("Walk(bare WAD) = %v, %d files", err, n)
+	}
+	if err := Walk(filepath.Join(dir, "missing"), func(File) error { return nil }); !errors.Is(err, installs.ErrPackageMissing) {
+		t.Errorf("missing package: %v", err)
+	}
+}
+
+func TestImportFailureKeepsInstalledMod(t *testing.T) {
+	dir := t.TempDir()
+	mods := filepath.Join(dir, "mods")
+	src := writeZip(t, dir, "mod.fantome", map[string][]byte{
+		"META/info.json":      []byte(`{"Name":"Arcade Ahri"}`),
+		"META/image.png":      []byte("png"),
+		"WAD/Ahri.wad.client": testWad(t),
+	})
+	reg, _ := installs.Open("")
+	e, err := Import(src, mods, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La precondición pasa al empezar el Import y falla dentro de Add.
+	errNotReady := errors.New("game folder gone")
+	calls := 0
+	reg.SetPrecondition(func() error {
+		calls++
+		if calls > 1 {
+			return errNotReady
+		}
+		return nil
+	})
+	if _, err := Import(src, mods, reg); !errors.Is(err, errNotReady) {
+		t.Fatalf("re-import err = %v, want the Add error", err)
+	}
+	if sum, err := installs.FileChecksum(e.PackagePath); err != nil || sum != e.Checksum {
+		t.Errorf("failed re-import damaged the installed package: %v", err)
+	}
+	if raw, err := os.ReadFile(e.Mod.ImagePath); err != nil || string(raw) != "png" {
+		t.Errorf("failed re-import damaged the mod image: %q, %v", raw, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(mods, "*")); len(files) != 2 {
+		t.Errorf("failed re-import left files: %v", files)
+	}
+}
+
+func TestReadLyingWadHeader(t *testing.T) {
+	// Cabecera de WAD sin tabla dentro de un zip que dice medir 1 TiB: no debe reservarse
+	// la tabla anunciada.
+	head := make([]byte, 4+256+8+4)
+	copy(head, "RW\x03\x01")
+	for _, tc := range []struct {
+		name  string
+		count uint32
+	}{{"huge", 0xFFFFFFFF}, {"truncated", 1000}} {
+		binary.LittleEndian.PutUint32(head[len(head)-4:], tc.count)
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		iw, _ := zw.Create("META/info.json")
+		iw.Write([]byte(`{"Name":"x"}`))
+		ww, err := zw.CreateRaw(&zip.FileHeader{
+			Name:               "WAD/Ahri.wad.client",
+			Method:             zip.Store,
+			CompressedSize64:   uint64(len(head)),
+			UncompressedSize64: 1 << 40,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ww.Write(head)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(t.TempDir(), tc.name+".fantome")
+		os.WriteFile(p, buf.Bytes(), 0o644)
+		if _, err := Read(p); !errors.Is(err, wad.ErrCorrupt) {
+			t.Errorf("%s: err = %v, want wad.ErrCorrupt", tc.name, err)
+		}
+	}
+}
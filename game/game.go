@@ -0,0 +1,246 @@
+// skinhunter/game/game.go
+package game
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Localización y validación de la instalación del juego. La carpeta configurada es
+// la raíz de la instalación ("Riot Games/League of Legends"), que contiene
+// LeagueClient y la carpeta Game con los WAD en Game/DATA/FINAL.
+
+var (
+	// ErrNotConfigured indica que no hay carpeta del juego configurada.
+	ErrNotConfigured = errors.New("game directory not configured")
+	// ErrInvalidDir indica que la carpeta configurada no es una instalación del juego.
+	ErrInvalidDir = errors.New("not a League of Legends installation")
+)
+
+// UserMessage explica al usuario los problemas con la carpeta del juego (ok = false si err es de otro tipo).
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		return "The game folder isn't set. Choose it in Settings before installing or uninstalling skins.", true
+	case errors.Is(err, ErrInvalidDir):
+		return "The game folder in Settings isn't a League of Legends installation (Game/DATA/FINAL or LeagueClient is missing).", true
+	}
+	return "", false
+}
+
+// clientFiles son los ficheros del cliente que se buscan en la raíz; basta con uno.
+var clientFiles = []string{"LeagueClient.exe", "LeagueClient.app", "LeagueClientUx.exe"}
+
+// Installation es una instalación válida del juego.
+type Installation struct {
+	Root    string // Raíz: contiene LeagueClient y Game
+	GameDir string // Root/Game
+	DataDir string // Root/Game/DATA/FINAL, donde están los WAD
+}
+
+// Validate comprueba que dir es una instalación del juego. Acepta tanto la raíz como
+// su subcarpeta Game.
+func Validate(dir string) (Installation, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return Installation{}, ErrNotConfigured
+	}
+	root := filepath.Clean(dir)
+	if strings.EqualFold(filepath.Base(root), "Game") && !isDir(filepath.Join(root, "Game")) {
+		root = filepath.Dir(root)
+	}
+	inst := Installation{
+		Root:    root,
+		GameDir: filepath.Join(root, "Game"),
+		DataDir: filepath.Join(root, "Game", "DATA", "FINAL"),
+	}
+	if !isDir(root) {
+		return Installation{}, fmt.Errorf("%w: %s does not exist", ErrInvalidDir, root)
+	}
+	if !isDir(inst.DataDir) {
+		return Installation{}, fmt.Errorf("%w: no Game/DATA/FINAL in %s", ErrInvalidDir, root)
+	}
+	for _, f := range clientFiles {
+		if _, err := os.Stat(filepath.Join(root, f)); err == nil {
+			return inst, nil
+		}
+	}
+	return Installation{}, fmt.Errorf("%w: no LeagueClient in %s", ErrInvalidDir, root)
+}
+
+func isDir(p string) bool {
+	st, err := os.Stat(p)
+	return err == nil && st.IsDir()
+}
+
+// Detect devuelve las instalaciones encontradas en las rutas habituales del sistema
+// (en Linux, también dentro de prefijos de Wine, Lutris y Bottles), sin repetir.
+func Detect() []Installation {
+	home, _ := os.UserHomeDir()
+	var out []Installation
+	seen := make(map[string]bool)
+	for _, c := range candidates(runtime.GOOS, home, os.Getenv) {
+		inst, err := Validate(c)
+		if err != nil || seen[inst.Root] {
+			continue
+		}
+		seen[inst.Root] = true
+		out = append(out, inst)
+	}
+	return out
+}
+
+// candidates devuelve las carpetas donde suele estar el juego en goos.
+func candidates(goos, home string, getenv func(string) string) []string {
+	switch goos {
+	case "windows":
+		var out []string
+		for d := 'C'; d <= 'H'; d++ {
+			out = append(out, driveCandidates(string(d)+`:\`)...)
+		}
+		return out
+	case "darwin":
+		return []string{
+			"/Applications/League of Legends.app/Contents/LoL",
+			filepath.Join(home, "Applications", "League of Legends.app", "Contents", "LoL"),
+		}
+	}
+	// Linux y demás: el juego corre bajo Wine; se buscan las unidades C: de los prefijos.
+	var drives []string
+	if p := getenv("WINEPREFIX"); p != "" {
+		drives = append(drives, filepath.Join(p, "drive_c"))
+	}
+	if home != "" {
+		drives = append(drives, filepath.Join(home, ".wine", "drive_c"))
+		for _, pattern := range []string{
+			filepath.Join(home, "Games", "*", "drive_c"), // Lutris (~/Games/league-of-legends)
+			filepath.Join(home, ".local", "share", "lutris", "prefixes", "*", "drive_c"),
+			filepath.Join(home, ".local", "share", "bottles", "bottles", "*", "drive_c"),
+			filepath.Join(home, ".var", "app", "com.usebottles.bottles", "data", "bottles", "bottles", "*", "drive_c"),
+		} {
+			matches, _ := filepath.Glob(pattern)
+			drives = append(drives, matches...)
+		}
+	}
+	var out []string
+	for _, d := range drives {
+		out = append(out, driveCandidates(d)...)
+	}
+	return out
+}
+
+// driveCandidates devuelve las rutas de la unidad drive (la raíz de C: o el drive_c
+// de un prefijo): primero la que haya registrado el instalador de Riot y después las
+// carpetas por defecto.
+func driveCandidates(drive string) []string {
+	var out []string
+	settings := filepath.Join(drive, "ProgramData", "Riot Games", "Metadata", "league_of_legends.live", "league_of_legends.live.product_settings.yaml")
+	if p := productInstallPath(settings); p != "" {
+		// La ruta es de Windows ("C:/Riot Games/..."): se cuelga de la unidad.
+		if len(p) >= 2 && p[1] == ':' {
+			p = p[2:]
+		}
+		out = append(out, filepath.Join(drive, filepath.FromSlash(strings.ReplaceAll(p, `\`, "/"))))
+	}
+	return append(out,
+		filepath.Join(drive, "Riot Games", "League of Legends"),
+		filepath.Join(drive, "Program Files", "Riot Games", "League of Legends"),
+		filepath.Join(drive, "Program Files (x86)", "Riot Games", "League of Legends"),
+	)
+}
+
+// productInstallPath lee product_install_full_path del fichero de ajustes del
+// instalador de Riot; "" si no existe.
+func productInstallPath(settingsFile string) string {
+	f, err := os.Open(settingsFile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if ok && key == "product_install_full_path" {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
+
+var (
+	mu        sync.Mutex
+	dir       string
+	listeners = make(map[int]func())
+	nextID    int
+)
+
+// OnChange registra fn para que se llame (desde la goroutine de SetDir) cuando cambia
+// la carpeta configurada. La función devuelta da de baja fn.
+func OnChange(fn func()) (remove func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	id := nextID
+	nextID++
+	listeners[id] = fn
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(listeners, id)
+	}
+}
+
+// SetDir configura la carpeta del juego que usa la app ("" la quita). Devuelve el
+// resultado de validarla, pero la guarda igualmente.
+func SetDir(d string) error {
+	d = strings.TrimSpace(d)
+	mu.Lock()
+	changed := d != dir
+	dir = d
+	fns := make([]func(), 0, len(listeners))
+	for _, fn := range listeners {
+		fns = append(fns, fn)
+	}
+	mu.Unlock()
+	if changed {
+		for _, fn := range fns {
+			fn()
+		}
+	}
+	if d == "" {
+		return ErrNotConfigured
+	}
+	_, err := Validate(d)
+	if err != nil {
+		log.Printf("WARN: Game directory %q: %v", d, err)
+	}
+	return err
+}
+
+// Dir devuelve la carpeta configurada, tal como se guardó.
+func Dir() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return dir
+}
+
+// Current valida la carpeta configurada (en cada llamada: puede haber cambiado en
+// disco) y devuelve la instalación.
+func Current() (Installation, error) {
+	return Validate(Dir())
+}
+
+// Ready devuelve nil si hay una instalación válida configurada; sirve como
+// precondición de las operaciones de instalación.
+func Ready() error {
+	_, err := Current()
+	return err
+}
+
+// --- End of game.go ---
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeInstall crea en root el esqueleto de una instalación.
+func makeInstall(t *testing.T, root string, client bool) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, "Game", "DATA", "FINAL", "Champions"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if client {
+		if err := os.WriteFile(filepath.Join(root, "LeagueClient.exe"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	base := t.TempDir()
+	good := filepath.Join(base, "Riot Games", "League of Legends")
+	makeInstall(t, good, true)
+	for _, d := range []string{good, filepath.Join(good, "Game"), good + string(filepath.Separator)} {
+		inst, err := Validate(d)
+		if err != nil {
+			t.Fatalf("Validate(%q): %v", d, err)
+		}
+		if inst.Root != good || inst.DataDir != filepath.Join(good, "Game", "DATA", "FINAL") {
+			t.Errorf("Validate(%q) = %+v", d, inst)
+		}
+	}
+
+	noClient := filepath.Join(base, "noclient")
+	makeInstall(t, noClient, false)
+	if _, err := Validate(noClient); !errors.Is(err, ErrInvalidDir) {
+		t.Errorf("without LeagueClient: %v", err)
+	}
+	if _, err := Validate(base); !errors.Is(err, ErrInvalidDir) {
+		t.Errorf("without Game/DATA/FINAL: %v", err)
+	}
+	if _, err := Validate(filepath.Join(base, "missing")); !errors.Is(err, ErrInvalidDir) {
+		t.Errorf("missing dir: %v", err)
+	}
+	if _, err := Validate(" "); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("empty: %v", err)
+	}
+}
+
+func TestLinuxCandidates(t *testing.T) {
+	home := t.TempDir()
+	lutris := filepath.Join(home, "Games", "league-of-legends", "drive_c", "Riot Games", "League of Legends")
+	makeInstall(t, lutris, true)
+
+	// Prefijo con la ruta registrada por el instalador en otra carpeta.
+	prefix := filepath.Join(home, "prefixes", "lol")
+	custom := filepath.Join(prefix, "drive_c", "Games", "LoL")
+	makeInstall(t, custom, true)
+	meta := filepath.Join(prefix, "drive_c", "ProgramData", "Riot Games", "Metadata", "league_of_legends.live")
+	os.MkdirAll(meta, 0o755)
+	yaml := "product_install_full_path: \"C:/Games/LoL\"\nproduct_install_root: \"C:/\"\n"
+	if err := os.WriteFile(filepath.Join(meta, "league_of_legends.live.product_settings.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := func(k string) string {
+		if k == "WINEPREFIX" {
+			return prefix
+		}
+		return ""
+	}
+	found := map[string]bool{}
+	for _, c := range candidates("linux", home, env) {
+		if inst, err := Validate(c); err == nil {
+			found[inst.Root] = true
+		}
+	}
+	if !found[lutris] || !found[custom] || len(found) != 2 {
+		t.Errorf("found %v, want the Lutris and WINEPREFIX installs", found)
+	}
+}
+
+func TestSetDir(t *testing.T) {
+	t.Cleanup(func() { SetDir("") })
+	changes := 0
+	remove := OnChange(func() { changes++ })
+	defer remove()
+	if err := SetDir(""); !errors.Is(err, ErrNotConfigured) || !errors.Is(Ready(), ErrNotConfigured) {
+		t.Errorf("empty dir should not be ready")
+	}
+	root := t.TempDir()
+	if err := SetDir(root); !errors.Is(err, ErrInvalidDir) || Dir() != root {
+		t.Errorf("invalid dir should be kept but reported: %v", err)
+	}
+	makeInstall(t, root, true)
+	if err := Ready(); err != nil {
+		t.Errorf("Ready after creating the layout: %v", err)
+	}
+	SetDir(root)
+	if changes != 1 {
+		t.Errorf("OnChange called %d times, want 1 (only real changes)", changes)
+	}
+}
@@ -0,0 +1,230 @@
+package hashes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"skinhunter/wad"
+)
+
+var testPaths = []string{
+	"assets/characters/ahri/skins/skin15/ahri_skin15_tx_cm.tex",
+	"data/characters/ahri/skins/skin15.bin",
+	"assets/characters/ahri/skins/base/ahri.skn",
+}
+
+// tableText devuelve paths en formato hashes.game.txt.
+func tableText(paths ...string) string {
+	var b strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&b, "%016x %s\n", wad.HashPath(p), p)
+	}
+	return b.String()
+}
+
+func TestTableLookups(t *testing.T) {
+	tbl, err := Parse(strings.NewReader(tableText(testPaths...) + "\r\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Len() != len(testPaths) {
+		t.Fatalf("Len = %d", tbl.Len())
+	}
+	for _, p := range testPaths {
+		h := wad.HashPath(p)
+		if got, ok := tbl.Path(h); !ok || got != p {
+			t.Errorf("Path(%016x) = %q, %v", h, got, ok)
+		}
+		if got, ok := tbl.Hash(strings.ToUpper(p)); !ok || got != h {
+			t.Errorf("Hash(%q) = %016x, %v", p, got, ok)
+		}
+	}
+	unknown := wad.HashPath("assets/unknown.tex")
+	if got := tbl.Unknown([]uint64{wad.HashPath(testPaths[0]), unknown}); len(got) != 1 || got[0] != unknown {
+		t.Errorf("Unknown = %x", got)
+	}
+	if got := tbl.Name(unknown); got != fmt.Sprintf("%016x", unknown) {
+		t.Errorf("Name(unknown) = %q", got)
+	}
+	var nilTable *Table
+	if _, ok := nilTable.Path(1); ok || nilTable.Len() != 0 {
+		t.Errorf("nil table should know nothing")
+	}
+}
+
+func TestParseErrorsAndMerge(t *testing.T) {
+	if _, err := Parse(strings.NewReader("0123 ok\nnothex path\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("malformed line: %v", err)
+	}
+	a, _ := Parse(strings.NewReader(tableText(testPaths[0])))
+	b, _ := Parse(strings.NewReader(tableText(testPaths[1:]...) + fmt.Sprintf("%016x other/name.tex\n", wad.HashPath(testPaths[0]))))
+	m := Merge(a, nil, b)
+	if m.Len() != len(testPaths) {
+		t.Errorf("Merge Len = %d", m.Len())
+	}
+	if p, _ := m.Path(wad.HashPath(testPaths[0])); p != testPaths[0] {
+		t.Errorf("Merge should keep the first table's path, got %q", p)
+	}
+}
+
+func TestManagerHTTPUpdates(t *testing.T) {
+	var body atomic.Value
+	body.Store(tableText(testPaths[:2]...))
+	var fulls, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lol/hashes.game.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		content := body.Load().(string)
+		etag := fmt.Sprintf(`"%d"`, len(content))
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fulls.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	m := NewManager(dir)
+	m.SetLocation(srv.URL + "/lol")
+	ctx := context.Background()
+	tbl, err := m.Table(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Len() != 2 || fulls.Load() != 1 {
+		t.Fatalf("first load: %d paths, %d downloads", tbl.Len(), fulls.Load())
+	}
+
+	// Otro gestor con la misma caché no vuelve a preguntar antes de UpdateInterval.
+	m2 := NewManager(dir)
+	m2.SetLocation(srv.URL + "/lol")
+	if tbl, err := m2.Table(ctx); err != nil || tbl.Len() != 2 || fulls.Load()+notModified.Load() != 1 {
+		t.Fatalf("cached load: %v, %d requests", err, fulls.Load()+notModified.Load())
+	}
+
+	// Update sin cambios: 304; con cambios: se baja de nuevo.
+	if _, err := m.Update(ctx); err != nil || notModified.Load() != 1 {
+		t.Fatalf("Update unchanged: %v, %d not-modified", err, notModified.Load())
+	}
+	body.Store(tableText(testPaths...))
+	tbl, err = m.Update(ctx)
+	if err != nil || tbl.Len() != 3 || fulls.Load() != 2 {
+		t.Fatalf("Update changed: %v, %d paths, %d downloads", err, tbl.Len(), fulls.Load())
+	}
+
+	// Sin servidor se sigue usando la caché.
+	srv.Close()
+	if tbl, err := m.Update(ctx); err != nil || tbl.Len() != 3 {
+		t.Errorf("offline update: %v", err)
+	}
+}
+
+func TestManagerLocalAndMissing(t *testing.T) {
+	src := t.TempDir()
+	file := filepath.Join(src, "hashes.game.txt")
+	os.WriteFile(file, []byte(tableText(testPaths[0])), 0o644)
+	m := NewManager(t.TempDir())
+	m.SetLocation(src)
+	if tbl, err := m.Table(context.Background()); err != nil || tbl.Len() != 1 {
+		t.Fatalf("local load: %v", err)
+	}
+	os.WriteFile(file, []byte(tableText(testPaths...)), 0o644)
+	os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if tbl, err := m.Update(context.Background()); err != nil || tbl.Len() != 3 {
+		t.Fatalf("local update: %v", err)
+	}
+
+	empty := NewManager(t.TempDir())
+	empty.SetLocation(t.TempDir())
+	if _, err := empty.Table(context.Background()); !errors.Is(err, ErrNoTables) {
+		t.Errorf("missing tables = %v, want ErrNoTables", err)
+	}
+}
+
+func TestParseUnsortedDuplicates(t *testing.T) {
+	h := wad.HashPath(testPaths[1])
+	text := tableText(testPaths[1], testPaths[0]) + fmt.Sprintf("%016x other/name.bin\n", h) + tableText(testPaths[2])
+	tbl, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Len() != len(testPaths) {
+		t.Errorf("Len = %d, want %d", tbl.Len(), len(testPaths))
+	}
+	if p, _ := tbl.Path(h); p != testPaths[1] {
+		t.Errorf("duplicate hash should keep the first path, got %q", p)
+	}
+	for i := 1; i < len(tbl.hashes); i++ {
+		if tbl.hashes[i-1] >= tbl.hashes[i] {
+			t.Fatalf("hashes not sorted: %x", tbl.hashes)
+		}
+	}
+}
+
+func TestTableCancelDoesNotAbortSharedLoad(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		started <- struct{}{}
+		<-release
+		w.Write([]byte(tableText(testPaths...)))
+	}))
+	defer srv.Close()
+	unblock := sync.OnceFunc(func() { close(release) })
+	defer unblock() // Antes de srv.Close, que espera a los handlers
+	m := NewManager(t.TempDir())
+	m.SetLocation(srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := m.Table(ctx)
+		first <- err
+	}()
+	<-started
+	second := make(chan *Table, 1)
+	go func() {
+		tbl, _ := m.Table(context.Background())
+		second <- tbl
+	}()
+	cancel()
+	select {
+	case err := <-first:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("cancelled caller err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled caller did not return")
+	}
+
+	unblock()
+	select {
+	case tbl := <-second:
+		if tbl.Len() != len(testPaths) {
+			t.Fatalf("other caller got %d paths, want %d", tbl.Len(), len(testPaths))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("other caller did not get the table")
+	}
+	if tbl, err := m.Table(context.Background()); err != nil || tbl.Len() != len(testPaths) || requests.Load() != 1 {
+		t.Errorf("after shared load: %v, %d requests", err, requests.Load())
+	}
+}
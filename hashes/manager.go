@@ -0,0 +1,347 @@
+// skinhunter/hashes/manager.go
+package hashes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gestión de las tablas: se descargan de una ubicación configurable (URL http(s) o
+// directorio local) a la caché del usuario y se vuelven a pedir como mucho una vez
+// cada UpdateInterval. Las actualizaciones son incrementales por fichero: con
+// ETag/Last-Modified (o tamaño y fecha en un directorio) solo se baja lo que cambió.
+
+// DefaultLocation es la carpeta de tablas de CommunityDragon.
+const DefaultLocation = "https://raw.communitydragon.org/data/hashes/lol/"
+
+// DefaultFiles son las tablas que se cargan por defecto.
+var DefaultFiles = []string{"hashes.game.txt"}
+
+// BinFiles son las tablas de nombres de los .bin (FNV-1a de 32 bits): entradas, campos,
+// valores hash y clases.
+var BinFiles = []string{"hashes.binentries.txt", "hashes.binfields.txt", "hashes.binhashes.txt", "hashes.bintypes.txt"}
+
+// UpdateInterval es lo que se usa la caché antes de comprobar si hay tablas nuevas.
+const UpdateInterval = 24 * time.Hour
+
+// ErrNoTables indica que no hay tablas en caché y no se han podido descargar.
+var ErrNoTables = errors.New("hash tables not available")
+
+// UserMessage explica al usuario que faltan las tablas de hashes.
+func UserMessage(err error) (msg string, ok bool) {
+	if errors.Is(err, ErrNoTables) {
+		return "The hash tables couldn't be downloaded, so file names can't be shown. Check the hash tables location in Settings or your connection.", true
+	}
+	return "", false
+}
+
+// fileMeta es lo que se guarda junto a cada fichero en caché (<fichero>.meta).
+type fileMeta struct {
+	Location     string    `json:"location"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Size         int64     `json:"size,omitempty"`    // Solo ubicaciones locales
+	ModTime      time.Time `json:"modTime,omitempty"` // Solo ubicaciones locales
+	CheckedAt    time.Time `json:"checkedAt"`
+}
+
+// Manager es seguro para uso concurrente.
+type Manager struct {
+	dir    string // Caché de las tablas
+	client *http.Client
+
+	// loadTimeout limita la carga compartida de Table, que no depende del ctx de nadie.
+	loadTimeout time.Duration
+
+	mu       sync.Mutex
+	location string
+	files    []string
+	table    *Table
+	loading  *loadCall // != nil mientras hay una carga en curso
+}
+
+// loadCall es una carga de Table compartida por todos los que la esperan.
+type loadCall struct {
+	done  chan struct{} // Se cierra al terminar; entonces table y err son definitivos
+	table *Table
+	err   error
+}
+
+// Shared guarda las tablas de rutas en <UserCacheDir>/skinhunter/hashes y SharedBin
+// las de nombres de los .bin en <UserCacheDir>/skinhunter/hashes/bin.
+var Shared, SharedBin *Manager
+
+func init() {
+	dir := ""
+	if base, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(base, "skinhunter", "hashes")
+	} else {
+		dir = filepath.Join(os.TempDir(), "skinhunter-hashes")
+	}
+	Shared = NewManager(dir)
+	SharedBin = NewManager(filepath.Join(dir, "bin"))
+	SharedBin.SetFiles(BinFiles...)
+}
+
+// NewManager crea un gestor con caché en dir, la ubicación por defecto y DefaultFiles.
+func NewManager(dir string) *Manager {
+	return &Manager{
+		dir:         dir,
+		client:      &http.Client{Timeout: 10 * time.Minute},
+		loadTimeout: 15 * time.Minute,
+		location:    DefaultLocation,
+		files:       DefaultFiles,
+	}
+}
+
+// SetLocation cambia la ubicación de las tablas ("" = DefaultLocation) y, si cambia,
+// descarta la tabla en memoria.
+func (m *Manager) SetLocation(loc string) {
+	loc = strings.TrimSpace(loc)
+	if loc == "" {
+		loc = DefaultLocation
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if loc != m.location {
+		m.location = loc
+		m.table, m.loading = nil, nil
+	}
+}
+
+// Location devuelve la ubicación configurada.
+func (m *Manager) Location() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.location
+}
+
+// SetFiles cambia las tablas que se cargan (nombres relativos a la ubicación).
+func (m *Manager) SetFiles(files ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files = append([]string(nil), files...)
+	m.table, m.loading = nil, nil
+}
+
+// Table devuelve la tabla en memoria o la carga: actualiza los ficheros que lleven
+// más de UpdateInterval sin comprobarse y los lee de la caché. Si no se puede
+// actualizar pero hay caché, se usa la caché. La carga se comparte entre quienes
+// llaman a la vez y no se corta si uno cancela su ctx: ese solo deja de esperar.
+func (m *Manager) Table(ctx context.Context) (*Table, error) {
+	m.mu.Lock()
+	if m.table != nil {
+		t := m.table
+		m.mu.Unlock()
+		return t, nil
+	}
+	call := m.loading
+	if call == nil {
+		call = &loadCall{done: make(chan struct{})}
+		m.loading = call
+		go m.loadShared(context.WithoutCancel(ctx), call)
+	}
+	m.mu.Unlock()
+	select {
+	case <-call.done:
+		return call.table, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadShared hace la carga de call con el límite de loadTimeout. El resultado solo se
+// guarda si la ubicación y las tablas no han cambiado entretanto; un error no se
+// guarda, de modo que el siguiente Table vuelve a intentarlo.
+func (m *Manager) loadShared(ctx context.Context, call *loadCall) {
+	ctx, cancel := context.WithTimeout(ctx, m.loadTimeout)
+	defer cancel()
+	call.table, call.err = m.load(ctx, false)
+	m.mu.Lock()
+	if m.loading == call {
+		m.loading = nil
+		if call.err == nil {
+			m.table = call.table
+		}
+	}
+	m.mu.Unlock()
+	close(call.done)
+}
+
+// Update comprueba ahora todas las tablas, baja las que hayan cambiado y recarga la
+// tabla en memoria.
+func (m *Manager) Update(ctx context.Context) (*Table, error) {
+	t, err := m.load(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.table = t
+	m.mu.Unlock()
+	return t, nil
+}
+
+func (m *Manager) load(ctx context.Context, force bool) (*Table, error) {
+	m.mu.Lock()
+	loc, files := m.location, m.files
+	m.mu.Unlock()
+	if len(files) == 0 {
+		return nil, ErrNoTables
+	}
+	var tables []*Table
+	for _, name := range files {
+		cached := filepath.Join(m.dir, filepath.Base(name))
+		if err := m.refresh(ctx, loc, name, cached, force); err != nil {
+			if _, statErr := os.Stat(cached); statErr != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrNoTables, name, err)
+			}
+			log.Printf("WARN: Hashes: could not update %s, using cached copy: %v", name, err)
+		}
+		f, err := os.Open(cached)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoTables, err)
+		}
+		t, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		tables = append(tables, t)
+	}
+	t := tables[0]
+	if len(tables) > 1 {
+		t = Merge(tables...)
+	}
+	log.Printf("Hashes: loaded %d paths from %s", t.Len(), loc)
+	return t, nil
+}
+
+// refresh trae name de loc a cached si cambió desde la última vez. Sin force no hace
+// nada si se comprobó hace menos de UpdateInterval.
+func (m *Manager) refresh(ctx context.Context, loc, name, cached string, force bool) error {
+	metaPath := cached + ".meta"
+	var meta fileMeta
+	if raw, err := os.ReadFile(metaPath); err == nil {
+		json.Unmarshal(raw, &meta)
+	}
+	if _, err := os.Stat(cached); err != nil || meta.Location != loc {
+		meta = fileMeta{} // Sin copia válida de esta ubicación: se baja entera
+	}
+	if !force && meta.Location != "" && time.Since(meta.CheckedAt) < UpdateInterval {
+		return nil
+	}
+
+	var body io.ReadCloser
+	var err error
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		body, err = m.fetchHTTP(ctx, loc, name, &meta)
+	} else {
+		body, err = fetchLocal(loc, name, &meta)
+	}
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		err = writeAtomic(cached, body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		log.Printf("Hashes: updated %s from %s", name, loc)
+	}
+	meta.Location, meta.CheckedAt = loc, time.Now()
+	raw, _ := json.Marshal(meta)
+	return os.WriteFile(metaPath, raw, 0o644)
+}
+
+// fetchHTTP pide name con If-None-Match/If-Modified-Since. Devuelve nil si no cambió.
+func (m *Manager) fetchHTTP(ctx context.Context, loc, name string, meta *fileMeta) (io.ReadCloser, error) {
+	base, err := url.Parse(strings.TrimSuffix(loc, "/") + "/")
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	u := base.ResolveReference(ref).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		resp.Body.Close()
+		return nil, nil
+	case http.StatusOK:
+		meta.ETag, meta.LastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		return resp.Body, nil
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("bad status %s for %s", resp.Status, u)
+}
+
+// fetchLocal abre loc/name si su tamaño o fecha cambiaron. Devuelve nil si no cambió.
+func fetchLocal(loc, name string, meta *fileMeta) (io.ReadCloser, error) {
+	p := filepath.Join(loc, filepath.FromSlash(name))
+	st, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Size == st.Size() && meta.ModTime.Equal(st.ModTime()) {
+		return nil, nil
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	meta.Size, meta.ModTime = st.Size(), st.ModTime()
+	return f, nil
+}
+
+func writeAtomic(dest string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".hashes-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// --- End of manager.go ---
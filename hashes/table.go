@@ -0,0 +1,172 @@
+// skinhunter/hashes/table.go
+package hashes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"skinhunter/wad"
+)
+
+// Tablas hash -> ruta de los WAD, en el formato de CommunityDragon (hashes.game.txt):
+// una línea por ruta con el hash en hex, un espacio y la ruta.
+//
+//	0a1b2c3d4e5f6071 assets/characters/ahri/skins/skin15/ahri_skin15_tx_cm.tex
+//
+// En memoria se guardan los hashes ordenados en un slice y las rutas concatenadas en
+// un único string, con el final de cada una en ends: ~12 bytes por entrada además del
+// texto, en lugar de un map con un string por ruta.
+
+// Table es una tabla de rutas conocidas. Es inmutable y segura para uso concurrente.
+type Table struct {
+	hashes []uint64 // Ordenados
+	ends   []uint32 // ends[i] = fin de la ruta de hashes[i] en blob
+	blob   string
+}
+
+// Parse lee una tabla en formato hashes.game.txt. Las líneas vacías se ignoran; una
+// línea mal formada es un error. Las rutas se van escribiendo directamente en blob;
+// si el fichero no viene ordenado por hash se ordena al final.
+func Parse(r io.Reader) (*Table, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	t := &Table{}
+	var b strings.Builder
+	sorted := true
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		hexHash, p, ok := strings.Cut(line, " ")
+		h, err := strconv.ParseUint(hexHash, 16, 64)
+		if !ok || err != nil || p == "" {
+			return nil, fmt.Errorf("hash table line %d: malformed %q", n, truncate(line, 60))
+		}
+		if last := len(t.hashes) - 1; last >= 0 && h <= t.hashes[last] {
+			sorted = false
+		}
+		b.WriteString(p)
+		t.hashes = append(t.hashes, h)
+		t.ends = append(t.ends, uint32(b.Len()))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	t.blob = b.String()
+	if !sorted {
+		t = t.sorted()
+	}
+	return t, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// sorted devuelve una copia de t ordenada por hash; si un hash se repite gana la primera
+// ruta. Solo se ordenan índices, sin un string por ruta.
+func (t *Table) sorted() *Table {
+	idx := make([]uint32, len(t.hashes))
+	for i := range idx {
+		idx[i] = uint32(i)
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return t.hashes[idx[a]] < t.hashes[idx[b]] })
+	out := &Table{hashes: make([]uint64, 0, len(idx)), ends: make([]uint32, 0, len(idx))}
+	var b strings.Builder
+	b.Grow(len(t.blob))
+	for k, i := range idx {
+		if k > 0 && t.hashes[i] == t.hashes[idx[k-1]] {
+			continue
+		}
+		b.WriteString(t.pathAt(int(i)))
+		out.hashes = append(out.hashes, t.hashes[i])
+		out.ends = append(out.ends, uint32(b.Len()))
+	}
+	out.blob = b.String()
+	return out
+}
+
+// Merge une varias tablas (p. ej. los ficheros partidos de CommunityDragon). Si un hash
+// está en varias gana la primera tabla que lo tiene.
+func Merge(tables ...*Table) *Table {
+	all := &Table{}
+	var b strings.Builder
+	for _, t := range tables {
+		if t == nil {
+			continue
+		}
+		base := uint32(b.Len())
+		b.WriteString(t.blob)
+		all.hashes = append(all.hashes, t.hashes...)
+		for _, end := range t.ends {
+			all.ends = append(all.ends, base+end)
+		}
+	}
+	all.blob = b.String()
+	return all.sorted()
+}
+
+// Len devuelve el número de rutas conocidas.
+func (t *Table) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.hashes)
+}
+
+func (t *Table) pathAt(i int) string {
+	start := uint32(0)
+	if i > 0 {
+		start = t.ends[i-1]
+	}
+	return t.blob[start:t.ends[i]]
+}
+
+// Path devuelve la ruta de h, si se conoce.
+func (t *Table) Path(h uint64) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	i := sort.Search(len(t.hashes), func(i int) bool { return t.hashes[i] >= h })
+	if i == len(t.hashes) || t.hashes[i] != h {
+		return "", false
+	}
+	return t.pathAt(i), true
+}
+
+// Hash devuelve el hash de path (wad.HashPath) e indica si la tabla lo conoce.
+func (t *Table) Hash(path string) (uint64, bool) {
+	h := wad.HashPath(path)
+	_, ok := t.Path(h)
+	return h, ok
+}
+
+// Name devuelve la ruta de h o, si no se conoce, el hash en hex como lo escribe
+// CommunityDragon.
+func (t *Table) Name(h uint64) string {
+	if p, ok := t.Path(h); ok {
+		return p
+	}
+	return fmt.Sprintf("%016x", h)
+}
+
+// Unknown devuelve los hashes de hs que la tabla no conoce, en el mismo orden.
+func (t *Table) Unknown(hs []uint64) []uint64 {
+	var out []uint64
+	for _, h := range hs {
+		if _, ok := t.Path(h); !ok {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// --- End of table.go ---
@@ -0,0 +1,422 @@
+// skinhunter/imagecache/imagecache.go
+package imagecache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Caché compartida de imágenes (tiles, retratos, gemas de rareza, iconos, chromas...).
+//
+// Dos niveles:
+//   - disco: los bytes se guardan por hash de contenido en blobs/<sha256> y un índice
+//     urls/<sha256(url)> apunta al blob. Así dos URLs con la misma imagen comparten fichero.
+//     El tamaño total de blobs/ se limita a un presupuesto; se expulsan los menos usados (mtime).
+//   - memoria: LRU de imágenes ya decodificadas, limitada por w*h*4 bytes.
+
+const (
+	DefaultDiskBudget int64 = 256 << 20
+	DefaultMemBudget  int64 = 96 << 20
+)
+
+type memEntry struct {
+	url   string
+	img   image.Image
+	bytes int64
+}
+
+// inflightCall es una carga compartida por todos los Get concurrentes de una URL.
+// Tiene su propio contexto: solo se cancela cuando ya no queda nadie esperando.
+type inflightCall struct {
+	done    chan struct{}
+	img     image.Image
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Cache es segura para uso concurrente.
+type Cache struct {
+	dir        string
+	client     *http.Client
+	diskBudget int64
+	memBudget  int64
+
+	mu       sync.Mutex
+	lru      *list.List // Front = más reciente
+	items    map[string]*list.Element
+	memBytes int64
+	inflight map[string]*inflightCall
+
+	diskMu         sync.Mutex // Serializa escrituras y expulsiones en disco
+	diskUsage      int64      // Bytes en blobs/, válido si diskUsageKnown
+	diskUsageKnown bool
+}
+
+// Shared es la caché que usa toda la UI.
+var Shared *Cache
+
+func init() {
+	dir := ""
+	if base, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(base, "skinhunter", "images")
+	} else {
+		log.Printf("WARN: No user cache dir, image disk cache disabled: %v", err)
+	}
+	Shared = New(dir, DefaultDiskBudget, DefaultMemBudget)
+}
+
+// New crea una caché con directorio dir ("" = solo memoria).
+func New(dir string, diskBudget, memBudget int64) *Cache {
+	return &Cache{
+		dir:        dir,
+		client:     &http.Client{Timeout: 20 * time.Second},
+		diskBudget: diskBudget,
+		memBudget:  memBudget,
+		lru:        list.New(),
+		items:      make(map[string]*list.Element),
+		inflight:   make(map[string]*inflightCall),
+	}
+}
+
+// SetDiskBudget cambia el tamaño máximo en disco y expulsa lo que sobre.
+func (c *Cache) SetDiskBudget(budget int64) {
+	c.mu.Lock()
+	c.diskBudget = budget
+	c.mu.Unlock()
+	c.evictDisk()
+}
+
+// DiskBudget devuelve el presupuesto de disco actual.
+func (c *Cache) DiskBudget() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diskBudget
+}
+
+// Get devuelve la imagen decodificada de rawURL (http(s), file:// o data:),
+// desde memoria, disco o red en ese orden. Peticiones concurrentes de la
+// misma URL comparten una única descarga. Si ctx se cancela Get vuelve con
+// ctx.Err(); la descarga solo se aborta si nadie más la está esperando.
+func (c *Cache) Get(ctx context.Context, rawURL string) (image.Image, error) {
+	c.mu.Lock()
+	if el, ok := c.items[rawURL]; ok {
+		c.lru.MoveToFront(el)
+		img := el.Value.(*memEntry).img
+		c.mu.Unlock()
+		return img, nil
+	}
+	call, ok := c.inflight[rawURL]
+	if ok {
+		call.waiters++
+	} else {
+		loadCtx, cancel := context.WithCancel(context.Background())
+		call = &inflightCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		c.inflight[rawURL] = call
+		go c.runLoad(loadCtx, rawURL, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.img, call.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			if c.inflight[rawURL] == call {
+				delete(c.inflight, rawURL) // El siguiente Get empieza una carga nueva
+			}
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Cache) runLoad(ctx context.Context, rawURL string, call *inflightCall) {
+	img, err := c.load(ctx, rawURL)
+	call.cancel()
+	c.mu.Lock()
+	if c.inflight[rawURL] == call {
+		delete(c.inflight, rawURL)
+	}
+	if err == nil {
+		c.addMemLocked(rawURL, img)
+	}
+	call.img, call.err = img, err
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// Peek devuelve la imagen solo si ya está decodificada en memoria.
+func (c *Cache) Peek(rawURL string) (image.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[rawURL]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*memEntry).img, true
+	}
+	return nil, false
+}
+
+func (c *Cache) load(ctx context.Context, rawURL string) (image.Image, error) {
+	raw, err := c.loadBytes(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decode image %s: %w", rawURL, err)
+	}
+	return img, nil
+}
+
+func (c *Cache) loadBytes(ctx context.Context, rawURL string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(rawURL, "data:"):
+		return decodeDataURL(rawURL)
+	case strings.HasPrefix(rawURL, "file://"):
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		p := u.Path
+		if len(p) > 2 && p[0] == '/' && p[2] == ':' { // file:///C:/...
+			p = p[1:]
+		}
+		return os.ReadFile(filepath.FromSlash(p))
+	}
+	if b, ok := c.readDisk(rawURL); ok {
+		return b, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.writeDisk(rawURL, b)
+	return b, nil
+}
+
+func decodeDataURL(rawURL string) ([]byte, error) {
+	comma := strings.IndexByte(rawURL, ',')
+	if comma < 0 {
+		return nil, fmt.Errorf("invalid data URL")
+	}
+	meta, payload := rawURL[len("data:"):comma], rawURL[comma+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(payload)
+	}
+	s, err := url.PathUnescape(payload)
+	return []byte(s), err
+}
+
+// --- Memoria ---
+
+func (c *Cache) addMemLocked(rawURL string, img image.Image) {
+	if _, ok := c.items[rawURL]; ok {
+		return
+	}
+	b := img.Bounds()
+	size := int64(b.Dx()) * int64(b.Dy()) * 4
+	c.items[rawURL] = c.lru.PushFront(&memEntry{url: rawURL, img: img, bytes: size})
+	c.memBytes += size
+	for c.memBytes > c.memBudget && c.lru.Len() > 1 {
+		oldest := c.lru.Back()
+		e := oldest.Value.(*memEntry)
+		c.lru.Remove(oldest)
+		delete(c.items, e.url)
+		c.memBytes -= e.bytes
+	}
+}
+
+// --- Disco ---
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) indexPath(rawURL string) string {
+	return filepath.Join(c.dir, "urls", hashHex([]byte(rawURL)))
+}
+func (c *Cache) blobPath(contentHash string) string {
+	return filepath.Join(c.dir, "blobs", contentHash)
+}
+
+func (c *Cache) readDisk(rawURL string) ([]byte, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	ref, err := os.ReadFile(c.indexPath(rawURL))
+	if err != nil {
+		return nil, false
+	}
+	bp := c.blobPath(strings.TrimSpace(string(ref)))
+	b, err := os.ReadFile(bp)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(bp, now, now) // Marca de uso para la expulsión LRU
+	return b, true
+}
+
+func (c *Cache) writeDisk(rawURL string, b []byte) {
+	if c.dir == "" {
+		return
+	}
+	c.diskMu.Lock()
+	contentHash := hashHex(b)
+	for _, sub := range []string{"urls", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(c.dir, sub), 0o755); err != nil {
+			c.diskMu.Unlock()
+			log.Printf("WARN: Cannot create image cache dir: %v", err)
+			return
+		}
+	}
+	bp := c.blobPath(contentHash)
+	if _, err := os.Stat(bp); err != nil {
+		if err := writeFileAtomic(bp, b); err != nil {
+			c.diskMu.Unlock()
+			log.Printf("WARN: Cannot write image cache blob: %v", err)
+			return
+		}
+		if c.diskUsageKnown {
+			c.diskUsage += int64(len(b))
+		}
+	}
+	if err := writeFileAtomic(c.indexPath(rawURL), []byte(contentHash)); err != nil {
+		log.Printf("WARN: Cannot write image cache index: %v", err)
+	}
+	c.diskMu.Unlock()
+	c.evictDisk()
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// DiskUsage devuelve los bytes ocupados por las imágenes en disco.
+func (c *Cache) DiskUsage() int64 {
+	c.diskMu.Lock()
+	defer c.diskMu.Unlock()
+	return c.diskUsageLocked()
+}
+
+// diskUsageLocked devuelve el total acumulado; solo recorre blobs/ la primera vez.
+// Se llama con diskMu tomado.
+func (c *Cache) diskUsageLocked() int64 {
+	if !c.diskUsageKnown {
+		c.diskUsage = 0
+		for _, fi := range c.listBlobs() {
+			c.diskUsage += fi.Size()
+		}
+		c.diskUsageKnown = true
+	}
+	return c.diskUsage
+}
+
+func (c *Cache) listBlobs() []os.FileInfo {
+	if c.dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(c.dir, "blobs"))
+	if err != nil {
+		return nil
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && fi.Mode().IsRegular() && !strings.HasSuffix(fi.Name(), ".tmp") {
+			infos = append(infos, fi)
+		}
+	}
+	return infos
+}
+
+// evictDisk borra los blobs menos usados hasta quedar dentro del presupuesto.
+// Las entradas del índice que apuntan a blobs borrados se tratan como fallo de caché.
+func (c *Cache) evictDisk() {
+	c.diskMu.Lock()
+	defer c.diskMu.Unlock()
+	budget := c.DiskBudget()
+	if c.diskUsageLocked() <= budget {
+		return
+	}
+	// Solo aquí, por encima del presupuesto, se vuelve a listar el directorio.
+	blobs := c.listBlobs()
+	var total int64
+	for _, fi := range blobs {
+		total += fi.Size()
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].ModTime().Before(blobs[j].ModTime()) })
+	for _, fi := range blobs {
+		if total <= budget {
+			break
+		}
+		if err := os.Remove(c.blobPath(fi.Name())); err == nil {
+			total -= fi.Size()
+		}
+	}
+	c.diskUsage = total
+}
+
+// Clear vacía la caché en memoria y en disco.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	c.lru.Init()
+	c.items = make(map[string]*list.Element)
+	c.memBytes = 0
+	c.mu.Unlock()
+	if c.dir == "" {
+		return nil
+	}
+	c.diskMu.Lock()
+	defer c.diskMu.Unlock()
+	c.diskUsageKnown = false
+	for _, sub := range []string{"urls", "blobs"} {
+		if err := os.RemoveAll(filepath.Join(c.dir, sub)); err != nil {
+			return fmt.Errorf("clear image cache: %w", err)
+		}
+	}
+	log.Println("Image cache cleared.")
+	return nil
+}
+
+// --- End of imagecache.go ---
@@ -0,0 +1,185 @@
+package imagecache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pngBytes devuelve un PNG de w x h píxeles.
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngServer sirve un PNG de size x (size+len(ruta)) y cuenta las peticiones.
+func pngServer(t *testing.T, size int) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(pngBytes(t, size, size+len(r.URL.Path)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestMemoryLRUBudget(t *testing.T) {
+	srv, _ := pngServer(t, 10)
+	// Cada imagen ocupa ~10*12*4 bytes: caben dos.
+	c := New("", DefaultDiskBudget, 1000)
+	ctx := context.Background()
+	a, b, d := srv.URL+"/a", srv.URL+"/b", srv.URL+"/d"
+	for _, u := range []string{a, b} {
+		if _, err := c.Get(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Peek(a) // a pasa a ser la más reciente
+	if _, err := c.Get(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Peek(b); ok {
+		t.Error("least recently used image b should have been evicted")
+	}
+	for _, u := range []string{a, d} {
+		if _, ok := c.Peek(u); !ok {
+			t.Errorf("%s should still be in memory", u)
+		}
+	}
+	if c.memBytes > 1000 {
+		t.Errorf("memBytes = %d, above budget", c.memBytes)
+	}
+}
+
+func TestDiskCacheServesWithoutNetwork(t *testing.T) {
+	srv, hits := pngServer(t, 4)
+	dir := t.TempDir()
+	url := srv.URL + "/tile.png"
+	if _, err := New(dir, DefaultDiskBudget, DefaultMemBudget).Get(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	// Una caché nueva sobre el mismo directorio no toca la red.
+	if _, err := New(dir, DefaultDiskBudget, DefaultMemBudget).Get(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("server hits = %d, want 1", hits.Load())
+	}
+}
+
+func TestGetReportsHTTPErrors(t *testing.T) {
+	srv, _ := pngServer(t, 4)
+	c := New(t.TempDir(), DefaultDiskBudget, DefaultMemBudget)
+	if _, err := c.Get(context.Background(), srv.URL+"/missing.png"); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	if c.DiskUsage() != 0 {
+		t.Error("failed download should not be written to disk")
+	}
+}
+
+func TestDiskEvictionByMTime(t *testing.T) {
+	srv, _ := pngServer(t, 16)
+	dir := t.TempDir()
+	c := New(dir, DefaultDiskBudget, DefaultMemBudget)
+	ctx := context.Background()
+	urls := []string{srv.URL + "/a", srv.URL + "/bb", srv.URL + "/ccc"} // Rutas de distinta longitud = blobs distintos
+	var sizes []int64
+	for i, u := range urls {
+		if _, err := c.Get(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		ref, err := os.ReadFile(c.indexPath(u))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bp := c.blobPath(string(ref))
+		mt := time.Now().Add(time.Duration(i-len(urls)) * time.Hour)
+		if err := os.Chtimes(bp, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+		fi, _ := os.Stat(bp)
+		sizes = append(sizes, fi.Size())
+	}
+	if got, want := c.DiskUsage(), sizes[0]+sizes[1]+sizes[2]; got != want {
+		t.Fatalf("DiskUsage = %d, want %d", got, want)
+	}
+
+	// Presupuesto para las dos más recientes: se borra la de mtime más antiguo.
+	c.SetDiskBudget(sizes[1] + sizes[2])
+	if got := c.DiskUsage(); got != sizes[1]+sizes[2] {
+		t.Errorf("DiskUsage after eviction = %d, want %d", got, sizes[1]+sizes[2])
+	}
+	fresh := New(dir, DefaultDiskBudget, DefaultMemBudget)
+	if _, ok := fresh.readDisk(urls[0]); ok {
+		t.Error("oldest blob should have been evicted")
+	}
+	for _, u := range urls[1:] {
+		if _, ok := fresh.readDisk(u); !ok {
+			t.Errorf("%s should still be on disk", u)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	srv, hits := pngServer(t, 4)
+	dir := t.TempDir()
+	c := New(dir, DefaultDiskBudget, DefaultMemBudget)
+	url := srv.URL + "/x.png"
+	if _, err := c.Get(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Peek(url); ok {
+		t.Error("memory cache not cleared")
+	}
+	if u := c.DiskUsage(); u != 0 {
+		t.Errorf("DiskUsage after Clear = %d", u)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blobs")); !os.IsNotExist(err) {
+		t.Errorf("blobs dir still present: %v", err)
+	}
+	if _, err := c.Get(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server hits = %d, want 2 (reload after Clear)", hits.Load())
+	}
+}
+
+func TestDuplicateContentSharesBlob(t *testing.T) {
+	body := pngBytes(t, 8, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(body) }))
+	defer srv.Close()
+	c := New(t.TempDir(), DefaultDiskBudget, DefaultMemBudget)
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(context.Background(), fmt.Sprintf("%s/copy%d.png", srv.URL, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.DiskUsage(); got != int64(len(body)) {
+		t.Errorf("DiskUsage = %d, want one blob of %d bytes", got, len(body))
+	}
+}
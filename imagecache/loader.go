@@ -0,0 +1,129 @@
+// skinhunter/imagecache/loader.go
+package imagecache
+
+import (
+	"context"
+	"image"
+	"sync"
+)
+
+// Loader reparte las cargas de imágenes entre un número fijo de workers.
+// Cada petición lleva un contexto (se descarta si se cancela antes o durante la
+// carga) y una función de prioridad que se evalúa cada vez que un worker elige
+// el siguiente trabajo, para que lo visible en pantalla se cargue primero.
+type Loader struct {
+	cache *Cache
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []*job
+	seq   uint64
+}
+
+// Request describe una carga pendiente.
+type Request struct {
+	URL string
+	Ctx context.Context
+	// Priority devuelve la prioridad actual (mayor = antes). Debe ser barata y
+	// segura desde cualquier goroutine (p.ej. leer un atomic). nil = 0.
+	Priority func() int
+	// Done se llama desde un worker solo si Ctx no se ha cancelado.
+	Done func(img image.Image, err error)
+}
+
+type job struct {
+	req Request
+	seq uint64
+}
+
+// NewLoader arranca workers goroutines que cargan a través de cache.
+func NewLoader(cache *Cache, workers int) *Loader {
+	if workers < 1 {
+		workers = 1
+	}
+	l := &Loader{cache: cache}
+	l.cond = sync.NewCond(&l.mu)
+	for i := 0; i < workers; i++ {
+		go l.worker()
+	}
+	return l
+}
+
+// Enqueue añade una petición. Si la imagen ya está decodificada en memoria,
+// Done se llama inmediatamente sin pasar por la cola.
+func (l *Loader) Enqueue(req Request) {
+	if req.Ctx == nil {
+		req.Ctx = context.Background()
+	}
+	if req.Ctx.Err() != nil {
+		return
+	}
+	if img, ok := l.cache.Peek(req.URL); ok {
+		if req.Done != nil {
+			req.Done(img, nil)
+		}
+		return
+	}
+	l.mu.Lock()
+	l.seq++
+	l.queue = append(l.queue, &job{req: req, seq: l.seq})
+	l.mu.Unlock()
+	l.cond.Signal()
+}
+
+// Pending devuelve el número de peticiones en cola (sin contar las que están cargando).
+func (l *Loader) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queue)
+}
+
+// next saca el trabajo de mayor prioridad (FIFO entre iguales), descartando los cancelados.
+func (l *Loader) next() *job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for {
+		best, bestPrio := -1, 0
+		alive := l.queue[:0]
+		for _, j := range l.queue {
+			if j.req.Ctx.Err() != nil {
+				continue
+			}
+			alive = append(alive, j)
+		}
+		for i := len(alive); i < len(l.queue); i++ {
+			l.queue[i] = nil
+		}
+		l.queue = alive
+		for i, j := range l.queue {
+			p := 0
+			if j.req.Priority != nil {
+				p = j.req.Priority()
+			}
+			if best < 0 || p > bestPrio || (p == bestPrio && j.seq < l.queue[best].seq) {
+				best, bestPrio = i, p
+			}
+		}
+		if best >= 0 {
+			j := l.queue[best]
+			l.queue = append(l.queue[:best], l.queue[best+1:]...)
+			return j
+		}
+		l.cond.Wait()
+	}
+}
+
+func (l *Loader) worker() {
+	for {
+		j := l.next()
+		img, err := l.cache.Get(j.req.Ctx, j.req.URL)
+		if j.req.Ctx.Err() != nil {
+			continue
+		}
+		if j.req.Done != nil {
+			j.req.Done(img, err)
+		}
+	}
+}
+
+// --- End of loader.go ---
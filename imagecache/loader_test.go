@@ -0,0 +1,177 @@
+package imagecache
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dataURL devuelve una data: URL distinta para cada name con el mismo PNG.
+func dataURL(t *testing.T, name string) string {
+	return fmt.Sprintf("data:image/png;name=%s;base64,%s", name, base64.StdEncoding.EncodeToString(pngBytes(t, 2, 2)))
+}
+
+// gateServer sirve un PNG en cada ruta, pero no responde hasta que se cierra release.
+type gateServer struct {
+	*httptest.Server
+	release  chan struct{}
+	requests atomic.Int32
+	started  chan string
+}
+
+func newGateServer(t *testing.T) *gateServer {
+	g := &gateServer{release: make(chan struct{}), started: make(chan string, 16)}
+	body := pngBytes(t, 4, 4)
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.requests.Add(1)
+		g.started <- r.URL.Path
+		select {
+		case <-g.release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(func() {
+		select {
+		case <-g.release:
+		default:
+			close(g.release)
+		}
+		g.Close()
+	})
+	return g
+}
+
+func waitStarted(t *testing.T, g *gateServer) string {
+	t.Helper()
+	select {
+	case p := <-g.started:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the server")
+		return ""
+	}
+}
+
+func TestLoaderPriorityOrder(t *testing.T) {
+	g := newGateServer(t)
+	l := NewLoader(New("", DefaultDiskBudget, DefaultMemBudget), 1)
+
+	// Ocupa el único worker.
+	l.Enqueue(Request{URL: g.URL + "/blocker"})
+	waitStarted(t, g)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(name string, prio int) {
+		wg.Add(1)
+		l.Enqueue(Request{
+			URL:      dataURL(t, name),
+			Priority: func() int { return prio },
+			Done: func(image.Image, error) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				wg.Done()
+			},
+		})
+	}
+	enqueue("off1", 0)
+	enqueue("near", 1)
+	enqueue("off2", 0)
+	enqueue("visible", 2)
+	close(g.release)
+	wg.Wait()
+
+	want := []string{"visible", "near", "off1", "off2"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestLoaderDiscardsCancelledJobs(t *testing.T) {
+	g := newGateServer(t)
+	l := NewLoader(New("", DefaultDiskBudget, DefaultMemBudget), 1)
+	l.Enqueue(Request{URL: g.URL + "/blocker"})
+	waitStarted(t, g)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var called atomic.Bool
+	l.Enqueue(Request{URL: dataURL(t, "cancelled"), Ctx: ctx, Done: func(image.Image, error) { called.Store(true) }})
+	cancel()
+
+	done := make(chan struct{})
+	l.Enqueue(Request{URL: dataURL(t, "alive"), Done: func(image.Image, error) { close(done) }})
+	close(g.release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("live job never completed")
+	}
+	if called.Load() {
+		t.Error("Done was called for a cancelled job")
+	}
+	if n := l.Pending(); n != 0 {
+		t.Errorf("Pending = %d, want 0", n)
+	}
+}
+
+func TestGetSharesInflightDownload(t *testing.T) {
+	g := newGateServer(t)
+	c := New("", DefaultDiskBudget, DefaultMemBudget)
+	url := g.URL + "/shared.png"
+
+	// Un waiter que se cancela no aborta la descarga del otro.
+	cancelled, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 2)
+	go func() { _, err := c.Get(cancelled, url); errc <- err }()
+	waitStarted(t, g)
+	go func() { _, err := c.Get(context.Background(), url); errc <- err }()
+	time.Sleep(20 * time.Millisecond) // Deja que el segundo Get se una a la carga
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("cancelled Get returned %v, want context.Canceled", err)
+	}
+	close(g.release)
+	if err := <-errc; err != nil {
+		t.Fatalf("shared Get failed: %v", err)
+	}
+	if n := g.requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+	if _, ok := c.Peek(url); !ok {
+		t.Error("image not cached in memory after load")
+	}
+}
+
+func TestGetCancelsDownloadWithoutWaiters(t *testing.T) {
+	g := newGateServer(t)
+	c := New("", DefaultDiskBudget, DefaultMemBudget)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { _, err := c.Get(ctx, g.URL+"/stale.png"); errc <- err }()
+	waitStarted(t, g)
+	start := time.Now()
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("Get returned %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Get did not return promptly after cancel")
+	}
+	c.mu.Lock()
+	n := len(c.inflight)
+	c.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d in-flight loads left after the only waiter cancelled", n)
+	}
+}
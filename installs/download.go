@@ -0,0 +1,418 @@
+// skinhunter/installs/download.go
+package installs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Descarga de paquetes de skins desde un repositorio configurable.
+//
+// El repositorio es una URL http(s) o un directorio local con un manifest.json:
+//
+//	{"version": 1, "packages": {"103015": {"path": "103/103015.fantome", "sha256": "...", "size": 123}}}
+//
+// Las claves son IDs de skin o de chroma y path es relativo a la raíz del
+// repositorio. El paquete se descarga a un temporal mientras se calcula su SHA-256;
+// solo si coincide con el del manifest se mueve a su sitio y se registra.
+
+var (
+	// ErrNoRepository indica que no hay repositorio de paquetes configurado.
+	ErrNoRepository = errors.New("no package repository configured")
+	// ErrPackageNotFound indica que el manifest no tiene paquete para ese ID.
+	ErrPackageNotFound = errors.New("no package for this skin")
+	// ErrDownloadInProgress indica que ese ID ya se está descargando.
+	ErrDownloadInProgress = errors.New("download already in progress")
+	// ErrSizeMismatch indica que el paquete descargado no mide lo que dice el manifest.
+	ErrSizeMismatch = errors.New("package size mismatch")
+)
+
+// ManifestPackage es la entrada del manifest de un paquete.
+type ManifestPackage struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size,omitempty"`
+}
+
+// Manifest es el índice de paquetes del repositorio.
+type Manifest struct {
+	Version  int                        `json:"version"`
+	Packages map[string]ManifestPackage `json:"packages"`
+}
+
+// Item identifica lo que se quiere instalar.
+type Item struct {
+	ChampionID int
+	SkinID     int
+	ChromaID   int // 0 = la skin sin chroma
+	Name       string
+	Replace    bool // Sustituir lo instalado del campeón en vez de fallar con ErrChampionConflict
+}
+
+// entry devuelve la entrada que tendrá it una vez instalado (sin paquete).
+func (it Item) entry() Entry {
+	return Entry{ChampionID: it.ChampionID, SkinID: it.SkinID, ChromaID: it.ChromaID, Name: it.Name}
+}
+
+// ItemID es el ID del paquete: el del chroma si lo hay, si no el de la skin.
+func (it Item) ItemID() int {
+	if it.ChromaID != 0 {
+		return it.ChromaID
+	}
+	return it.SkinID
+}
+
+// Progress es el avance de una descarga. Total es 0 si no se conoce el tamaño.
+type Progress struct {
+	Downloaded int64
+	Total      int64
+}
+
+// Fraction devuelve el avance entre 0 y 1, o -1 si no se conoce el total.
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Downloaded) / float64(p.Total)
+}
+
+// DownloadEvent se envía a los suscriptores del Downloader durante una descarga.
+// Done es true en el último evento, con Err a nil si terminó bien.
+type DownloadEvent struct {
+	Item     Item
+	Progress Progress
+	Done     bool
+	Err      error
+}
+
+const (
+	manifestTTL      = 10 * time.Minute
+	progressInterval = 100 * time.Millisecond
+)
+
+// Downloader es seguro para uso concurrente.
+type Downloader struct {
+	dir      string // Donde se guardan los paquetes instalados
+	registry *Registry
+	client   *http.Client
+
+	mu         sync.Mutex
+	repo       string
+	manifest   *Manifest
+	manifestAt time.Time
+	active     map[int]bool
+	listeners  map[int]func(DownloadEvent)
+	nextID     int
+}
+
+// SharedDownloader guarda los paquetes en <UserConfigDir>/skinhunter/packages y los
+// registra en Shared; lo crea OpenShared. El repositorio se configura con SetRepository.
+var SharedDownloader *Downloader
+
+func newSharedDownloader(registry *Registry) *Downloader {
+	dir := ""
+	if base, err := os.UserConfigDir(); err == nil {
+		dir = filepath.Join(base, "skinhunter", "packages")
+	} else {
+		dir = filepath.Join(os.TempDir(), "skinhunter-packages")
+	}
+	return NewDownloader(dir, registry)
+}
+
+// NewDownloader crea un descargador que guarda en dir y registra en registry.
+func NewDownloader(dir string, registry *Registry) *Downloader {
+	return &Downloader{
+		dir:       dir,
+		registry:  registry,
+		client:    &http.Client{Timeout: 10 * time.Minute},
+		active:    make(map[int]bool),
+		listeners: make(map[int]func(DownloadEvent)),
+	}
+}
+
+// SetRepository cambia el repositorio (URL http(s) o directorio; "" lo desactiva)
+// y descarta el manifest en memoria.
+func (d *Downloader) SetRepository(repo string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	repo = strings.TrimSpace(repo)
+	if repo != d.repo {
+		d.repo = repo
+		d.manifest = nil
+	}
+}
+
+// Dir devuelve el directorio donde se guardan los paquetes.
+func (d *Downloader) Dir() string { return d.dir }
+
+// Repository devuelve el repositorio configurado.
+func (d *Downloader) Repository() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.repo
+}
+
+// Subscribe registra fn para recibir los eventos de todas las descargas (desde la
+// goroutine que descarga). La función devuelta da de baja fn.
+func (d *Downloader) Subscribe(fn func(DownloadEvent)) (remove func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id := d.nextID
+	d.nextID++
+	d.listeners[id] = fn
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.listeners, id)
+	}
+}
+
+func (d *Downloader) emit(ev DownloadEvent) {
+	d.mu.Lock()
+	listeners := make([]func(DownloadEvent), 0, len(d.listeners))
+	for _, fn := range d.listeners {
+		listeners = append(listeners, fn)
+	}
+	d.mu.Unlock()
+	for _, fn := range listeners {
+		fn(ev)
+	}
+}
+
+// Download descarga el paquete de item, verifica su SHA-256 y lo registra. Falla sin
+// descargar nada si no se cumple la precondición del registro o, salvo con
+// item.Replace, si ya hay otra skin del campeón (ErrChampionConflict).
+// onProgress (puede ser nil) se llama desde esta goroutine como mucho cada 100 ms.
+func (d *Downloader) Download(ctx context.Context, item Item, onProgress func(Progress)) (Entry, error) {
+	if err := d.registry.CheckReady(); err != nil {
+		return Entry{}, err
+	}
+	if c := d.registry.Conflicts(item.entry()); len(c) > 0 && !item.Replace {
+		return Entry{}, ConflictError(c)
+	}
+	id := item.ItemID()
+	d.mu.Lock()
+	if d.active[id] {
+		d.mu.Unlock()
+		return Entry{}, fmt.Errorf("%w: %d", ErrDownloadInProgress, id)
+	}
+	d.active[id] = true
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.active, id)
+		d.mu.Unlock()
+	}()
+
+	report := func(p Progress) {
+		if onProgress != nil {
+			onProgress(p)
+		}
+		d.emit(DownloadEvent{Item: item, Progress: p})
+	}
+	entry, err := d.download(ctx, item, report)
+	d.emit(DownloadEvent{Item: item, Done: true, Err: err})
+	if err != nil {
+		log.Printf("Download %s (ID %d) failed: %v", item.Name, id, err)
+	}
+	return entry, err
+}
+
+func (d *Downloader) download(ctx context.Context, item Item, report func(Progress)) (Entry, error) {
+	id := item.ItemID()
+	m, err := d.Manifest(ctx)
+	if err != nil {
+		return Entry{}, err
+	}
+	pkg, ok := m.Packages[strconv.Itoa(id)]
+	if !ok || pkg.Path == "" {
+		return Entry{}, fmt.Errorf("%w: %d", ErrPackageNotFound, id)
+	}
+	body, size, err := d.open(ctx, pkg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Entry{}, fmt.Errorf("%w: %v", ErrPackageNotFound, err)
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	defer body.Close()
+	if size <= 0 {
+		size = pkg.Size
+	}
+
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return Entry{}, err
+	}
+	tmp, err := os.CreateTemp(d.dir, ".download-*")
+	if err != nil {
+		return Entry{}, err
+	}
+	defer os.Remove(tmp.Name()) // No-op tras el rename
+
+	h := sha256.New()
+	pr := &progressReader{r: body, report: report, total: size}
+	var src io.Reader = pr
+	if pkg.Size > 0 {
+		src = io.LimitReader(pr, pkg.Size+1) // Un byte de más basta para saber que sobra
+	}
+	_, err = io.Copy(io.MultiWriter(tmp, h), src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if ctx.Err() != nil {
+		return Entry{}, ctx.Err()
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("download %s: %w", pkg.Path, err)
+	}
+	report(Progress{Downloaded: pr.n, Total: size})
+
+	if pkg.Size > 0 && pr.n != pkg.Size {
+		return Entry{}, fmt.Errorf("%w: %s is %d bytes, manifest says %d", ErrSizeMismatch, pkg.Path, pr.n, pkg.Size)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(sum, pkg.SHA256) {
+		return Entry{}, fmt.Errorf("%w: %s (got %s, manifest %s)", ErrChecksumMismatch, pkg.Path, sum, pkg.SHA256)
+	}
+
+	// Cada descarga va a un nombre nuevo (<champ>/<id>-<aleatorio><ext>): si el
+	// registro la rechaza solo se borra ese fichero, nunca el paquete de una
+	// instalación anterior del mismo ID. El registro borra el viejo al sustituirlo.
+	champDir := filepath.Join(d.dir, strconv.Itoa(item.ChampionID))
+	if err := os.MkdirAll(champDir, 0o755); err != nil {
+		return Entry{}, err
+	}
+	slot, err := os.CreateTemp(champDir, strconv.Itoa(id)+"-*"+path.Ext(pkg.Path))
+	if err != nil {
+		return Entry{}, err
+	}
+	final := slot.Name()
+	slot.Close()
+	if err := os.Rename(tmp.Name(), final); err != nil {
+		os.Remove(final)
+		return Entry{}, err
+	}
+	entry := item.entry()
+	entry.PackagePath, entry.Checksum = final, sum
+	add := d.registry.Add
+	if item.Replace {
+		add = d.registry.Replace
+	}
+	if err := add(entry); err != nil {
+		os.Remove(final)
+		return Entry{}, err
+	}
+	entry, _ = d.registry.Get(id)
+	log.Printf("Downloaded %s (ID %d) to %s", item.Name, id, final)
+	return entry, nil
+}
+
+// Manifest devuelve el manifest del repositorio; se reutiliza durante manifestTTL.
+func (d *Downloader) Manifest(ctx context.Context) (*Manifest, error) {
+	d.mu.Lock()
+	repo, m, at := d.repo, d.manifest, d.manifestAt
+	d.mu.Unlock()
+	if repo == "" {
+		return nil, ErrNoRepository
+	}
+	if m != nil && time.Since(at) < manifestTTL {
+		return m, nil
+	}
+	body, _, err := d.open(ctx, "manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("load package manifest: %w", err)
+	}
+	defer body.Close()
+	var fresh Manifest
+	if err := json.NewDecoder(body).Decode(&fresh); err != nil {
+		return nil, fmt.Errorf("parse package manifest: %w", err)
+	}
+	d.mu.Lock()
+	if d.repo == repo {
+		d.manifest, d.manifestAt = &fresh, time.Now()
+	}
+	d.mu.Unlock()
+	return &fresh, nil
+}
+
+// open abre rel dentro del repositorio y devuelve su tamaño si se conoce.
+// Si no existe el error envuelve os.ErrNotExist.
+func (d *Downloader) open(ctx context.Context, rel string) (io.ReadCloser, int64, error) {
+	repo := d.Repository()
+	if repo == "" {
+		return nil, 0, ErrNoRepository
+	}
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		base, err := url.Parse(strings.TrimSuffix(repo, "/") + "/")
+		if err != nil {
+			return nil, 0, err
+		}
+		ref, err := url.Parse(rel)
+		if err != nil {
+			return nil, 0, err
+		}
+		u := base.ResolveReference(ref).String()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, 0, fmt.Errorf("%w: %s", os.ErrNotExist, u)
+			}
+			return nil, 0, fmt.Errorf("bad status %s for %s", resp.Status, u)
+		}
+		return resp.Body, resp.ContentLength, nil
+	}
+	p := filepath.Join(repo, filepath.FromSlash(path.Clean("/"+rel)))
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, 0, err
+	}
+	var size int64
+	if st, err := f.Stat(); err == nil {
+		size = st.Size()
+	}
+	return f, size, nil
+}
+
+// progressReader cuenta los bytes leídos y avisa a report como mucho cada progressInterval.
+type progressReader struct {
+	r      io.Reader
+	report func(Progress)
+	total  int64
+	n      int64
+	last   time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if now := time.Now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.report(Progress{Downloaded: p.n, Total: p.total})
+	}
+	return n, err
+}
+
+// --- End of download.go ---
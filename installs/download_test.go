@@ -0,0 +1,300 @@
+package installs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRepo sirve un manifest.json y los paquetes de files (ruta relativa -> contenido).
+// sums permite forzar el checksum del manifest de una ruta.
+func fakeRepo(t *testing.T, packages map[string]string, sums map[string]string) (*httptest.Server, map[string]ManifestPackage) {
+	t.Helper()
+	m := Manifest{Version: 1, Packages: map[string]ManifestPackage{}}
+	files := map[string]string{}
+	for id, content := range packages {
+		p := "pkgs/" + id + ".fantome"
+		files["/"+p] = content
+		h := sha256.Sum256([]byte(content))
+		sum := hex.EncodeToString(h[:])
+		if s, ok := sums[id]; ok {
+			sum = s
+		}
+		m.Packages[id] = ManifestPackage{Path: p, SHA256: sum, Size: int64(len(content))}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repo/manifest.json" {
+			json.NewEncoder(w).Encode(m)
+			return
+		}
+		content, ok := files[strings.TrimPrefix(r.URL.Path, "/repo")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, m.Packages
+}
+
+func newTestDownloader(t *testing.T, repo string) (*Downloader, *Registry) {
+	t.Helper()
+	dir := t.TempDir()
+	reg, err := Open(filepath.Join(dir, "installed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDownloader(filepath.Join(dir, "packages"), reg)
+	d.SetRepository(repo)
+	return d, reg
+}
+
+func TestDownloadInstallsPackage(t *testing.T) {
+	content := strings.Repeat("skin data ", 10000)
+	srv, pkgs := fakeRepo(t, map[string]string{"103015": content}, nil)
+	d, reg := newTestDownloader(t, srv.URL+"/repo")
+
+	var events []DownloadEvent
+	d.Subscribe(func(ev DownloadEvent) { events = append(events, ev) })
+	var last Progress
+	item := Item{ChampionID: 103, SkinID: 103015, Name: "Star Guardian Ahri"}
+	entry, err := d.Download(context.Background(), item, func(p Progress) { last = p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Downloaded != int64(len(content)) || last.Fraction() != 1 {
+		t.Errorf("last progress = %+v, want complete", last)
+	}
+	if entry.Checksum != pkgs["103015"].SHA256 || entry.InstalledAt.IsZero() {
+		t.Errorf("entry = %+v", entry)
+	}
+	if raw, err := os.ReadFile(entry.PackagePath); err != nil || string(raw) != content {
+		t.Fatalf("package on disk: %v", err)
+	}
+	if err := reg.Verify(103015); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if len(events) == 0 || !events[len(events)-1].Done || events[len(events)-1].Err != nil {
+		t.Errorf("events = %+v, want a final Done event without error", events)
+	}
+}
+
+func TestDownloadChromaUsesChromaID(t *testing.T) {
+	srv, _ := fakeRepo(t, map[string]string{"103016": "chroma"}, nil)
+	d, reg := newTestDownloader(t, srv.URL+"/repo")
+	if _, err := d.Download(context.Background(), Item{ChampionID: 103, SkinID: 103015, ChromaID: 103016}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reg.IsInstalled(103016) || reg.IsInstalled(103015) {
+		t.Errorf("chroma should be registered under its own ID")
+	}
+}
+
+func TestDownloadErrors(t *testing.T) {
+	srv, _ := fakeRepo(t, map[string]string{"1001": "annie", "1002": "bad"}, map[string]string{"1002": strings.Repeat("0", 64)})
+	tests := []struct {
+		name string
+		repo string
+		id   int
+		want error
+	}{
+		{"checksum mismatch", srv.URL + "/repo", 1002, ErrChecksumMismatch},
+		{"not in manifest", srv.URL + "/repo", 1003, ErrPackageNotFound},
+		{"no repository", "", 1001, ErrNoRepository},
+		{"missing manifest", srv.URL + "/nowhere", 1001, os.ErrNotExist},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, reg := newTestDownloader(t, tc.repo)
+			_, err := d.Download(context.Background(), Item{SkinID: tc.id}, nil)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+			if reg.IsInstalled(tc.id) {
+				t.Errorf("failed download was registered")
+			}
+			if files, _ := filepath.Glob(filepath.Join(d.dir, "*", "*")); len(files) != 0 {
+				t.Errorf("files left behind: %v", files)
+			}
+			if tmps, _ := filepath.Glob(filepath.Join(d.dir, ".download-*")); len(tmps) != 0 {
+				t.Errorf("temp files left behind: %v", tmps)
+			}
+		})
+	}
+}
+
+func TestDownloadMissingPackageFile(t *testing.T) {
+	srv, _ := fakeRepo(t, map[string]string{"1001": "annie"}, nil)
+	d, _ := newTestDownloader(t, srv.URL+"/repo")
+	m, err := d.Manifest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Packages["1005"] = ManifestPackage{Path: "pkgs/gone.fantome", SHA256: "x"}
+	if _, err := d.Download(context.Background(), Item{SkinID: 1005}, nil); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("err = %v, want ErrPackageNotFound", err)
+	}
+}
+
+func TestDownloadFromLocalDirectory(t *testing.T) {
+	repo := t.TempDir()
+	content := "local package"
+	h := sha256.Sum256([]byte(content))
+	os.MkdirAll(filepath.Join(repo, "1"), 0o755)
+	os.WriteFile(filepath.Join(repo, "1", "1001.fantome"), []byte(content), 0o644)
+	raw, _ := json.Marshal(Manifest{Version: 1, Packages: map[string]ManifestPackage{"1001": {Path: "1/1001.fantome", SHA256: hex.EncodeToString(h[:])}}})
+	os.WriteFile(filepath.Join(repo, "manifest.json"), raw, 0o644)
+
+	d, reg := newTestDownloader(t, repo)
+	if _, err := d.Download(context.Background(), Item{ChampionID: 1, SkinID: 1001}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Verify(1001); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestDownloadCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	content := "slow package"
+	h := sha256.Sum256([]byte(content))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "manifest.json") {
+			json.NewEncoder(w).Encode(Manifest{Version: 1, Packages: map[string]ManifestPackage{"1001": {Path: "p.fantome", SHA256: hex.EncodeToString(h[:])}}})
+			return
+		}
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(content))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	d, reg := newTestDownloader(t, srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 1)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := d.Download(ctx, Item{SkinID: 1001}, func(p Progress) {
+			if p.Downloaded > 0 {
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+			}
+		})
+		errc <- err
+	}()
+	<-started
+	if _, err := d.Download(context.Background(), Item{SkinID: 1001}, nil); !errors.Is(err, ErrDownloadInProgress) {
+		t.Errorf("concurrent download err = %v, want ErrDownloadInProgress", err)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if reg.IsInstalled(1001) {
+		t.Errorf("cancelled download was registered")
+	}
+}
+
+func TestDownloadChampionConflict(t *testing.T) {
+	srv, _ := fakeRepo(t, map[string]string{"103015": "sg ahri", "103027": "sb ahri"}, nil)
+	d, reg := newTestDownloader(t, srv.URL+"/repo")
+	if _, err := d.Download(context.Background(), Item{ChampionID: 103, SkinID: 103015, Name: "Star Guardian Ahri"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	item := Item{ChampionID: 103, SkinID: 103027, Name: "Spirit Blossom Ahri"}
+	if _, err := d.Download(context.Background(), item, nil); !errors.Is(err, ErrChampionConflict) {
+		t.Fatalf("second skin = %v, want ErrChampionConflict", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(d.dir, "103", "*")); len(files) != 1 {
+		t.Errorf("conflicting download left files: %v", files)
+	}
+	item.Replace = true
+	if _, err := d.Download(context.Background(), item, nil); err != nil {
+		t.Fatal(err)
+	}
+	if reg.IsInstalled(103015) || !reg.IsInstalled(103027) {
+		t.Errorf("Replace did not swap the installed skin: %+v", reg.List())
+	}
+	if files, _ := filepath.Glob(filepath.Join(d.dir, "103", "*")); len(files) != 1 {
+		t.Errorf("packages on disk after replace: %v", files)
+	}
+}
+
+func TestDownloadFailedReinstallKeepsPackage(t *testing.T) {
+	srv, _ := fakeRepo(t, map[string]string{"103015": "sg ahri v1"}, nil)
+	d, reg := newTestDownloader(t, srv.URL+"/repo")
+	item := Item{ChampionID: 103, SkinID: 103015, Name: "Star Guardian Ahri"}
+	first, err := d.Download(context.Background(), item, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La precondición pasa en Download pero falla dentro de Add, ya con el paquete descargado.
+	errNotReady := errors.New("game folder gone")
+	calls := 0
+	reg.SetPrecondition(func() error {
+		calls++
+		if calls > 1 {
+			return errNotReady
+		}
+		return nil
+	})
+	if _, err := d.Download(context.Background(), item, nil); !errors.Is(err, errNotReady) {
+		t.Fatalf("reinstall err = %v, want the Add error", err)
+	}
+	if raw, err := os.ReadFile(first.PackagePath); err != nil || string(raw) != "sg ahri v1" {
+		t.Fatalf("failed reinstall deleted the installed package: %q, %v", raw, err)
+	}
+	if e, _ := reg.Get(103015); e.PackagePath != first.PackagePath || e.Checksum != first.Checksum {
+		t.Errorf("entry changed after failed reinstall: %+v", e)
+	}
+	if files, _ := filepath.Glob(filepath.Join(d.dir, "103", "*")); len(files) != 1 {
+		t.Errorf("failed reinstall left files: %v", files)
+	}
+
+	// Una reinstalación correcta deja solo el paquete nuevo.
+	reg.SetPrecondition(nil)
+	second, err := d.Download(context.Background(), item, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(d.dir, "103", "*")); len(files) != 1 || files[0] != second.PackagePath {
+		t.Errorf("packages after reinstall = %v, want only %s", files, second.PackagePath)
+	}
+}
+
+func TestDownloadRejectsSizeMismatch(t *testing.T) {
+	for _, size := range []int64{5, 500} {
+		srv, pkgs := fakeRepo(t, map[string]string{"1001": "0123456789"}, nil)
+		d, reg := newTestDownloader(t, srv.URL+"/repo")
+		m, err := d.Manifest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg := pkgs["1001"]
+		pkg.Size = size
+		m.Packages["1001"] = pkg
+		if _, err := d.Download(context.Background(), Item{ChampionID: 1, SkinID: 1001}, nil); !errors.Is(err, ErrSizeMismatch) {
+			t.Errorf("size %d: err = %v, want ErrSizeMismatch", size, err)
+		}
+		if reg.IsInstalled(1001) {
+			t.Errorf("size %d: package with the wrong size was registered", size)
+		}
+	}
+}
@@ -0,0 +1,597 @@
+// skinhunter/installs/registry.go
+package installs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"skinhunter/data"
+)
+
+// Registro de skins instaladas. Se guarda como JSON en el directorio de datos del
+// usuario (<UserConfigDir>/skinhunter/installed.json) y se reescribe entero en cada
+// cambio, de forma atómica (fichero temporal + rename).
+//
+// Solo puede haber una skin o chroma del catálogo por campeón: con dos el juego carga
+// una mezcla indefinida. Add lo rechaza con ErrChampionConflict y Replace sustituye
+// lo que hubiera. Los mods importados no cuentan (no se sabe de qué campeón son).
+//
+// Cada entrada tiene además una prioridad y puede estar desactivada: al montar el
+// overlay solo entran las activas y, si dos traen el mismo fichero, gana la de mayor
+// prioridad. Lo último instalado queda arriba del todo.
+
+var (
+	// ErrNotInstalled indica que el ID no está en el registro.
+	ErrNotInstalled = errors.New("not installed")
+	// ErrPackageMissing indica que el paquete registrado ya no está en disco.
+	ErrPackageMissing = errors.New("package file missing")
+	// ErrChecksumMismatch indica que el paquete en disco no coincide con el checksum registrado.
+	ErrChecksumMismatch = errors.New("package checksum mismatch")
+	// ErrChampionConflict indica que ya hay instalada otra skin (o chroma) del mismo
+	// campeón; solo se admite una por campeón.
+	ErrChampionConflict = errors.New("another skin of this champion is installed")
+	// ErrCorruptRegistry indica que installed.json existe pero no es JSON válido.
+	ErrCorruptRegistry = errors.New("install registry is corrupt")
+)
+
+// UserMessage traduce los errores de descarga e instalación a un texto para el usuario;
+// ok = false si err no viene de este paquete.
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrNoRepository):
+		return "No package repository is configured. Set one in Settings to download skins.", true
+	case errors.Is(err, ErrPackageNotFound):
+		return "This skin isn't available in the package repository.", true
+	case errors.Is(err, ErrChecksumMismatch):
+		return "The downloaded package is damaged (checksum mismatch). Try downloading it again.", true
+	case errors.Is(err, ErrSizeMismatch):
+		return "The downloaded package is incomplete or damaged (size mismatch). Try downloading it again.", true
+	case errors.Is(err, ErrDownloadInProgress):
+		return "This skin is already being downloaded.", true
+	case errors.Is(err, ErrChampionConflict):
+		return "Another skin of this champion is already installed. Only one skin per champion can be installed: uninstall it or replace it.", true
+	case errors.Is(err, ErrPackageMissing):
+		return "The installed package is no longer on disk.", true
+	}
+	return "", false
+}
+
+// Entry es una skin o chroma instalada, o un mod importado (ModID != 0).
+type Entry struct {
+	ChampionID  int       `json:"championId"`
+	SkinID      int       `json:"skinId"`
+	ChromaID    int       `json:"chromaId,omitempty"` // 0 = la skin sin chroma
+	ModID       int       `json:"modId,omitempty"`    // Solo mods importados; sale del checksum del paquete
+	Name        string    `json:"name"`
+	PackagePath string    `json:"packagePath"`
+	Checksum    string    `json:"checksum"` // SHA-256 del paquete, en hex
+	InstalledAt time.Time `json:"installedAt"`
+	Disabled    bool      `json:"disabled,omitempty"` // No se incluye en el overlay
+	Priority    int       `json:"priority,omitempty"` // Mayor = gana en los ficheros en conflicto
+	Mod         *ModInfo  `json:"mod,omitempty"`
+}
+
+// ModInfo son los metadatos de un mod importado (los de su META/info.json).
+type ModInfo struct {
+	Author      string   `json:"author,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ImagePath   string   `json:"imagePath,omitempty"` // Copia local de META/image.png
+	Wads        []string `json:"wads,omitempty"`      // WADs que modifica, p. ej. "Ahri.wad.client"
+}
+
+// ItemID es el ID con el que se instaló: el del chroma si lo hay, si no el de la skin.
+// Los mods importados usan -ModID para no chocar nunca con un ID del catálogo.
+func (e Entry) ItemID() int {
+	switch {
+	case e.ModID != 0:
+		return -e.ModID
+	case e.ChromaID != 0:
+		return e.ChromaID
+	}
+	return e.SkinID
+}
+
+// IsMod indica si la entrada es un mod importado y no una skin del catálogo.
+func (e Entry) IsMod() bool { return e.ModID != 0 }
+
+type registryFile struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+const registryVersion = 1
+
+// Registry es segura para uso concurrente.
+type Registry struct {
+	path string // "" = solo en memoria
+
+	mu        sync.Mutex
+	entries   map[int]Entry // Por ItemID
+	listeners map[int]func()
+	nextID    int
+	ready     func() error // Precondición de Add y Remove; nil = siempre
+}
+
+// Shared es el registro que usa toda la aplicación; lo crea OpenShared.
+var Shared *Registry
+
+// OpenShared abre el registro de <UserConfigDir>/skinhunter/installed.json y crea
+// Shared y SharedDownloader. Se llama una vez desde main, antes de crear la UI.
+func OpenShared() error {
+	path := ""
+	if base, err := os.UserConfigDir(); err == nil {
+		path = filepath.Join(base, "skinhunter", "installed.json")
+	} else {
+		log.Printf("WARN: No user config dir, install registry will not be saved: %v", err)
+	}
+	r, err := openOrReset(path)
+	if err != nil {
+		return err
+	}
+	Shared = r
+	SharedDownloader = newSharedDownloader(r)
+	return nil
+}
+
+// openOrReset abre el registro de path. Si el JSON está dañado lo aparta como
+// .corrupt y empieza vacío; cualquier otro error (permisos, disco) se devuelve
+// para no sobrescribir un registro que quizá esté bien.
+func openOrReset(path string) (*Registry, error) {
+	r, err := Open(path)
+	if !errors.Is(err, ErrCorruptRegistry) {
+		return r, err
+	}
+	log.Printf("ERROR: %v; starting with an empty install registry", err)
+	if renameErr := os.Rename(path, path+".corrupt"); renameErr != nil {
+		return nil, fmt.Errorf("move aside corrupt %s: %w", path, renameErr)
+	}
+	return &Registry{path: path, entries: make(map[int]Entry)}, nil
+}
+
+// Open carga el registro de path; si el fichero no existe empieza vacío. Un
+// fichero que no es JSON válido da ErrCorruptRegistry.
+func Open(path string) (*Registry, error) {
+	r := &Registry{path: path, entries: make(map[int]Entry)}
+	if path == "" {
+		return r, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f registryFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("%w: parse %s: %v", ErrCorruptRegistry, path, err)
+	}
+	for _, e := range f.Entries {
+		r.entries[e.ItemID()] = e
+	}
+	return r, nil
+}
+
+// Path devuelve el fichero del registro.
+func (r *Registry) Path() string { return r.path }
+
+// OnChange registra fn para que se llame (desde la goroutine que hizo el cambio)
+// tras cada cambio (Add, Remove, SetEnabled...). La función devuelta da de baja fn.
+func (r *Registry) OnChange(fn func()) (remove func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listeners == nil {
+		r.listeners = make(map[int]func())
+	}
+	id := r.nextID
+	r.nextID++
+	r.listeners[id] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.listeners, id)
+	}
+}
+
+// SetPrecondition hace que Add, Remove y CheckReady fallen con el error de ready
+// mientras no devuelva nil (p. ej. sin carpeta del juego configurada).
+func (r *Registry) SetPrecondition(ready func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = ready
+}
+
+// CheckReady devuelve el error de la precondición, o nil si se puede instalar.
+func (r *Registry) CheckReady() error {
+	r.mu.Lock()
+	ready := r.ready
+	r.mu.Unlock()
+	if ready == nil {
+		return nil
+	}
+	return ready()
+}
+
+// List devuelve todas las entradas, de la instalación más reciente a la más antigua.
+func (r *Registry) List() []Entry {
+	r.mu.Lock()
+	out := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, e)
+	}
+	r.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].InstalledAt.Equal(out[j].InstalledAt) {
+			return out[i].InstalledAt.After(out[j].InstalledAt)
+		}
+		return out[i].ItemID() < out[j].ItemID()
+	})
+	return out
+}
+
+// LoadOrder devuelve todas las entradas de mayor a menor prioridad (a igual prioridad,
+// la instalada más tarde primero): el orden en que se aplican al overlay.
+func (r *Registry) LoadOrder() []Entry {
+	out := r.List()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Priority > out[j].Priority })
+	return out
+}
+
+// Get devuelve la entrada de itemID (skin o chroma).
+func (r *Registry) Get(itemID int) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[itemID]
+	return e, ok
+}
+
+// IsInstalled indica si itemID (skin o chroma) está instalado.
+func (r *Registry) IsInstalled(itemID int) bool {
+	_, ok := r.Get(itemID)
+	return ok
+}
+
+// championOf devuelve el campeón de e: el registrado o, si falta, el que sale del ID
+// de la skin. 0 para mods.
+func championOf(e Entry) int {
+	if e.IsMod() {
+		return 0
+	}
+	if e.ChampionID > 0 {
+		return e.ChampionID
+	}
+	if c := data.GetChampionIDFromSkinID(e.SkinID); c > 0 {
+		return c
+	}
+	return 0
+}
+
+// Conflicts devuelve las entradas que impiden instalar e con Add: las de otras skins
+// o chromas de su campeón. Reinstalar el mismo ItemID no es un conflicto.
+func (r *Registry) Conflicts(e Entry) []Entry {
+	r.mu.Lock()
+	c := r.conflictsLocked(e)
+	r.mu.Unlock()
+	sort.Slice(c, func(i, j int) bool { return c[i].ItemID() < c[j].ItemID() })
+	return c
+}
+
+func (r *Registry) conflictsLocked(e Entry) []Entry {
+	champ := championOf(e)
+	if champ == 0 {
+		return nil
+	}
+	var out []Entry
+	for id, other := range r.entries {
+		if id != e.ItemID() && championOf(other) == champ {
+			out = append(out, other)
+		}
+	}
+	return out
+}
+
+// ConflictError envuelve ErrChampionConflict con los nombres de lo ya instalado.
+func ConflictError(conflicts []Entry) error {
+	names := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		names[i] = c.Name
+	}
+	return fmt.Errorf("%w: %s", ErrChampionConflict, strings.Join(names, ", "))
+}
+
+// ForChampion devuelve las entradas del campeón champID.
+func (r *Registry) ForChampion(champID int) []Entry {
+	var out []Entry
+	for _, e := range r.List() {
+		if e.ChampionID == champID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Add registra e (sustituye la entrada anterior del mismo ItemID) y guarda el registro.
+// Si e.InstalledAt es cero se usa la hora actual. Una entrada nueva se pone la primera
+// en el orden de carga; al reinstalar se conservan su prioridad y si está activa. Falla con ErrChampionConflict si
+// hay otra skin de su campeón instalada.
+func (r *Registry) Add(e Entry) error {
+	return r.add(e, false)
+}
+
+// Replace es como Add, pero desinstala antes (en el mismo guardado) las otras skins
+// del campeón de e, borrando sus paquetes.
+func (r *Registry) Replace(e Entry) error {
+	return r.add(e, true)
+}
+
+func (r *Registry) add(e Entry, replace bool) error {
+	if e.SkinID == 0 && e.ModID == 0 {
+		return fmt.Errorf("install entry without skin or mod ID")
+	}
+	if err := r.CheckReady(); err != nil {
+		return err
+	}
+	if e.InstalledAt.IsZero() {
+		e.InstalledAt = time.Now()
+	}
+	e.ChampionID = championOf(e)
+	r.mu.Lock()
+	conflicts := r.conflictsLocked(e)
+	if len(conflicts) > 0 && !replace {
+		r.mu.Unlock()
+		return ConflictError(conflicts)
+	}
+	prev, had := r.entries[e.ItemID()]
+	if had {
+		e.Priority, e.Disabled = prev.Priority, prev.Disabled
+	} else {
+		e.Priority = r.maxPriorityLocked() + 1
+	}
+	for _, c := range conflicts {
+		delete(r.entries, c.ItemID())
+	}
+	r.entries[e.ItemID()] = e
+	if err := r.saveLocked(); err != nil {
+		if had {
+			r.entries[e.ItemID()] = prev
+		} else {
+			delete(r.entries, e.ItemID())
+		}
+		for _, c := range conflicts {
+			r.entries[c.ItemID()] = c
+		}
+		r.mu.Unlock()
+		return err
+	}
+	r.mu.Unlock()
+	if had {
+		removeStaleFiles(prev, e)
+	}
+	for _, c := range conflicts {
+		removeFiles(c)
+		log.Printf("Installs: %s (ID %d) replaced by %s", c.Name, c.ItemID(), e.Name)
+	}
+	log.Printf("Installs: registered %s (ID %d)", e.Name, e.ItemID())
+	r.notify()
+	return nil
+}
+
+// Remove desinstala itemID: lo quita del registro y borra su paquete (y la imagen,
+// si es un mod) del disco.
+func (r *Registry) Remove(itemID int) error {
+	if err := r.CheckReady(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	e, ok := r.entries[itemID]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %d", ErrNotInstalled, itemID)
+	}
+	delete(r.entries, itemID)
+	if err := r.saveLocked(); err != nil {
+		r.entries[itemID] = e
+		r.mu.Unlock()
+		return err
+	}
+	r.mu.Unlock()
+	removeFiles(e)
+	log.Printf("Installs: removed %s (ID %d)", e.Name, itemID)
+	r.notify()
+	return nil
+}
+
+// SetEnabled activa o desactiva itemID en el overlay.
+func (r *Registry) SetEnabled(itemID int, enabled bool) error {
+	return r.update(func() error {
+		e, ok := r.entries[itemID]
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrNotInstalled, itemID)
+		}
+		e.Disabled = !enabled
+		r.entries[itemID] = e
+		return nil
+	})
+}
+
+// SetOrder cambia el orden de carga: itemIDs va de mayor a menor prioridad. Las
+// entradas que no estén en itemIDs quedan detrás, en el orden que tenían.
+func (r *Registry) SetOrder(itemIDs []int) error {
+	order := make([]int, 0, len(itemIDs))
+	listed := make(map[int]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if !listed[id] {
+			listed[id] = true
+			order = append(order, id)
+		}
+	}
+	for _, e := range r.LoadOrder() {
+		if !listed[e.ItemID()] {
+			order = append(order, e.ItemID())
+		}
+	}
+	return r.update(func() error {
+		for _, id := range order {
+			if _, ok := r.entries[id]; !ok {
+				return fmt.Errorf("%w: %d", ErrNotInstalled, id)
+			}
+		}
+		for i, id := range order {
+			e := r.entries[id]
+			e.Priority = len(order) - i
+			r.entries[id] = e
+		}
+		return nil
+	})
+}
+
+// update aplica change con r.mu tomado y guarda; si change o el guardado fallan se
+// deja todo como estaba.
+func (r *Registry) update(change func() error) error {
+	r.mu.Lock()
+	prev := make(map[int]Entry, len(r.entries))
+	for id, e := range r.entries {
+		prev[id] = e
+	}
+	err := change()
+	if err == nil {
+		err = r.saveLocked()
+	}
+	if err != nil {
+		r.entries = prev
+		r.mu.Unlock()
+		return err
+	}
+	r.mu.Unlock()
+	r.notify()
+	return nil
+}
+
+func (r *Registry) maxPriorityLocked() int {
+	m := 0
+	for _, e := range r.entries {
+		m = max(m, e.Priority)
+	}
+	return m
+}
+
+// removeFiles borra del disco el paquete de e y, si es un mod, su imagen.
+func removeFiles(e Entry) { removeStaleFiles(e, Entry{}) }
+
+// removeStaleFiles borra los ficheros de prev (paquete e imagen) que no use cur;
+// se llama al reinstalar un ID con un paquete nuevo.
+func removeStaleFiles(prev, cur Entry) {
+	keep := map[string]bool{cur.PackagePath: true}
+	if cur.Mod != nil {
+		keep[cur.Mod.ImagePath] = true
+	}
+	files := []string{prev.PackagePath}
+	if prev.Mod != nil {
+		files = append(files, prev.Mod.ImagePath)
+	}
+	for _, f := range files {
+		if f == "" || keep[f] {
+			continue
+		}
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("WARN: Installs: could not delete %s: %v", f, err)
+		}
+	}
+}
+
+// Verify comprueba que el paquete de itemID sigue en disco con el checksum registrado.
+func (r *Registry) Verify(itemID int) error {
+	e, ok := r.Get(itemID)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrNotInstalled, itemID)
+	}
+	sum, err := FileChecksum(e.PackagePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrPackageMissing, e.PackagePath)
+	}
+	if err != nil {
+		return err
+	}
+	if sum != e.Checksum {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, e.PackagePath)
+	}
+	return nil
+}
+
+// FileChecksum devuelve el SHA-256 en hex del fichero path.
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (r *Registry) notify() {
+	r.mu.Lock()
+	listeners := make([]func(), 0, len(r.listeners))
+	for _, fn := range r.listeners {
+		listeners = append(listeners, fn)
+	}
+	r.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// saveLocked escribe el registro en disco. Se llama con r.mu tomado.
+func (r *Registry) saveLocked() error {
+	if r.path == "" {
+		return nil
+	}
+	f := registryFile{Version: registryVersion, Entries: make([]Entry, 0, len(r.entries))}
+	for _, e := range r.entries {
+		f.Entries = append(f.Entries, e)
+	}
+	sort.Slice(f.Entries, func(i, j int) bool { return f.Entries[i].ItemID() < f.Entries[j].ItemID() })
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".installed-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save install registry: %w", err)
+	}
+	return nil
+}
+
+// --- End of registry.go ---
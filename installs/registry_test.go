@@ -0,0 +1,300 @@
+package installs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePackage crea un paquete de prueba y devuelve su ruta y checksum.
+func writePackage(t *testing.T, dir, name, content string) (string, string) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := FileChecksum(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, sum
+}
+
+func TestRegistryPersists(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "installed.json")
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, sum := writePackage(t, dir, "ahri.fantome", "ahri")
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{ChampionID: 103, SkinID: 103015, Name: "Star Guardian Ahri", PackagePath: pkg, Checksum: sum, InstalledAt: t0},
+		{ChampionID: 145, SkinID: 145014, ChromaID: 145016, Name: "Star Guardian Kai'Sa (Ruby)", InstalledAt: t0.Add(time.Hour)},
+	}
+	changes := 0
+	remove := r.OnChange(func() { changes++ })
+	for _, e := range entries {
+		if err := r.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if changes != 2 {
+		t.Errorf("OnChange called %d times, want 2", changes)
+	}
+	remove()
+	if err := r.Add(entries[0]); err != nil {
+		t.Fatal(err)
+	}
+	if changes != 2 {
+		t.Errorf("listener called after remove")
+	}
+
+	r2, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := r2.List()
+	if len(list) != 2 || list[0].ItemID() != 145016 || list[1].ItemID() != 103015 {
+		t.Fatalf("List() = %+v, want newest first", list)
+	}
+	if got := list[1]; got.Checksum != sum || got.PackagePath != pkg || !got.InstalledAt.Equal(t0) {
+		t.Errorf("reloaded entry = %+v", got)
+	}
+	if !r2.IsInstalled(145016) || r2.IsInstalled(145014) {
+		t.Errorf("IsInstalled should match the chroma ID, not its skin")
+	}
+	if got := r2.ForChampion(103); len(got) != 1 || got[0].SkinID != 103015 {
+		t.Errorf("ForChampion(103) = %+v", got)
+	}
+}
+
+func TestRegistryAddReplaces(t *testing.T) {
+	r, _ := Open(filepath.Join(t.TempDir(), "installed.json"))
+	if err := r.Add(Entry{SkinID: 1001, Name: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(Entry{SkinID: 1001, Name: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if list := r.List(); len(list) != 1 || list[0].Name != "new" || list[0].InstalledAt.IsZero() {
+		t.Errorf("List() = %+v, want one entry named new with a time", list)
+	}
+	if err := r.Add(Entry{Name: "no id"}); err == nil {
+		t.Errorf("Add without SkinID should fail")
+	}
+}
+
+func TestRegistryRemoveDeletesPackage(t *testing.T) {
+	dir := t.TempDir()
+	r, _ := Open(filepath.Join(dir, "installed.json"))
+	pkg, sum := writePackage(t, dir, "annie.fantome", "annie")
+	if err := r.Add(Entry{SkinID: 1001, PackagePath: pkg, Checksum: sum}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(1001); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(pkg); !os.IsNotExist(err) {
+		t.Errorf("package still on disk: %v", err)
+	}
+	if r.IsInstalled(1001) {
+		t.Errorf("still installed after Remove")
+	}
+	if err := r.Remove(1001); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("second Remove = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestRegistryVerify(t *testing.T) {
+	dir := t.TempDir()
+	r, _ := Open("")
+	pkg, sum := writePackage(t, dir, "lux.fantome", "lux")
+	if err := r.Add(Entry{SkinID: 99007, PackagePath: pkg, Checksum: sum}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Verify(99007); err != nil {
+		t.Errorf("Verify on intact package: %v", err)
+	}
+	if err := os.WriteFile(pkg, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Verify(99007); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify after change = %v, want ErrChecksumMismatch", err)
+	}
+	os.Remove(pkg)
+	if err := r.Verify(99007); !errors.Is(err, ErrPackageMissing) {
+		t.Errorf("Verify after delete = %v, want ErrPackageMissing", err)
+	}
+	if err := r.Verify(1); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("Verify unknown = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrCorruptRegistry) {
+		t.Errorf("Open of corrupt registry = %v, want ErrCorruptRegistry", err)
+	}
+
+	// openOrReset aparta el fichero dañado y empieza vacío.
+	r, err := openOrReset(path)
+	if err != nil || len(r.List()) != 0 {
+		t.Fatalf("openOrReset = %v, %v", r, err)
+	}
+	if b, err := os.ReadFile(path + ".corrupt"); err != nil || string(b) != "{not json" {
+		t.Errorf("corrupt registry not moved aside: %q, %v", b, err)
+	}
+}
+
+func TestOpenOrResetKeepsUnreadableRegistry(t *testing.T) {
+	// Un error que no es de parseo (aquí, installed.json es un directorio) se
+	// devuelve sin apartar nada.
+	path := filepath.Join(t.TempDir(), "installed.json")
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openOrReset(path); err == nil || errors.Is(err, ErrCorruptRegistry) {
+		t.Fatalf("openOrReset = %v, want a read error", err)
+	}
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Errorf("unreadable registry should not be moved aside: %v", err)
+	}
+}
+
+func TestRegistryPrecondition(t *testing.T) {
+	r, _ := Open("")
+	if err := r.Add(Entry{SkinID: 1001}); err != nil {
+		t.Fatal(err)
+	}
+	errNoGame := errors.New("no game")
+	r.SetPrecondition(func() error { return errNoGame })
+	if err := r.Add(Entry{SkinID: 1002}); !errors.Is(err, errNoGame) {
+		t.Errorf("Add = %v, want the precondition error", err)
+	}
+	if err := r.Remove(1001); !errors.Is(err, errNoGame) || !r.IsInstalled(1001) {
+		t.Errorf("Remove = %v, want the precondition error and no change", err)
+	}
+	d := NewDownloader(t.TempDir(), r)
+	d.SetRepository(t.TempDir())
+	if _, err := d.Download(context.Background(), Item{SkinID: 1002}, nil); !errors.Is(err, errNoGame) {
+		t.Errorf("Download = %v, want the precondition error", err)
+	}
+	r.SetPrecondition(nil)
+	if err := r.Remove(1001); err != nil {
+		t.Errorf("Remove after clearing the precondition: %v", err)
+	}
+}
+
+func TestRegistryOneSkinPerChampion(t *testing.T) {
+	dir := t.TempDir()
+	r, _ := Open(filepath.Join(dir, "installed.json"))
+	oldPkg, oldSum := writePackage(t, dir, "ahri15.fantome", "sg ahri")
+	if err := r.Add(Entry{SkinID: 103015, Name: "Star Guardian Ahri", PackagePath: oldPkg, Checksum: oldSum}); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := r.Get(103015); e.ChampionID != 103 {
+		t.Errorf("ChampionID not derived from the skin ID: %+v", e)
+	}
+	// Reinstalar lo mismo no es conflicto; otra skin, un chroma o la otra skin con
+	// ChampionID explícito sí.
+	if err := r.Add(Entry{SkinID: 103015, Name: "Star Guardian Ahri", PackagePath: oldPkg, Checksum: oldSum}); err != nil {
+		t.Errorf("re-adding the same skin: %v", err)
+	}
+	for _, e := range []Entry{
+		{SkinID: 103027, Name: "Spirit Blossom Ahri"},
+		{SkinID: 103015, ChromaID: 103016, Name: "Star Guardian Ahri (Ruby)"},
+		{ChampionID: 103, SkinID: 103027, Name: "Spirit Blossom Ahri"},
+	} {
+		err := r.Add(e)
+		if !errors.Is(err, ErrChampionConflict) || !strings.Contains(err.Error(), "Star Guardian Ahri") {
+			t.Errorf("Add(%s) = %v, want ErrChampionConflict naming the installed skin", e.Name, err)
+		}
+	}
+	if c := r.Conflicts(Entry{SkinID: 103027}); len(c) != 1 || c[0].SkinID != 103015 {
+		t.Errorf("Conflicts = %+v", c)
+	}
+	if err := r.Add(Entry{SkinID: 145014, Name: "Star Guardian Kai'Sa"}); err != nil {
+		t.Errorf("other champion: %v", err)
+	}
+	if err := r.Add(Entry{ModID: 42, Name: "Some mod"}); err != nil {
+		t.Errorf("mods don't conflict: %v", err)
+	}
+
+	if err := r.Replace(Entry{SkinID: 103027, Name: "Spirit Blossom Ahri"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.IsInstalled(103015) || !r.IsInstalled(103027) || len(r.ForChampion(103)) != 1 {
+		t.Errorf("Replace left %+v", r.ForChampion(103))
+	}
+	if _, err := os.Stat(oldPkg); !os.IsNotExist(err) {
+		t.Errorf("replaced package still on disk: %v", err)
+	}
+	r2, _ := Open(filepath.Join(dir, "installed.json"))
+	if len(r2.List()) != 3 {
+		t.Errorf("saved registry has %d entries, want 3", len(r2.List()))
+	}
+}
+
+func TestRegistryLoadOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "installed.json")
+	r, _ := Open(path)
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, e := range []Entry{{ModID: 1, Name: "a"}, {ModID: 2, Name: "b"}, {ModID: 3, Name: "c"}} {
+		e.InstalledAt = t0.Add(time.Duration(i) * time.Hour)
+		if err := r.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(r *Registry) string {
+		var s []string
+		for _, e := range r.LoadOrder() {
+			s = append(s, e.Name)
+		}
+		return strings.Join(s, ",")
+	}
+	if got := names(r); got != "c,b,a" {
+		t.Errorf("LoadOrder = %s, want the newest first", got)
+	}
+	if err := r.SetOrder([]int{-1, -3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(r); got != "a,c,b" {
+		t.Errorf("after SetOrder = %s", got)
+	}
+	if err := r.SetEnabled(-3, false); err != nil {
+		t.Fatal(err)
+	}
+	// Reinstalar conserva la prioridad y el estado; lo nuevo va arriba.
+	if err := r.Add(Entry{ModID: 3, Name: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(Entry{ModID: 4, Name: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	r2, _ := Open(path)
+	if got := names(r2); got != "d,a,c,b" {
+		t.Errorf("reloaded LoadOrder = %s", got)
+	}
+	if e, _ := r2.Get(-3); !e.Disabled {
+		t.Errorf("reinstall re-enabled the entry: %+v", e)
+	}
+	if err := r.SetOrder([]int{99}); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("SetOrder with unknown ID = %v", err)
+	}
+	if err := r.SetEnabled(99, true); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("SetEnabled with unknown ID = %v", err)
+	}
+	if got := names(r); got != "d,a,c,b" {
+		t.Errorf("failed SetOrder changed the order: %s", got)
+	}
+}
@@ -2,11 +2,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"skinhunter/data"
+	"skinhunter/game"
+	"skinhunter/hashes"
+	"skinhunter/imagecache"
+	"skinhunter/installs"
 	"skinhunter/ui"
 
 	"fyne.io/fyne/v2"
@@ -25,7 +33,10 @@ import (
 	// "net/http"
 )
 
-const appName = "Skin Hunter Fyne"
+const (
+	appName = "Skin Hunter Fyne"
+	appID   = "com.foghvh.skinhunterfyne"
+)
 
 type skinHunterApp struct {
 	fyneApp fyne.App
@@ -39,6 +50,10 @@ type skinHunterApp struct {
 	navBackButton *widget.Button
 
 	statusLabel      *widget.Label
+	offlineBanner    *fyne.Container
+	offlineLabel     *widget.Label
+	gameBanner       *fyne.Container
+	gameLabel        *widget.Label
 	currentView      string
 	selectedChampion data.ChampionSummary
 	isDetailView     bool
@@ -46,25 +61,37 @@ type skinHunterApp struct {
 	championsData      []data.ChampionSummary
 	championsDataErr   error
 	championsGridView  fyne.CanvasObject
-	championDetailView *ui.ChampionView // Changed type to pointer
-	installedView      fyne.CanvasObject
+	gridCancel         context.CancelFunc // Cancela las cargas de retratos del grid actual
+	loadCancel         context.CancelFunc // Cancela una carga del catálogo en curso (p.ej. al cambiar ajustes)
+	championDetailView *ui.ChampionView   // Changed type to pointer
+	skinLinesView      *ui.SkinLinesView
+	skinQueryView      *ui.SkinQueryView
+	installedView      *ui.InstalledView
 	profileView        fyne.CanvasObject
 }
 
 func main() {
 	// go func() { log.Println(http.ListenAndServe("localhost:6060", nil)) }()
 
+	if err := installs.OpenShared(); err != nil {
+		log.Fatalf("ERROR: Cannot open the install registry: %v", err)
+	}
+
 	shApp := &skinHunterApp{
 		currentView:   "loading",
 		centerContent: container.NewMax(),
 	}
 
-	shApp.fyneApp = app.New()
+	shApp.fyneApp = app.NewWithID(appID)
 	shApp.window = shApp.fyneApp.NewWindow(appName)
 	shApp.window.Resize(fyne.NewSize(950, 720))
 	shApp.window.CenterOnScreen()
 	shApp.window.SetMainMenu(fyne.NewMainMenu(
-		fyne.NewMenu("File", fyne.NewMenuItem("Quit", func() { shApp.fyneApp.Quit() })),
+		fyne.NewMenu("File",
+			fyne.NewMenuItem("Settings...", func() { shApp.showSettings() }),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Quit", func() { shApp.fyneApp.Quit() }),
+		),
 	))
 
 	shApp.headerContent = container.NewHBox()
@@ -73,58 +100,236 @@ func main() {
 	shApp.navBackButton = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() { shApp.goBack() })
 	shApp.navBackButton.Hide()
 	shApp.statusLabel = widget.NewLabel("Initializing...")
+	shApp.offlineLabel = widget.NewLabel("")
+	shApp.offlineBanner = container.NewHBox(layout.NewSpacer(), widget.NewIcon(theme.WarningIcon()), shApp.offlineLabel, layout.NewSpacer())
+	shApp.offlineBanner.Hide()
+	shApp.gameLabel = widget.NewLabel("")
+	gameSettingsBtn := widget.NewButtonWithIcon("Settings", theme.SettingsIcon(), func() { shApp.showSettings() })
+	shApp.gameBanner = container.NewHBox(layout.NewSpacer(), widget.NewIcon(theme.WarningIcon()), shApp.gameLabel, gameSettingsBtn, layout.NewSpacer())
+	shApp.gameBanner.Hide()
 	bgColor := theme.BackgroundColor()
 	shApp.background = canvas.NewRectangle(bgColor)
 	layeredContent := container.NewStack(shApp.background, shApp.centerContent)
-	mainAppLayout := container.NewBorder(header, shApp.footer, nil, nil, layeredContent)
+	bottomBar := container.NewVBox(shApp.gameBanner, shApp.offlineBanner, shApp.footer)
+	mainAppLayout := container.NewBorder(header, bottomBar, nil, nil, layeredContent)
 	shApp.window.SetContent(mainAppLayout)
 	shApp.window.SetMaster()
-	shApp.showLoading()
+	shApp.applySettings()
+	installs.SharedDownloader.Subscribe(shApp.showDownloadStatus)
+	installs.Shared.SetPrecondition(game.Ready) // Sin juego no se instala ni desinstala nada
+	game.OnChange(func() { fyne.Do(shApp.updateGameBanner) })
+	shApp.updateGameBanner()
+	shApp.window.SetOnDropped(shApp.importDropped)
+	shApp.loadData()
+
+	shApp.window.ShowAndRun()
+}
+
+// applySettings configura la capa de datos a partir de las preferencias guardadas.
+func (sh *skinHunterApp) applySettings() {
+	prefs := sh.fyneApp.Preferences()
+	src, err := data.NewSourceFromLocation(prefs.String(ui.PrefDataSource), prefs.String(ui.PrefGameVersion), prefs.String(ui.PrefLocale))
+	if err != nil {
+		log.Printf("WARN: Invalid data source setting, falling back to CommunityDragon: %v", err)
+		src = data.NewCommunityDragonSource()
+	}
+	data.SetDataSource(src)
+	imagecache.Shared.SetDiskBudget(int64(prefs.IntWithFallback(ui.PrefImageCacheMB, ui.DefaultImageCacheMB)) << 20)
+	installs.SharedDownloader.SetRepository(prefs.String(ui.PrefPackageRepo))
+	hashes.Shared.SetLocation(prefs.String(ui.PrefHashTables))
+	hashes.SharedBin.SetLocation(prefs.String(ui.PrefHashTables))
+
+	gameDir := prefs.String(ui.PrefGameDir)
+	if gameDir == "" {
+		if found := game.Detect(); len(found) > 0 {
+			gameDir = found[0].Root
+			prefs.SetString(ui.PrefGameDir, gameDir)
+			log.Printf("Detected game directory %s", gameDir)
+		}
+	}
+	game.SetDir(gameDir)
+}
+
+// loadData (re)carga el catálogo en segundo plano y reconstruye las vistas.
+// Tras un cambio de ajustes (p.ej. idioma) vuelve a la vista que estaba abierta.
+func (sh *skinHunterApp) loadData() {
+	prevView, prevChampID := sh.currentView, sh.selectedChampion.ID
+	sh.showLoading()
+	sh.championsGridView = nil
+	sh.cancelLoads()
+	ctx, cancel := context.WithCancel(context.Background())
+	sh.loadCancel = cancel
 
 	go func() {
-		champions, err := data.FetchAllChampions()
-		shApp.championsData = champions
-		shApp.championsDataErr = err
+		champions, err := data.FetchAllChampions(ctx)
+		if ctx.Err() != nil {
+			log.Println("Catalog load superseded, discarding result.")
+			return
+		}
+		sh.championsData = champions
+		sh.championsDataErr = err
 
 		if err != nil {
 			log.Printf("ERROR: Failed to get champion data for initial view: %v", err)
 			fyne.Do(func() {
-				if shApp.currentView == "loading" {
-					errMsg := fmt.Sprintf("Failed to load champion list:\n%v\n\nPlease check connection or restart.", err)
-					shApp.showError(errMsg)
+				if sh.currentView == "loading" {
+					sh.showError("Failed to load champion list", err, sh.loadData)
 				}
 			})
 			return
 		}
 
 		// Pre-create reusable views
-		shApp.championDetailView = ui.NewChampionView(
-			shApp.window,
+		sh.championDetailView = ui.NewChampionView(
+			sh.window,
 			func(skin data.Skin, allChromas []data.Chroma) {
-				ui.ShowSkinDialog(skin, allChromas, shApp.window)
+				ui.ShowSkinDialog(skin, allChromas, sh.window)
 			},
 		)
-		shApp.installedView = container.NewCenter(widget.NewLabel("Installed Skins View (Not Implemented)"))
-		shApp.profileView = container.NewCenter(widget.NewLabel("User Profile View (Not Implemented)"))
+		sh.skinLinesView = ui.NewSkinLinesView(func(skin data.Skin, allChromas []data.Chroma) {
+			ui.ShowSkinDialog(skin, allChromas, sh.window)
+		})
+		sh.skinLinesView.Reload(ctx)
+		sh.skinQueryView = ui.NewSkinQueryView(func(skin data.Skin, allChromas []data.Chroma) {
+			ui.ShowSkinDialog(skin, allChromas, sh.window)
+		})
+		fyne.Do(func() {
+			if sh.installedView == nil { // Se crea una vez: escucha el registro de instalaciones
+				sh.installedView = ui.NewInstalledView(sh.window, installs.Shared, func(skin data.Skin) {
+					ui.ShowSkinDialog(skin, skin.Chromas, sh.window)
+				}, sh.reinstall)
+			} else {
+				sh.installedView.Reload() // Imágenes de la nueva fuente/idioma
+			}
+		})
+		sh.profileView = container.NewCenter(widget.NewLabel("User Profile View (Not Implemented)"))
 
 		fyne.Do(func() {
-			shApp.updateStatus("Ready")
-			shApp.switchView("champions_grid")
+			sh.updateStatus(fmt.Sprintf("Ready (%s, patch %s)", data.CurrentDataSource().Name(), data.GetPatch()))
+			sh.restoreView(prevView, prevChampID)
+			sh.updateOfflineBanner()
 		})
 	}()
+}
 
-	shApp.window.ShowAndRun()
+// reinstall vuelve a registrar una instalación cuyo paquete sigue intacto en disco;
+// si falta o está dañado lo descarga de nuevo (el progreso va a la barra de estado).
+// Los mods importados no tienen de dónde descargarse: solo se avisa.
+func (sh *skinHunterApp) reinstall(e installs.Entry) {
+	verifyErr := installs.Shared.Verify(e.ItemID())
+	if verifyErr == nil {
+		e.InstalledAt = time.Time{}
+		if err := installs.Shared.Add(e); err != nil {
+			log.Printf("ERROR: Reinstall %s (ID %d): %v", e.Name, e.ItemID(), err)
+			dialog.ShowError(fmt.Errorf("%s: %s", e.Name, ui.ErrorMessage(err)), sh.window)
+		}
+		return
+	}
+	if e.IsMod() { // Un mod importado no se puede descargar
+		log.Printf("Reinstall mod %s (ID %d): %v", e.Name, e.ItemID(), verifyErr)
+		dialog.ShowError(fmt.Errorf("%s: the mod package is missing or damaged. Import the .fantome again", e.Name), sh.window)
+		return
+	}
+	log.Printf("Reinstall %s (ID %d): %v, downloading again", e.Name, e.ItemID(), verifyErr)
+	item := installs.Item{ChampionID: e.ChampionID, SkinID: e.SkinID, ChromaID: e.ChromaID, Name: e.Name}
+	go func() {
+		if _, err := installs.SharedDownloader.Download(context.Background(), item, nil); err != nil {
+			fyne.Do(func() { dialog.ShowError(fmt.Errorf("%s: %s", e.Name, ui.ErrorMessage(err)), sh.window) })
+		}
+	}()
+}
+
+// importDropped importa los .fantome soltados sobre la ventana y abre la vista Installed.
+func (sh *skinHunterApp) importDropped(_ fyne.Position, uris []fyne.URI) {
+	if sh.installedView == nil {
+		return
+	}
+	imported := false
+	for _, u := range uris {
+		if strings.EqualFold(u.Extension(), ".fantome") {
+			sh.installedView.Import(u.Path())
+			imported = true
+		}
+	}
+	if imported {
+		sh.switchView("installed_view")
+	}
+}
+
+// showDownloadStatus refleja en la barra de estado las descargas de paquetes.
+func (sh *skinHunterApp) showDownloadStatus(ev installs.DownloadEvent) {
+	var status string
+	switch {
+	case ev.Done && ev.Err != nil:
+		status = fmt.Sprintf("Download failed: %s", ev.Item.Name)
+	case ev.Done:
+		status = fmt.Sprintf("Installed %s", ev.Item.Name)
+	case ev.Progress.Fraction() >= 0:
+		status = fmt.Sprintf("Downloading %s... %.0f%%", ev.Item.Name, ev.Progress.Fraction()*100)
+	default:
+		status = fmt.Sprintf("Downloading %s... %.1f MB", ev.Item.Name, float64(ev.Progress.Downloaded)/(1<<20))
+	}
+	fyne.Do(func() { sh.updateStatus(status) })
+}
+
+// cancelLoads aborta la carga del catálogo en curso y las cargas de las vistas que
+// se van a reconstruir (retratos del grid, detalles y tiles del campeón abierto).
+func (sh *skinHunterApp) cancelLoads() {
+	if sh.gridCancel != nil {
+		sh.gridCancel()
+		sh.gridCancel = nil
+	}
+	if sh.loadCancel != nil {
+		sh.loadCancel()
+		sh.loadCancel = nil
+	}
+	if sh.championDetailView != nil {
+		sh.championDetailView.Cancel()
+	}
+	if sh.skinQueryView != nil {
+		sh.skinQueryView.Cancel()
+	}
+}
+
+// restoreView vuelve a abrir viewName tras recargar los datos. El campeón se busca
+// de nuevo por ID para mostrar su nombre en el idioma actual.
+func (sh *skinHunterApp) restoreView(viewName string, champID int) {
+	switch viewName {
+	case "champion_detail":
+		for _, c := range sh.championsData {
+			if c.ID == champID {
+				sh.showChampionDetail(c)
+				return
+			}
+		}
+	case "skin_lines", "skin_query", "installed_view", "profile_view":
+		sh.switchView(viewName)
+		return
+	}
+	sh.switchView("champions_grid")
+}
+
+// showSettings abre el diálogo de ajustes y recarga los datos si cambian.
+func (sh *skinHunterApp) showSettings() {
+	ui.ShowSettingsDialog(sh.fyneApp.Preferences(), sh.window, func() {
+		sh.cancelLoads() // Antes de cambiar la fuente: la carga anterior ya no sirve
+		sh.applySettings()
+		sh.loadData()
+	})
 }
 
 // Method implementations (createHeaderContainer, updateHeaderContent, etc.)
 // use 'sh' as the receiver. switchView updated to use cache.
 
 func (sh *skinHunterApp) switchView(viewName string) {
-	if sh.currentView == viewName {
+	if sh.currentView == viewName && viewName != "champion_detail" { // El detalle se recarga con otro campeón (OmniSearch)
 		log.Printf("Already in view '%s', no switch.", viewName)
 		return
 	}
 	log.Printf("Switching view from '%s' to '%s'", sh.currentView, viewName)
+	if sh.currentView == "champion_detail" && viewName != "champion_detail" && sh.championDetailView != nil {
+		sh.championDetailView.Cancel() // Se abandona el campeón: fuera sus peticiones pendientes
+	}
 	sh.isDetailView = false
 	var newContent fyne.CanvasObject
 	headerElements := []fyne.CanvasObject{layout.NewSpacer()}
@@ -133,18 +338,18 @@ func (sh *skinHunterApp) switchView(viewName string) {
 	case "champions_grid":
 		sh.navBackButton.Hide()
 		titleLabel := widget.NewLabelWithStyle("Champions", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
-		headerElements = []fyne.CanvasObject{layout.NewSpacer(), titleLabel, layout.NewSpacer()}
+		patchLabel := widget.NewLabelWithStyle(fmt.Sprintf("Patch %s", data.GetPatch()), fyne.TextAlignTrailing, fyne.TextStyle{Italic: true})
+		headerElements = []fyne.CanvasObject{layout.NewSpacer(), titleLabel, layout.NewSpacer(), patchLabel}
 		if sh.championsGridView != nil {
 			newContent = sh.championsGridView
 		} else if sh.championsDataErr != nil {
-			el := widget.NewLabel(fmt.Sprintf("Error loading champion list:\n%v", sh.championsDataErr))
-			el.Wrapping = fyne.TextWrapWord
-			el.Alignment = fyne.TextAlignCenter
-			newContent = container.NewCenter(el)
+			newContent = ui.NewErrorPanel("Error loading champion list", sh.championsDataErr, sh.loadData)
 		} else if sh.championsData == nil {
 			newContent = container.NewCenter(widget.NewLabel("Champion data not available."))
 		} else {
-			newContent = ui.NewChampionGrid(sh.championsData, func(champ data.ChampionSummary) { sh.showChampionDetail(champ) })
+			ctx, cancel := context.WithCancel(context.Background())
+			sh.gridCancel = cancel
+			newContent = ui.NewChampionGrid(ctx, sh.championsData, func(champ data.ChampionSummary) { sh.showChampionDetail(champ) })
 			sh.championsGridView = newContent
 		}
 
@@ -163,11 +368,35 @@ func (sh *skinHunterApp) switchView(viewName string) {
 		sh.championDetailView.UpdateContent(sh.selectedChampion)
 		newContent = sh.championDetailView
 
+	case "skin_lines":
+		sh.navBackButton.Hide()
+		titleLabel := widget.NewLabelWithStyle("Skin Lines", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		headerElements = []fyne.CanvasObject{layout.NewSpacer(), titleLabel, layout.NewSpacer()}
+		if sh.skinLinesView != nil {
+			newContent = sh.skinLinesView
+		} else {
+			newContent = container.NewCenter(widget.NewLabel("Skin line data not available."))
+		}
+
+	case "skin_query":
+		sh.navBackButton.Hide()
+		titleLabel := widget.NewLabelWithStyle("Skin Filter", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		headerElements = []fyne.CanvasObject{layout.NewSpacer(), titleLabel, layout.NewSpacer()}
+		if sh.skinQueryView != nil {
+			newContent = sh.skinQueryView
+		} else {
+			newContent = container.NewCenter(widget.NewLabel("Skin data not available."))
+		}
+
 	case "installed_view":
 		sh.navBackButton.Hide()
 		titleLabel := widget.NewLabelWithStyle("Installed", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 		headerElements = []fyne.CanvasObject{layout.NewSpacer(), titleLabel, layout.NewSpacer()}
-		newContent = sh.installedView
+		if sh.installedView != nil {
+			newContent = sh.installedView
+		} else {
+			newContent = container.NewCenter(widget.NewLabel("Installed skins not available."))
+		}
 	case "profile_view":
 		sh.navBackButton.Hide()
 		titleLabel := widget.NewLabelWithStyle("Profile", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
@@ -195,7 +424,42 @@ func (sh *skinHunterApp) switchView(viewName string) {
 	}
 	sh.currentView = viewName
 	sh.updateHeaderContent(headerElements...)
+	sh.updateOfflineBanner()
+}
+
+// updateOfflineBanner muestra "offline, data from <date>" cuando el catálogo viene de la caché.
+func (sh *skinHunterApp) updateOfflineBanner() {
+	if sh.offlineBanner == nil {
+		return
+	}
+	st := data.GetCacheStatus()
+	if !st.Offline {
+		sh.offlineBanner.Hide()
+		return
+	}
+	sh.offlineLabel.SetText(fmt.Sprintf("Offline, data from %s", st.DataTime.Local().Format("2006-01-02 15:04")))
+	sh.offlineBanner.Show()
+}
+
+// updateGameBanner avisa de que no se puede instalar mientras la carpeta del juego
+// falte o no sea válida.
+func (sh *skinHunterApp) updateGameBanner() {
+	if sh.gameBanner == nil {
+		return
+	}
+	switch err := game.Ready(); {
+	case err == nil:
+		sh.gameBanner.Hide()
+		return
+	case errors.Is(err, game.ErrNotConfigured):
+		sh.gameLabel.SetText("Game folder not set: installing skins is disabled.")
+	default:
+		log.Printf("WARN: %v", err)
+		sh.gameLabel.SetText("Game folder is invalid: installing skins is disabled.")
+	}
+	sh.gameBanner.Show()
 }
+
 func (sh *skinHunterApp) createHeaderContainer(content *fyne.Container) fyne.CanvasObject { /* ... as before ... */
 	hb := theme.BackgroundColor()
 	bc := theme.ShadowColor()
@@ -227,7 +491,7 @@ func (sh *skinHunterApp) createFooter() fyne.CanvasObject { /* ... as before ...
 		icon   fyne.Resource
 		view   string
 		action func()
-	}{{"Champions", theme.HomeIcon(), "champions_grid", nil}, {"Search", theme.SearchIcon(), "", func() { sh.showOmniSearch() }}, {"Installed", theme.DownloadIcon(), "installed_view", nil}, {"Profile", theme.AccountIcon(), "profile_view", nil}}
+	}{{"Champions", theme.HomeIcon(), "champions_grid", nil}, {"Skin Lines", theme.ColorPaletteIcon(), "skin_lines", nil}, {"Search", theme.SearchIcon(), "", func() { sh.showOmniSearch() }}, {"Filter", theme.ListIcon(), "skin_query", nil}, {"Installed", theme.DownloadIcon(), "installed_view", nil}, {"Profile", theme.AccountIcon(), "profile_view", nil}}
 	btns := make([]fyne.CanvasObject, len(tabs))
 	for i := range tabs {
 		t := tabs[i]
@@ -262,18 +526,17 @@ func (sh *skinHunterApp) showLoading() { /* ... as before ... */
 		sh.updateHeaderContent(layout.NewSpacer(), lt, layout.NewSpacer())
 	}
 }
-func (sh *skinHunterApp) showError(errMsg string) { /* ... as before ... */
+
+// showError muestra err traducido a un mensaje para el usuario; onRetry (si no es nil) se
+// asocia al botón "Retry".
+func (sh *skinHunterApp) showError(title string, err error, onRetry func()) {
 	sh.updateStatus("Error")
 	if sh.centerContent != nil {
-		ei := widget.NewIcon(theme.ErrorIcon())
-		el := widget.NewLabelWithStyle(errMsg, fyne.TextAlignCenter, fyne.TextStyle{})
-		el.Wrapping = fyne.TextWrapWord
-		eb := container.NewVBox(layout.NewSpacer(), container.NewCenter(ei), widget.NewSeparator(), el, layout.NewSpacer())
-		pe := container.NewPadded(eb)
+		pe := ui.NewErrorPanel(title, err, onRetry)
 		sh.centerContent.Objects = []fyne.CanvasObject{pe}
 		sh.centerContent.Refresh()
 		sh.currentView = "error"
-		log.Println("UI state: Error - ", errMsg)
+		log.Printf("UI state: Error - %s: %v", title, err)
 		et := widget.NewLabelWithStyle("Error", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 		sh.updateHeaderContent(layout.NewSpacer(), et, layout.NewSpacer())
 	}
@@ -292,20 +555,20 @@ func (sh *skinHunterApp) goBack() { /* ... as before ... */
 		log.Println("Already at base view.")
 	}
 }
-func (sh *skinHunterApp) showOmniSearch() { /* ... as before ... */
+func (sh *skinHunterApp) showOmniSearch() {
 	log.Println("OmniSearch tab tapped.")
-	si := widget.NewEntry()
-	si.SetPlaceHolder("Search Champions, Skins...")
-	dc := container.NewVBox(widget.NewLabel("OmniSearch (WIP)"), si, widget.NewLabel("Enter search term..."))
-	dialog.ShowCustomConfirm("Search", "Search", "Cancel", dc, func(c bool) {
-		if c {
-			st := si.Text
-			log.Printf("Search WIP: '%s'", st)
-			dialog.ShowInformation("Search", fmt.Sprintf("Search for '%s' not implemented.", st), sh.window)
-		} else {
-			log.Println("Search cancelled.")
-		}
-	}, sh.window)
+	ui.ShowOmniSearch(sh.window, ui.OmniSearchActions{
+		OnChampion: sh.showChampionDetail,
+		OnSkin: func(skin data.Skin) {
+			ui.ShowSkinDialog(skin, skin.Chromas, sh.window)
+		},
+		OnSkinLine: func(line data.SkinLine) {
+			sh.switchView("skin_lines")
+			if sh.skinLinesView != nil {
+				sh.skinLinesView.ShowSkinLine(line.ID)
+			}
+		},
+	})
 }
 
 // --- End of main.go ---
@@ -0,0 +1,227 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+type enc struct{ bytes.Buffer }
+
+func (e *enc) put(vs ...any) {
+	for _, v := range vs {
+		binary.Write(e, binary.LittleEndian, v)
+	}
+}
+
+// quad es un cuadrado de lado 2 en el plano z = 0, partido en dos triángulos.
+var quad = struct {
+	positions [][3]float32
+	indices   []uint16
+}{
+	[][3]float32{{-1, -1, 0}, {1, -1, 0}, {1, 1, 0}, {-1, 1, 0}},
+	[]uint16{0, 1, 2, 0, 2, 3},
+}
+
+// encodeSKN escribe quad como .skn de la versión major, con dos submallas.
+func encodeSKN(major uint16) []byte {
+	var e enc
+	e.put(uint32(sknMagic), major, uint16(1))
+	vertexSize := uint32(sknBasicVertex)
+	if major == 4 {
+		vertexSize = sknBasicVertex + 4 // con color
+	}
+	if major == 0 {
+		e.put(uint32(len(quad.indices)), uint32(len(quad.positions)))
+	} else {
+		e.put(uint32(2))
+		for i, name := range []string{"Body", "Tail"} {
+			var mat [materialSize]byte
+			copy(mat[:], name)
+			e.put(mat, uint32(0), uint32(4), uint32(3*i), uint32(3))
+		}
+		if major == 4 {
+			e.put(uint32(0))
+		}
+		e.put(uint32(len(quad.indices)), uint32(len(quad.positions)))
+		if major == 4 {
+			e.put(vertexSize, uint32(1), [10]float32{})
+		}
+	}
+	e.put(quad.indices)
+	for i, p := range quad.positions {
+		e.put(p, [4]uint8{uint8(i), 0, 0, 0}, [4]float32{1, 0, 0, 0}, [3]float32{0, 0, 1}, [2]float32{p[0], p[1]})
+		if major == 4 {
+			e.put([4]uint8{255, 255, 255, 255})
+		}
+	}
+	return e.Bytes()
+}
+
+func TestParseSKN(t *testing.T) {
+	for _, major := range []uint16{0, 1, 2, 4} {
+		m, err := ParseSKN(encodeSKN(major))
+		if err != nil {
+			t.Fatalf("v%d: %v", major, err)
+		}
+		if len(m.Vertices) != 4 || m.TriangleCount() != 2 || m.Vertices[2].Position != quad.positions[2] || m.Vertices[3].Bones[0] != 3 {
+			t.Errorf("v%d: %+v", major, m)
+		}
+		if major == 0 && (len(m.Submeshes) != 1 || m.Submeshes[0].IndexCount != 6) {
+			t.Errorf("v0 submeshes = %+v", m.Submeshes)
+		}
+		if major > 0 && (len(m.Submeshes) != 2 || m.Submeshes[1].Material != "Tail" || m.Submeshes[1].StartIndex != 3) {
+			t.Errorf("v%d submeshes = %+v", major, m.Submeshes)
+		}
+	}
+	min, max := mustSKN(t).Bounds()
+	if min != [3]float32{-1, -1, 0} || max != [3]float32{1, 1, 0} {
+		t.Errorf("Bounds = %v %v", min, max)
+	}
+
+	good := encodeSKN(4)
+	if _, err := ParseSKN([]byte("PROP")); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("bad magic: %v", err)
+	}
+	bad := append([]byte(nil), good...)
+	binary.LittleEndian.PutUint16(bad[4:], 3)
+	if _, err := ParseSKN(bad); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("version 3: %v", err)
+	}
+	for n := 4; n < len(good); n += 13 {
+		if _, err := ParseSKN(good[:n]); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("truncated at %d: %v", n, err)
+		}
+	}
+	// Un índice fuera de los vértices.
+	bad = append([]byte(nil), good...)
+	idx := bytes.Index(bad, []byte{0, 0, 1, 0, 2, 0, 0, 0, 2, 0, 3, 0})
+	bad[idx+2] = 9
+	if _, err := ParseSKN(bad); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("index out of range: %v", err)
+	}
+}
+
+func mustSKN(t *testing.T) *Mesh {
+	t.Helper()
+	m, err := ParseSKN(encodeSKN(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// encodeSKL escribe un esqueleto de dos huesos (raíz y un hijo) con dos influencias.
+func encodeSKL() []byte {
+	const headerSize = 4*3 + 2 + 2 + 4 + 11*4
+	jointsOffset := headerSize
+	influencesOffset := jointsOffset + 2*sklJointSize
+	namesOffset := influencesOffset + 2*2
+	names := []string{"Root", "Tail1", "ahri_skin05", "ASSETS/Ahri.skl"}
+	nameAt := make([]int, len(names))
+	off := namesOffset
+	for i, n := range names {
+		nameAt[i] = off
+		off += len(n) + 1
+	}
+
+	var e enc
+	e.put(uint32(off), uint32(sklMagic), uint32(0), uint16(0), uint16(2), uint32(2))
+	e.put(int32(jointsOffset), int32(-1), int32(influencesOffset), int32(nameAt[2]), int32(nameAt[3]), int32(-1), [5]int32{})
+	for i, parent := range []int16{-1, 0} {
+		start := e.Len()
+		e.put(uint16(0), int16(i), parent, uint16(0), uint32(0), float32(1))
+		e.put([3]float32{0, float32(i), 0}, [3]float32{1, 1, 1}, [4]float32{0, 0, 0, 1})
+		e.put([3]float32{}, [3]float32{1, 1, 1}, [4]float32{0, 0, 0, 1})
+		field := start + 96
+		e.put(int32(nameAt[i] - field))
+	}
+	e.put([]uint16{0, 1})
+	for _, n := range names {
+		e.WriteString(n)
+		e.WriteByte(0)
+	}
+	return e.Bytes()
+}
+
+func TestParseSKL(t *testing.T) {
+	s, err := ParseSKL(encodeSKL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "ahri_skin05" || s.AssetName != "ASSETS/Ahri.skl" || len(s.Joints) != 2 || len(s.Influences) != 2 {
+		t.Fatalf("skeleton = %+v", s)
+	}
+	if j := s.Joints[1]; j.Name != "Tail1" || j.Parent != 0 || j.ID != 1 || j.LocalTranslation != [3]float32{0, 1, 0} {
+		t.Errorf("joint 1 = %+v", j)
+	}
+	m := mustSKN(t)
+	if s.Compatible(m) {
+		t.Errorf("mesh uses bone 3 but the skeleton has 2 influences")
+	}
+	for i := range m.Vertices {
+		m.Vertices[i].Bones[0] = 1
+	}
+	if !s.Compatible(m) {
+		t.Errorf("mesh should be compatible")
+	}
+
+	if _, err := ParseSKL([]byte("r3d2sklt\x01\x00\x00\x00")); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("legacy: %v", err)
+	}
+	if _, err := ParseSKL(encodeSKN(4)); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("skn as skl: %v", err)
+	}
+	bad := encodeSKL()
+	if _, err := ParseSKL(bad[:200]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("truncated: %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	m := mustSKN(t)
+	img := Render(m, Options{Size: 64})
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 64 {
+		t.Fatalf("bounds = %v", img.Bounds())
+	}
+	// El cuadrado llena la imagen salvo el margen.
+	if c := img.NRGBAAt(32, 32); c.A != 255 || c.R == 0 {
+		t.Errorf("center = %v", c)
+	}
+	if c := img.NRGBAAt(1, 1); c.A != 0 {
+		t.Errorf("corner should be transparent: %v", c)
+	}
+	// Visto de canto (90°) el cuadrado es una línea: casi todo queda vacío.
+	side := Render(m, Options{Size: 64, Yaw: 90, Background: color.White})
+	if c := side.NRGBAAt(10, 32); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("side view should show the background: %v", c)
+	}
+
+	// En alambre se dibuja la diagonal pero no el interior.
+	wire := Render(m, Options{Size: 64, Wireframe: true, Color: color.NRGBA{255, 0, 0, 255}})
+	if c := wire.NRGBAAt(31, 32); c.A == 0 {
+		t.Errorf("diagonal edge missing: %v", c)
+	}
+	if c := wire.NRGBAAt(40, 20); c.A != 0 {
+		t.Errorf("wireframe interior filled: %v", c)
+	}
+
+	// Mallas vacías o degeneradas no fallan.
+	Render(&Mesh{}, Options{})
+	flat := &Mesh{Vertices: []Vertex{{}, {}, {}}, Indices: []uint16{0, 1, 2}}
+	Render(flat, Options{})
+	nan := &Mesh{Vertices: []Vertex{{Position: [3]float32{float32(math.NaN()), 0, 0}}, {Position: [3]float32{1, 1, 1}}, {}}, Indices: []uint16{0, 1, 2}}
+	Render(nan, Options{Wireframe: true})
+
+	var buf bytes.Buffer
+	if err := RenderPNG(&buf, m, Options{Size: 32}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("invalid PNG: %v", err)
+	}
+}
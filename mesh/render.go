@@ -0,0 +1,253 @@
+// skinhunter/mesh/render.go
+package mesh
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Rasterizador por software para las miniaturas: proyección ortográfica de la malla en
+// pose de reposo, con z-buffer y sombreado plano por triángulo (o solo las aristas en
+// modo alambre). El modo sombreado se dibuja al doble de resolución y se reduce para
+// suavizar los bordes. No necesita GPU.
+
+// Options configura Render.
+type Options struct {
+	Size       int         // Lado de la imagen en píxeles (0 = 256, máximo 2048)
+	Wireframe  bool        // Solo las aristas, sin ocultar las de detrás
+	Yaw        float64     // Giro de la cámara alrededor del eje Y, en grados
+	Pitch      float64     // Inclinación de la cámara alrededor del eje X, en grados
+	Color      color.Color // Color del modelo (nil = gris azulado)
+	Background color.Color // Fondo (nil = transparente)
+}
+
+const (
+	defaultSize = 256
+	maxSize     = 2048
+	margin      = 0.06 // Borde libre alrededor del modelo, en fracción del lado
+	ambient     = 0.3
+	supersample = 2
+)
+
+var (
+	defaultColor = color.NRGBA{0xa8, 0xb8, 0xd0, 0xff}
+	// lightDir es la luz principal, desde arriba a la izquierda y detrás de la cámara.
+	lightDir = normalize([3]float64{-0.4, 0.6, 1})
+)
+
+// Render dibuja m según opts. Una malla vacía da una imagen con solo el fondo.
+func Render(m *Mesh, opts Options) *image.NRGBA {
+	size := opts.Size
+	if size <= 0 {
+		size = defaultSize
+	}
+	size = min(size, maxSize)
+	base := defaultColor
+	if opts.Color != nil {
+		base = color.NRGBAModel.Convert(opts.Color).(color.NRGBA)
+	}
+	var bg color.NRGBA
+	if opts.Background != nil {
+		bg = color.NRGBAModel.Convert(opts.Background).(color.NRGBA)
+	}
+
+	ss := supersample
+	if opts.Wireframe {
+		ss = 1
+	}
+	c := newCanvas(size*ss, bg)
+	pts := project(m, opts, float64(size*ss))
+	if len(pts) > 0 {
+		if opts.Wireframe {
+			for t := 0; t+2 < len(m.Indices); t += 3 {
+				a, b, d := pts[m.Indices[t]], pts[m.Indices[t+1]], pts[m.Indices[t+2]]
+				c.line(a, b, base)
+				c.line(b, d, base)
+				c.line(d, a, base)
+			}
+		} else {
+			for t := 0; t+2 < len(m.Indices); t += 3 {
+				a, b, d := pts[m.Indices[t]], pts[m.Indices[t+1]], pts[m.Indices[t+2]]
+				c.triangle(a, b, d, shade(a, b, d, base))
+			}
+		}
+	}
+	return c.downsample(ss)
+}
+
+// RenderPNG escribe en w la miniatura de m en PNG.
+func RenderPNG(w io.Writer, m *Mesh, opts Options) error {
+	return png.Encode(w, Render(m, opts))
+}
+
+// point es un vértice girado: x, y en píxeles y z la profundidad (mayor = más cerca),
+// junto con la posición girada sin escalar para calcular normales.
+type point struct {
+	x, y, z float64
+	world   [3]float64
+}
+
+// project gira los vértices según la cámara y los encaja en un cuadrado de lado size.
+func project(m *Mesh, opts Options, size float64) []point {
+	if len(m.Vertices) == 0 {
+		return nil
+	}
+	yaw, pitch := opts.Yaw*math.Pi/180, opts.Pitch*math.Pi/180
+	sy, cy, sp, cp := math.Sin(yaw), math.Cos(yaw), math.Sin(pitch), math.Cos(pitch)
+	pts := make([]point, len(m.Vertices))
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for i, v := range m.Vertices {
+		x, y, z := float64(v.Position[0]), float64(v.Position[1]), float64(v.Position[2])
+		x, z = x*cy+z*sy, -x*sy+z*cy
+		y, z = y*cp-z*sp, y*sp+z*cp
+		pts[i] = point{x: x, y: y, z: z, world: [3]float64{x, y, z}}
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	extent := math.Max(maxX-minX, maxY-minY)
+	if extent <= 0 || math.IsNaN(extent) || math.IsInf(extent, 0) {
+		return nil
+	}
+	scale := size * (1 - 2*margin) / extent
+	cx, cyy := (minX+maxX)/2, (minY+maxY)/2
+	for i := range pts {
+		pts[i].x = (pts[i].x-cx)*scale + size/2
+		pts[i].y = size/2 - (pts[i].y-cyy)*scale
+	}
+	return pts
+}
+
+// shade devuelve el color del triángulo con una luz direccional a dos caras (el orden
+// de los vértices no siempre es fiable).
+func shade(a, b, c point, base color.NRGBA) color.NRGBA {
+	u := sub(b.world, a.world)
+	v := sub(c.world, a.world)
+	n := normalize([3]float64{u[1]*v[2] - u[2]*v[1], u[2]*v[0] - u[0]*v[2], u[0]*v[1] - u[1]*v[0]})
+	k := ambient + (1-ambient)*math.Abs(n[0]*lightDir[0]+n[1]*lightDir[1]+n[2]*lightDir[2])
+	return color.NRGBA{uint8(float64(base.R) * k), uint8(float64(base.G) * k), uint8(float64(base.B) * k), 255}
+}
+
+func sub(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+
+func normalize(v [3]float64) [3]float64 {
+	l := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	if l == 0 {
+		return v
+	}
+	return [3]float64{v[0] / l, v[1] / l, v[2] / l}
+}
+
+// canvas es el lienzo de trabajo con su z-buffer.
+type canvas struct {
+	size int
+	pix  []color.NRGBA
+	z    []float64
+}
+
+func newCanvas(size int, bg color.NRGBA) *canvas {
+	c := &canvas{size: size, pix: make([]color.NRGBA, size*size), z: make([]float64, size*size)}
+	for i := range c.pix {
+		c.pix[i], c.z[i] = bg, math.Inf(-1)
+	}
+	return c
+}
+
+func edge(a, b point, x, y float64) float64 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// triangle rellena abc con col donde esté más cerca que lo ya dibujado.
+func (c *canvas) triangle(a, b, d point, col color.NRGBA) {
+	area := edge(a, b, d.x, d.y)
+	if math.Abs(area) < 1e-9 || math.IsNaN(area) {
+		return
+	}
+	x0 := max(0, int(math.Floor(math.Min(a.x, math.Min(b.x, d.x)))))
+	x1 := min(c.size-1, int(math.Ceil(math.Max(a.x, math.Max(b.x, d.x)))))
+	y0 := max(0, int(math.Floor(math.Min(a.y, math.Min(b.y, d.y)))))
+	y1 := min(c.size-1, int(math.Ceil(math.Max(a.y, math.Max(b.y, d.y)))))
+	for y := y0; y <= y1; y++ {
+		py := float64(y) + 0.5
+		for x := x0; x <= x1; x++ {
+			px := float64(x) + 0.5
+			w0, w1, w2 := edge(b, d, px, py)/area, edge(d, a, px, py)/area, edge(a, b, px, py)/area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			z := w0*a.z + w1*b.z + w2*d.z
+			if i := y*c.size + x; z > c.z[i] {
+				c.z[i], c.pix[i] = z, col
+			}
+		}
+	}
+}
+
+// line dibuja el segmento ab (Bresenham).
+func (c *canvas) line(a, b point, col color.NRGBA) {
+	if math.IsNaN(a.x + a.y + b.x + b.y) {
+		return
+	}
+	x0, y0, x1, y1 := int(a.x), int(a.y), int(b.x), int(b.y)
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		if x0 >= 0 && y0 >= 0 && x0 < c.size && y0 < c.size {
+			c.pix[y0*c.size+x0] = col
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// downsample reduce el lienzo ss veces promediando en alfa premultiplicado.
+func (c *canvas) downsample(ss int) *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, c.size/ss, c.size/ss))
+	n := ss * ss
+	for y := 0; y < c.size/ss; y++ {
+		for x := 0; x < c.size/ss; x++ {
+			var r, g, b, a int
+			for sy := 0; sy < ss; sy++ {
+				for sx := 0; sx < ss; sx++ {
+					p := c.pix[(y*ss+sy)*c.size+x*ss+sx]
+					r, g, b, a = r+int(p.R)*int(p.A), g+int(p.G)*int(p.A), b+int(p.B)*int(p.A), a+int(p.A)
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			o := out.PixOffset(x, y)
+			out.Pix[o], out.Pix[o+1], out.Pix[o+2], out.Pix[o+3] = uint8(r/a), uint8(g/a), uint8(b/a), uint8(a/n)
+		}
+	}
+	return out
+}
+
+// --- End of render.go ---
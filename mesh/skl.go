@@ -0,0 +1,147 @@
+// skinhunter/mesh/skl.go
+package mesh
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Esqueletos .skl en el formato actual (el antiguo "r3d2sklt" no se lee):
+//
+//	u32 tamaño, u32 0x22FD4FC3, u32 versión (0)
+//	u16 flags, u16 huesos, u32 influencias
+//	i32 × 11 desplazamientos: huesos, índices de huesos, influencias, nombre, nombre
+//	      del recurso, nombres de huesos y 5 reservados
+//
+// Cada hueso ocupa 100 bytes: u16 flags, i16 id, i16 padre, u16 relleno, u32 hash del
+// nombre, f32 radio, traslación, escala y rotación locales, traslación, escala y
+// rotación inversas de la pose de reposo, e i32 con el nombre (relativo a ese campo).
+// Las influencias traducen los índices de hueso de los vértices del .skn a ids de hueso.
+
+const (
+	sklMagic     = 0x22fd4fc3
+	sklJointSize = 100
+	sklNameMax   = 256
+)
+
+// Joint es un hueso del esqueleto.
+type Joint struct {
+	Name   string
+	ID     int16
+	Parent int16 // -1 en la raíz
+	Radius float32
+
+	LocalTranslation [3]float32
+	LocalScale       [3]float32
+	LocalRotation    [4]float32 // Cuaternión x, y, z, w
+
+	InverseBindTranslation [3]float32
+	InverseBindScale       [3]float32
+	InverseBindRotation    [4]float32
+}
+
+// Skeleton es un .skl decodificado.
+type Skeleton struct {
+	Name       string
+	AssetName  string
+	Joints     []Joint
+	Influences []uint16 // Vertex.Bones -> Joint.ID
+}
+
+// ParseSKL decodifica un .skl.
+func ParseSKL(b []byte) (*Skeleton, error) {
+	if len(b) >= 8 && string(b[:8]) == "r3d2sklt" {
+		return nil, fmt.Errorf("%w: legacy SKL", ErrUnsupportedVersion)
+	}
+	r := &reader{b: b}
+	r.u32() // tamaño del fichero
+	if r.u32() != sklMagic || r.err != nil {
+		return nil, ErrBadMagic
+	}
+	if v := r.u32(); v != 0 && r.err == nil {
+		return nil, fmt.Errorf("%w: SKL %d", ErrUnsupportedVersion, v)
+	}
+	r.u16() // flags
+	jointCount, influenceCount := int(r.u16()), r.count()
+	var offsets [11]int
+	for i := range offsets {
+		offsets[i] = int(int32(r.u32()))
+	}
+	jointsOffset, influencesOffset, nameOffset, assetNameOffset := offsets[0], offsets[2], offsets[3], offsets[4]
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	s := &Skeleton{Name: cstring(b, nameOffset), AssetName: cstring(b, assetNameOffset)}
+	if jointCount > 0 {
+		r.pos = jointsOffset
+		if jointsOffset < 0 || int64(jointCount)*sklJointSize > int64(len(b)-jointsOffset) {
+			return nil, fmt.Errorf("%w: %d joints at offset %d", ErrCorrupt, jointCount, jointsOffset)
+		}
+		s.Joints = make([]Joint, jointCount)
+		for i := range s.Joints {
+			j := &s.Joints[i]
+			r.u16() // flags
+			j.ID, j.Parent = int16(r.u16()), int16(r.u16())
+			r.u16() // relleno
+			r.u32() // hash del nombre
+			j.Radius = r.f32()
+			j.LocalTranslation = r.vec3()
+			j.LocalScale = r.vec3()
+			j.LocalRotation = r.quat()
+			j.InverseBindTranslation = r.vec3()
+			j.InverseBindScale = r.vec3()
+			j.InverseBindRotation = r.quat()
+			field := r.pos
+			j.Name = cstring(b, field+int(int32(r.u32())))
+			if int(j.Parent) >= jointCount || j.Parent < -1 {
+				r.fail("joint %d has parent %d", i, j.Parent)
+			}
+		}
+	}
+	if influenceCount > 0 {
+		r.pos = influencesOffset
+		if influencesOffset < 0 || int64(influenceCount)*2 > int64(len(b)-influencesOffset) {
+			return nil, fmt.Errorf("%w: %d influences at offset %d", ErrCorrupt, influenceCount, influencesOffset)
+		}
+		s.Influences = make([]uint16, influenceCount)
+		for i := range s.Influences {
+			s.Influences[i] = r.u16()
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s, nil
+}
+
+func (r *reader) vec3() [3]float32 { return [3]float32{r.f32(), r.f32(), r.f32()} }
+
+func (r *reader) quat() [4]float32 { return [4]float32{r.f32(), r.f32(), r.f32(), r.f32()} }
+
+// cstring lee el texto terminado en 0 que empieza en off ("" si off no es válido).
+func cstring(b []byte, off int) string {
+	if off <= 0 || off >= len(b) {
+		return ""
+	}
+	s := b[off:min(len(b), off+sklNameMax)]
+	if i := bytes.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return string(s)
+}
+
+// Compatible indica si los huesos de los vértices de m están en las influencias de s,
+// es decir, si el esqueleto es el de la malla.
+func (s *Skeleton) Compatible(m *Mesh) bool {
+	for _, v := range m.Vertices {
+		for i, bone := range v.Bones {
+			if v.Weights[i] != 0 && int(bone) >= len(s.Influences) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// --- End of skl.go ---
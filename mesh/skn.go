@@ -0,0 +1,243 @@
+// skinhunter/mesh/skn.go
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Mallas .skn (simple skin): vértices en pose de reposo con hasta 4 huesos por vértice,
+// índices de 16 bits y una lista de submallas, cada una con su material.
+//
+//	u32 0x00112233, u16 major, u16 minor
+//	major 0:  u32 índices, u32 vértices (una sola submalla)
+//	major 1+: u32 n, n × (char[64] material, u32 primer vértice, u32 vértices,
+//	          u32 primer índice, u32 índices)
+//	          [major 4: u32 flags] u32 índices, u32 vértices
+//	          [major 4: u32 tamaño de vértice, u32 tipo, caja (6 × f32), esfera (4 × f32)]
+//	u16 × índices, vértices
+//
+// Cada vértice es posición (3 × f32), huesos (4 × u8), pesos (4 × f32), normal
+// (3 × f32) y UV (2 × f32), más color (4 × u8) y tangente (4 × f32) según el tipo.
+
+var (
+	// ErrBadMagic indica que el fichero no es un .skn o .skl.
+	ErrBadMagic = errors.New("not a SKN/SKL file")
+	// ErrUnsupportedVersion indica una versión que no se lee.
+	ErrUnsupportedVersion = errors.New("unsupported mesh version")
+	// ErrCorrupt indica datos truncados o índices fuera de rango.
+	ErrCorrupt = errors.New("corrupt mesh")
+)
+
+// UserMessage da el texto para el usuario de un modelo que no se puede previsualizar.
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrUnsupportedVersion):
+		return "This model uses a format version that can't be previewed yet.", true
+	case errors.Is(err, ErrBadMagic), errors.Is(err, ErrCorrupt):
+		return "The model is damaged or isn't a SKN/SKL file.", true
+	}
+	return "", false
+}
+
+const (
+	sknMagic       = 0x00112233
+	sknBasicVertex = 52
+	materialSize   = 64
+	submeshSize    = materialSize + 16
+	// maxElements limita vértices, índices y submallas para no reservar memoria de más.
+	maxElements = 1 << 24
+)
+
+// Submesh es una parte de la malla con un material.
+type Submesh struct {
+	Material    string
+	StartVertex int
+	VertexCount int
+	StartIndex  int
+	IndexCount  int
+}
+
+// Vertex es un vértice en pose de reposo.
+type Vertex struct {
+	Position [3]float32
+	Bones    [4]uint8 // Índices en Skeleton.Influences
+	Weights  [4]float32
+	Normal   [3]float32
+	UV       [2]float32
+}
+
+// Mesh es un .skn decodificado.
+type Mesh struct {
+	Major, Minor uint16
+	Submeshes    []Submesh
+	Indices      []uint16 // Tres por triángulo
+	Vertices     []Vertex
+}
+
+// TriangleCount devuelve el número de triángulos.
+func (m *Mesh) TriangleCount() int { return len(m.Indices) / 3 }
+
+// Bounds devuelve la caja que contiene todos los vértices.
+func (m *Mesh) Bounds() (min, max [3]float32) {
+	for i := range min {
+		min[i], max[i] = math.MaxFloat32, -math.MaxFloat32
+	}
+	for _, v := range m.Vertices {
+		for i, c := range v.Position {
+			min[i] = float32(math.Min(float64(min[i]), float64(c)))
+			max[i] = float32(math.Max(float64(max[i]), float64(c)))
+		}
+	}
+	return min, max
+}
+
+// ParseSKN decodifica un .skn.
+func ParseSKN(b []byte) (*Mesh, error) {
+	r := &reader{b: b}
+	if r.u32() != sknMagic || r.err != nil {
+		return nil, ErrBadMagic
+	}
+	m := &Mesh{Major: r.u16(), Minor: r.u16()}
+	if r.err != nil {
+		return nil, r.err
+	}
+	switch m.Major {
+	case 0, 1, 2, 4:
+	default:
+		return nil, fmt.Errorf("%w: SKN %d.%d", ErrUnsupportedVersion, m.Major, m.Minor)
+	}
+	var indexCount, vertexCount int
+	vertexSize := sknBasicVertex
+	if m.Major == 0 {
+		indexCount, vertexCount = r.count(), r.count()
+		m.Submeshes = []Submesh{{Material: "default", VertexCount: vertexCount, IndexCount: indexCount}}
+	} else {
+		n := r.count()
+		if n > (len(b)-r.pos)/submeshSize {
+			r.fail("%d submeshes", n)
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			name := r.bytes(materialSize)
+			if j := bytes.IndexByte(name, 0); j >= 0 {
+				name = name[:j]
+			}
+			m.Submeshes = append(m.Submeshes, Submesh{
+				Material:    string(name),
+				StartVertex: r.count(),
+				VertexCount: r.count(),
+				StartIndex:  r.count(),
+				IndexCount:  r.count(),
+			})
+		}
+		if m.Major == 4 {
+			r.u32() // flags
+		}
+		indexCount, vertexCount = r.count(), r.count()
+		if m.Major == 4 {
+			vertexSize = r.count()
+			r.u32()         // tipo de vértice; el tamaño basta para saltar lo que no se usa
+			r.bytes(10 * 4) // caja y esfera envolventes
+			if r.err == nil && vertexSize < sknBasicVertex {
+				r.fail("vertex size %d", vertexSize)
+			}
+		}
+	}
+	if r.err == nil && (indexCount%3 != 0 || int64(indexCount)*2+int64(vertexCount)*int64(vertexSize) > int64(len(b)-r.pos)) {
+		r.fail("%d indices and %d vertices of %d bytes", indexCount, vertexCount, vertexSize)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	m.Indices = make([]uint16, indexCount)
+	for i := range m.Indices {
+		m.Indices[i] = r.u16()
+	}
+	m.Vertices = make([]Vertex, vertexCount)
+	for i := range m.Vertices {
+		v := &m.Vertices[i]
+		start := r.pos
+		v.Position = [3]float32{r.f32(), r.f32(), r.f32()}
+		copy(v.Bones[:], r.bytes(4))
+		v.Weights = [4]float32{r.f32(), r.f32(), r.f32(), r.f32()}
+		v.Normal = [3]float32{r.f32(), r.f32(), r.f32()}
+		v.UV = [2]float32{r.f32(), r.f32()}
+		r.pos = start + vertexSize
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validate comprueba que submallas e índices no se salen de los vértices.
+func (m *Mesh) validate() error {
+	for _, s := range m.Submeshes {
+		if s.StartVertex+s.VertexCount > len(m.Vertices) || s.StartIndex+s.IndexCount > len(m.Indices) {
+			return fmt.Errorf("%w: submesh %q out of range", ErrCorrupt, s.Material)
+		}
+	}
+	for _, i := range m.Indices {
+		if int(i) >= len(m.Vertices) {
+			return fmt.Errorf("%w: index %d with %d vertices", ErrCorrupt, i, len(m.Vertices))
+		}
+	}
+	return nil
+}
+
+// reader lee little-endian de b; tras el primer error todas las lecturas devuelven cero.
+type reader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s at offset %d", ErrCorrupt, fmt.Sprintf(format, args...), r.pos)
+	}
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos < 0 || r.pos+n > len(r.b) {
+		r.fail("truncated")
+		return nil
+	}
+	out := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return out
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) f32() float32 { return math.Float32frombits(r.u32()) }
+
+// count lee un u32 que es un número de elementos.
+func (r *reader) count() int {
+	n := r.u32()
+	if n > maxElements {
+		r.fail("count %d too large", n)
+		return 0
+	}
+	return int(n)
+}
+
+// --- End of skn.go ---
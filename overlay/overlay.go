@@ -0,0 +1,397 @@
+// skinhunter/overlay/overlay.go
+package overlay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"skinhunter/fantome"
+	"skinhunter/game"
+	"skinhunter/installs"
+	"skinhunter/wad"
+)
+
+// Overlay con las instalaciones activas, como el "mkoverlay" de cslol: por cada WAD
+// del juego que toca algún paquete se escribe una copia completa (las entradas del
+// juego más las de los paquetes) en la misma ruta relativa a la carpeta Game, p. ej.
+// DATA/FINAL/Champions/Ahri.wad.client. Los ficheros de un paquete van al WAD del
+// juego con el mismo nombre o, si no lo hay (RAW/, WAD sueltos), a los WAD del juego
+// que ya tienen ese hash. Si varios paquetes traen el mismo fichero para un WAD gana
+// el de mayor prioridad y se anota el conflicto. El resumen se guarda en overlay.json
+// dentro del overlay.
+
+// ErrNoDir indica que no hay carpeta donde escribir el overlay.
+var ErrNoDir = errors.New("no overlay directory")
+
+// UserMessage da el texto para el usuario de los errores del overlay.
+func UserMessage(err error) (msg string, ok bool) {
+	if errors.Is(err, ErrNoDir) {
+		return "There is no folder to write the overlay to.", true
+	}
+	return "", false
+}
+
+const resultFile = "overlay.json"
+
+// Dir es donde la app escribe el overlay: <UserConfigDir>/skinhunter/overlay.
+var Dir string
+
+func init() {
+	if base, err := os.UserConfigDir(); err == nil {
+		Dir = filepath.Join(base, "skinhunter", "overlay")
+	} else {
+		Dir = filepath.Join(os.TempDir(), "skinhunter-overlay")
+	}
+}
+
+// Mod identifica una instalación dentro del resultado.
+type Mod struct {
+	ItemID   int    `json:"itemId"`
+	Name     string `json:"name"`
+	Checksum string `json:"checksum"`
+}
+
+func modOf(e installs.Entry) Mod {
+	return Mod{ItemID: e.ItemID(), Name: e.Name, Checksum: e.Checksum}
+}
+
+// Wad es un WAD escrito en el overlay.
+type Wad struct {
+	Path  string `json:"path"`  // Relativa al overlay (y a la carpeta Game), con "/"
+	Files int    `json:"files"` // Ficheros que vienen de los paquetes
+}
+
+// Conflict es un fichero de un WAD que traen varios paquetes.
+type Conflict struct {
+	Wad    string `json:"wad"`
+	Hash   uint64 `json:"hash"` // wad.HashPath de la ruta
+	Winner Mod    `json:"winner"`
+	Losers []Mod  `json:"losers"` // De mayor a menor prioridad
+}
+
+// Unmatched cuenta los ficheros de un paquete que no van a ningún WAD del juego.
+type Unmatched struct {
+	Mod   Mod    `json:"mod"`
+	Wad   string `json:"wad"` // Como viene en el paquete
+	Files int    `json:"files"`
+}
+
+// Result es el resumen de un Build.
+type Result struct {
+	Dir       string      `json:"-"`
+	BuiltAt   time.Time   `json:"builtAt"`
+	Mods      []Mod       `json:"mods"` // Los aplicados, de mayor a menor prioridad
+	Wads      []Wad       `json:"wads"`
+	Conflicts []Conflict  `json:"conflicts,omitempty"`
+	Unmatched []Unmatched `json:"unmatched,omitempty"`
+}
+
+// UpToDate indica si el overlay corresponde a entries (en orden de carga): mismas
+// instalaciones activas, en el mismo orden y con los mismos paquetes.
+func (r *Result) UpToDate(entries []installs.Entry) bool {
+	var mods []Mod
+	for _, e := range entries {
+		if !e.Disabled {
+			mods = append(mods, modOf(e))
+		}
+	}
+	if len(mods) != len(r.Mods) {
+		return false
+	}
+	for i, m := range mods {
+		if m.ItemID != r.Mods[i].ItemID || m.Checksum != r.Mods[i].Checksum {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadResult lee el resumen del overlay de dir; nil, nil si no se ha construido.
+func LoadResult(dir string) (*Result, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, resultFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Result
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", resultFile, err)
+	}
+	r.Dir = dir
+	return &r, nil
+}
+
+// file es el fichero que gana para un hash de un WAD. Solo se guarda dónde está: los
+// datos se leen del paquete al escribir el WAD.
+type file struct {
+	owner int    // Índice del paquete en Result.Mods
+	pkg   string // Ruta del paquete
+	f     fantome.File
+}
+
+// Build escribe en dir el overlay de entries, que van de mayor a menor prioridad (las
+// desactivadas se saltan), sobre los WAD de inst. Sustituye lo que hubiera en dir
+// solo si todo sale bien. progress (puede ser nil) recibe los WAD escritos.
+func Build(ctx context.Context, inst game.Installation, entries []installs.Entry, dir string, progress func(done, total int)) (*Result, error) {
+	if dir == "" {
+		return nil, ErrNoDir
+	}
+	idx, err := indexGame(inst)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Dir: dir}
+	targets := make(map[string]map[uint64]*file)
+	conflicts := make(map[string]map[uint64]*Conflict)
+	unmatched := make(map[Unmatched]int) // Clave con Files = 0: paquete + WAD
+	for _, e := range entries {
+		if e.Disabled {
+			continue
+		}
+		owner := len(res.Mods)
+		mod := modOf(e)
+		res.Mods = append(res.Mods, mod)
+		err := fantome.Walk(e.PackagePath, func(f fantome.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rels := idx.targets(f)
+			if len(rels) == 0 {
+				unmatched[Unmatched{Mod: mod, Wad: f.Wad}]++
+				return nil
+			}
+			for _, rel := range rels {
+				h := f.Entry.PathHash
+				if h == subchunkTOC(rel) {
+					continue // La tabla de subchunks es la del WAD del juego
+				}
+				if targets[rel] == nil {
+					targets[rel] = make(map[uint64]*file)
+				}
+				prev, ok := targets[rel][h]
+				if !ok {
+					targets[rel][h] = &file{owner: owner, pkg: e.PackagePath, f: f}
+					continue
+				}
+				if prev.owner == owner {
+					continue
+				}
+				if conflicts[rel] == nil {
+					conflicts[rel] = make(map[uint64]*Conflict)
+				}
+				c := conflicts[rel][h]
+				if c == nil {
+					c = &Conflict{Wad: rel, Hash: h, Winner: res.Mods[prev.owner]}
+					conflicts[rel][h] = c
+				}
+				if last := len(c.Losers) - 1; last < 0 || c.Losers[last].ItemID != mod.ItemID {
+					c.Losers = append(c.Losers, mod)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+	}
+
+	rels := make([]string, 0, len(targets))
+	for rel := range targets {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*Result, error) {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	for i, rel := range rels {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		if progress != nil {
+			progress(i, len(rels))
+		}
+		if err := writeWad(ctx, filepath.Join(inst.GameDir, filepath.FromSlash(rel)), filepath.Join(tmp, filepath.FromSlash(rel)), targets[rel]); err != nil {
+			return fail(fmt.Errorf("%s: %w", rel, err))
+		}
+		res.Wads = append(res.Wads, Wad{Path: rel, Files: len(targets[rel])})
+		for _, c := range conflicts[rel] {
+			res.Conflicts = append(res.Conflicts, *c)
+		}
+	}
+	if progress != nil {
+		progress(len(rels), len(rels))
+	}
+	sort.Slice(res.Conflicts, func(i, j int) bool {
+		a, b := res.Conflicts[i], res.Conflicts[j]
+		if a.Wad != b.Wad {
+			return a.Wad < b.Wad
+		}
+		return a.Hash < b.Hash
+	})
+	for u, n := range unmatched {
+		u.Files = n
+		res.Unmatched = append(res.Unmatched, u)
+	}
+	sort.Slice(res.Unmatched, func(i, j int) bool {
+		a, b := res.Unmatched[i], res.Unmatched[j]
+		if a.Mod.ItemID != b.Mod.ItemID {
+			return a.Mod.ItemID < b.Mod.ItemID
+		}
+		return a.Wad < b.Wad
+	})
+
+	res.BuiltAt = time.Now()
+	raw, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		return fail(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, resultFile), raw, 0o644); err != nil {
+		return fail(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fail(fmt.Errorf("write overlay: %w", err))
+	}
+	log.Printf("Overlay: %d packages, %d WADs, %d conflicts in %s", len(res.Mods), len(res.Wads), len(res.Conflicts), dir)
+	return res, nil
+}
+
+// writeWad escribe en dest el WAD base con files encima. Las entradas se leen de base
+// y de los paquetes de una en una mientras se escribe dest.
+func writeWad(ctx context.Context, base, dest string, files map[uint64]*file) error {
+	r, err := wad.Open(base)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w := wad.NewWriter()
+	for _, e := range r.Entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if files[e.PathHash] != nil {
+			continue
+		}
+		w.AddRawFunc(e, func() ([]byte, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return r.ReadRaw(e)
+		})
+	}
+	sources := make(map[string]*fantome.Source)
+	defer func() {
+		for _, src := range sources {
+			src.Close()
+		}
+	}()
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		src := sources[f.pkg]
+		if src == nil {
+			if src, err = fantome.OpenSource(f.pkg); err != nil {
+				return err
+			}
+			sources[f.pkg] = src
+		}
+		read := func() ([]byte, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return src.Read(f.f)
+		}
+		if f.f.Entry.Compression != wad.CompressionNone {
+			w.AddRawFunc(f.f.Entry, read)
+			continue
+		}
+		w.AddFunc(f.f.Entry.PathHash, wad.CompressionZstd, read)
+	}
+	return w.WriteFile(dest)
+}
+
+// subchunkTOC es el hash de la tabla de subchunks del WAD rel
+// ("data/final/champions/ahri.wad.subchunktoc").
+func subchunkTOC(rel string) uint64 {
+	return wad.HashPath(strings.TrimSuffix(strings.ToLower(rel), ".client") + ".subchunktoc")
+}
+
+// gameIndex localiza los WAD del juego, por nombre y (al primer uso) por hash.
+type gameIndex struct {
+	gameDir string
+	rels    []string          // Rutas relativas a Game, con "/", ordenadas
+	byName  map[string]string // Nombre en minúsculas -> ruta
+	byHash  map[uint64][]string
+}
+
+func indexGame(inst game.Installation) (*gameIndex, error) {
+	idx := &gameIndex{gameDir: inst.GameDir, byName: make(map[string]string)}
+	err := filepath.WalkDir(inst.DataDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".wad.client") {
+			return nil
+		}
+		rel, err := filepath.Rel(inst.GameDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		idx.rels = append(idx.rels, rel)
+		if name := strings.ToLower(d.Name()); idx.byName[name] == "" {
+			idx.byName[name] = rel
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("index game WADs: %w", err)
+	}
+	return idx, nil
+}
+
+// targets devuelve los WAD del juego a los que va f.
+func (idx *gameIndex) targets(f fantome.File) []string {
+	if rel, ok := idx.byName[strings.ToLower(f.Wad)]; ok && f.Wad != "RAW" {
+		return []string{rel}
+	}
+	if idx.byHash == nil {
+		idx.byHash = make(map[uint64][]string)
+		for _, rel := range idx.rels {
+			r, err := wad.Open(filepath.Join(idx.gameDir, filepath.FromSlash(rel)))
+			if err != nil {
+				log.Printf("WARN: Overlay: skipping game WAD %s: %v", rel, err)
+				continue
+			}
+			for _, e := range r.Entries {
+				idx.byHash[e.PathHash] = append(idx.byHash[e.PathHash], rel)
+			}
+			r.Close()
+		}
+	}
+	return idx.byHash[f.Entry.PathHash]
+}
+
+// --- End of overlay.go ---
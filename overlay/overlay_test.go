@@ -0,0 +1,205 @@
+package overlay
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skinhunter/game"
+	"skinhunter/installs"
+	"skinhunter/wad"
+)
+
+var (
+	skin0 = "data/characters/ahri/skins/skin0.bin"
+	skin1 = "data/characters/ahri/skins/skin1.bin"
+	tex   = "assets/characters/ahri/skins/base/ahri_tx.tex"
+	mapX  = "data/maps/x.bin"
+)
+
+// writeTestWad escribe en p un WAD con files (ruta -> contenido).
+func writeTestWad(t *testing.T, p string, files map[string]string) {
+	t.Helper()
+	w := wad.NewWriter()
+	for name, data := range files {
+		if err := w.Add(wad.HashPath(name), []byte(data), wad.CompressionZstd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteFile(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFantome escribe en p un .fantome con files (ruta en el zip -> contenido).
+func writeFantome(t *testing.T, p string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files["META/info.json"] = `{"Name":"test"}`
+	for name, data := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testGame crea una instalación con Ahri.wad.client y Map11.wad.client.
+func testGame(t *testing.T) game.Installation {
+	t.Helper()
+	root := t.TempDir()
+	final := filepath.Join(root, "Game", "DATA", "FINAL")
+	writeTestWad(t, filepath.Join(final, "Champions", "Ahri.wad.client"), map[string]string{skin0: "base0", skin1: "base1", tex: "basetex"})
+	writeTestWad(t, filepath.Join(final, "Maps", "Shipping", "Map11.wad.client"), map[string]string{mapX: "basex"})
+	os.WriteFile(filepath.Join(root, "LeagueClient.exe"), nil, 0o644)
+	inst, err := game.Validate(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inst
+}
+
+func readOverlay(t *testing.T, p string) map[string]string {
+	t.Helper()
+	r, err := wad.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	out := make(map[string]string)
+	for _, name := range []string{skin0, skin1, tex, mapX} {
+		if data, err := r.Extract(wad.HashPath(name)); err == nil {
+			out[name] = string(data)
+		}
+	}
+	return out
+}
+
+func TestBuild(t *testing.T) {
+	inst := testGame(t)
+	pkgs := t.TempDir()
+	a, b, c := filepath.Join(pkgs, "a.fantome"), filepath.Join(pkgs, "103015.client"), filepath.Join(pkgs, "c.fantome")
+	writeFantome(t, a, map[string]string{
+		"WAD/Ahri.wad.client/" + skin0:    "A0",
+		"RAW/" + mapX:                     "Ax",
+		"WAD/Unknown.wad.client/data/y.x": "?",
+	})
+	writeTestWad(t, b, map[string]string{skin0: "B0", skin1: "B1"})
+	writeFantome(t, c, map[string]string{"WAD/Ahri.wad.client/" + skin1: "C1"})
+	entries := []installs.Entry{
+		{ModID: 1, Name: "A", PackagePath: a, Checksum: "a"},
+		{SkinID: 103015, Name: "B", PackagePath: b, Checksum: "b"},
+		{ModID: 3, Name: "C", PackagePath: c, Checksum: "c", Disabled: true},
+	}
+	dir := filepath.Join(t.TempDir(), "overlay")
+	var last [2]int
+	res, err := Build(context.Background(), inst, entries, dir, func(done, total int) { last = [2]int{done, total} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != [2]int{2, 2} {
+		t.Errorf("last progress = %v", last)
+	}
+
+	ahri := readOverlay(t, filepath.Join(dir, "DATA", "FINAL", "Champions", "Ahri.wad.client"))
+	if ahri[skin0] != "A0" || ahri[skin1] != "B1" || ahri[tex] != "basetex" {
+		t.Errorf("Ahri overlay = %v", ahri)
+	}
+	if m := readOverlay(t, filepath.Join(dir, "DATA", "FINAL", "Maps", "Shipping", "Map11.wad.client")); m[mapX] != "Ax" {
+		t.Errorf("Map11 overlay = %v", m)
+	}
+	if len(res.Mods) != 2 || res.Mods[0].Name != "A" || res.Mods[1].Name != "B" {
+		t.Errorf("Mods = %+v", res.Mods)
+	}
+	if len(res.Wads) != 2 || res.Wads[0].Path != "DATA/FINAL/Champions/Ahri.wad.client" || res.Wads[0].Files != 2 {
+		t.Errorf("Wads = %+v", res.Wads)
+	}
+	if len(res.Conflicts) != 1 {
+		t.Fatalf("Conflicts = %+v", res.Conflicts)
+	}
+	if cf := res.Conflicts[0]; cf.Hash != wad.HashPath(skin0) || cf.Winner.Name != "A" || len(cf.Losers) != 1 || cf.Losers[0].Name != "B" {
+		t.Errorf("conflict = %+v", cf)
+	}
+	if len(res.Unmatched) != 1 || res.Unmatched[0].Wad != "Unknown.wad.client" || res.Unmatched[0].Files != 1 {
+		t.Errorf("Unmatched = %+v", res.Unmatched)
+	}
+
+	saved, err := LoadResult(dir)
+	if err != nil || saved == nil || len(saved.Conflicts) != 1 || saved.Dir != dir {
+		t.Fatalf("LoadResult = %+v, %v", saved, err)
+	}
+	if !saved.UpToDate(entries) {
+		t.Errorf("overlay should be up to date")
+	}
+	entries[0], entries[1] = entries[1], entries[0]
+	if saved.UpToDate(entries) {
+		t.Errorf("reordering should make the overlay stale")
+	}
+
+	// Con B delante gana B, y sin nada activo el overlay queda vacío.
+	if _, err = Build(context.Background(), inst, entries, dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ahri := readOverlay(t, filepath.Join(dir, "DATA", "FINAL", "Champions", "Ahri.wad.client")); ahri[skin0] != "B0" {
+		t.Errorf("after reorder skin0 = %q", ahri[skin0])
+	}
+	if _, err = Build(context.Background(), inst, nil, dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "DATA")); !os.IsNotExist(err) {
+		t.Errorf("empty build left old WADs: %v", err)
+	}
+	if r, _ := LoadResult(t.TempDir()); r != nil {
+		t.Errorf("LoadResult without overlay = %+v", r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Build(ctx, inst, entries, dir, nil); err == nil {
+		t.Errorf("Build with a cancelled context should fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, resultFile)); err != nil {
+		t.Errorf("failed build removed the previous overlay: %v", err)
+	}
+}
+
+func TestWriteWadStopsOnCancel(t *testing.T) {
+	inst := testGame(t)
+	pkg := filepath.Join(t.TempDir(), "a.fantome")
+	writeFantome(t, pkg, map[string]string{"WAD/Ahri.wad.client/" + skin0: "A0"})
+	entries := []installs.Entry{{ModID: 1, Name: "A", PackagePath: pkg, Checksum: "a"}}
+	dir := filepath.Join(t.TempDir(), "overlay")
+	if _, err := Build(context.Background(), inst, entries, dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Se cancela en cuanto se empieza a escribir el primer WAD.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := Build(ctx, inst, entries, dir, func(done, total int) {
+		if done == 0 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Build err = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(dir + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("cancelled build left its temp dir: %v", err)
+	}
+	if ahri := readOverlay(t, filepath.Join(dir, "DATA", "FINAL", "Champions", "Ahri.wad.client")); ahri[skin0] != "A0" {
+		t.Errorf("cancelled build changed the previous overlay: %v", ahri)
+	}
+}
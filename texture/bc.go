@@ -0,0 +1,98 @@
+// skinhunter/texture/bc.go
+package texture
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// Decodificación de bloques BC1 (DXT1) y BC3 (DXT5). Cada bloque codifica 4×4 píxeles:
+// BC1 son dos colores RGB565 y un índice de 2 bits por píxel; BC3 añade delante un
+// bloque de alfa con dos valores y un índice de 3 bits por píxel.
+
+// decodeBlocks rellena img con los bloques de data, recortando los de los bordes si el
+// tamaño no es múltiplo de 4.
+func decodeBlocks(img *image.NRGBA, data []byte, f Format) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	blockSize := 8
+	if f == FormatBC3 {
+		blockSize = 16
+	}
+	var px [16][4]uint8
+	for by := 0; by < (h+3)/4; by++ {
+		for bx := 0; bx < (w+3)/4; bx++ {
+			block := data[(by*((w+3)/4)+bx)*blockSize:]
+			if f == FormatBC3 {
+				decodeColor(block[8:16], &px, false)
+				decodeAlpha(block[:8], &px)
+			} else {
+				decodeColor(block[:8], &px, true)
+			}
+			for i := range px {
+				x, y := bx*4+i%4, by*4+i/4
+				if x >= w || y >= h {
+					continue
+				}
+				o := img.PixOffset(x, y)
+				copy(img.Pix[o:o+4], px[i][:])
+			}
+		}
+	}
+}
+
+// rgb565 expande un color de 16 bits a 8 bits por canal.
+func rgb565(c uint16) [3]int {
+	r, g, b := int(c>>11&31), int(c>>5&63), int(c&31)
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
+
+// decodeColor decodifica un bloque de color de 8 bytes. En BC1, si el primer color no
+// es mayor que el segundo, el bloque usa 3 colores y el índice 3 es transparente.
+func decodeColor(b []byte, px *[16][4]uint8, bc1 bool) {
+	c0, c1 := binary.LittleEndian.Uint16(b), binary.LittleEndian.Uint16(b[2:])
+	p0, p1 := rgb565(c0), rgb565(c1)
+	var palette [4][4]uint8
+	for i := 0; i < 3; i++ {
+		palette[0][i], palette[1][i] = uint8(p0[i]), uint8(p1[i])
+		if c0 > c1 || !bc1 {
+			palette[2][i] = uint8((2*p0[i] + p1[i]) / 3)
+			palette[3][i] = uint8((p0[i] + 2*p1[i]) / 3)
+		} else {
+			palette[2][i] = uint8((p0[i] + p1[i]) / 2)
+		}
+	}
+	palette[0][3], palette[1][3], palette[2][3] = 255, 255, 255
+	if c0 > c1 || !bc1 {
+		palette[3][3] = 255
+	}
+	indices := binary.LittleEndian.Uint32(b[4:])
+	for i := range px {
+		px[i] = palette[indices>>(2*i)&3]
+	}
+}
+
+// decodeAlpha decodifica un bloque de alfa BC3 de 8 bytes sobre px.
+func decodeAlpha(b []byte, px *[16][4]uint8) {
+	a0, a1 := int(b[0]), int(b[1])
+	var alpha [8]uint8
+	alpha[0], alpha[1] = uint8(a0), uint8(a1)
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			alpha[i+1] = uint8(((7-i)*a0 + i*a1) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			alpha[i+1] = uint8(((5-i)*a0 + i*a1) / 5)
+		}
+		alpha[6], alpha[7] = 0, 255
+	}
+	var indices uint64
+	for i := 7; i >= 2; i-- {
+		indices = indices<<8 | uint64(b[i])
+	}
+	for i := range px {
+		px[i][3] = alpha[indices>>(3*i)&7]
+	}
+}
+
+// --- End of bc.go ---
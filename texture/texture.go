@@ -0,0 +1,276 @@
+// skinhunter/texture/texture.go
+package texture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+)
+
+// Texturas del juego (.tex) y DDS decodificadas en CPU a image.NRGBA, para previsualizarlas
+// sin GPU. Solo se decodifica el nivel de mipmap de mayor tamaño.
+//
+// TEX: "TEX\0", u16 ancho, u16 alto, u8 ?, u8 formato, u8 ?, u8 con mipmaps; después los
+// datos, con los mipmaps del más pequeño al más grande (el grande va al final).
+// DDS: "DDS ", cabecera de 124 bytes (más 20 si el fourCC es DX10) y los datos, con el
+// mipmap más grande al principio.
+
+var (
+	// ErrUnknownFormat indica que los datos no son ni TEX ni DDS.
+	ErrUnknownFormat = errors.New("not a TEX or DDS texture")
+	// ErrUnsupportedFormat indica un formato de píxel que no se decodifica (ETC, BC7...).
+	ErrUnsupportedFormat = errors.New("unsupported texture format")
+	// ErrCorrupt indica una cabecera inválida o datos truncados.
+	ErrCorrupt = errors.New("corrupt texture")
+)
+
+// UserMessage da el texto para el usuario de una textura que no se puede previsualizar.
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrUnsupportedFormat):
+		return "This texture uses a format that can't be previewed yet.", true
+	case errors.Is(err, ErrUnknownFormat), errors.Is(err, ErrCorrupt):
+		return "The texture is damaged or isn't a TEX/DDS file.", true
+	}
+	return "", false
+}
+
+// MaxDimension limita el ancho y el alto que se aceptan.
+const MaxDimension = 16384
+
+// Format es la codificación de los píxeles.
+type Format int
+
+const (
+	FormatBC1   Format = iota + 1 // DXT1: bloques 4×4 de 8 bytes, alfa de 1 bit
+	FormatBC3                     // DXT5: bloques 4×4 de 16 bytes con alfa interpolado
+	FormatBGRA8                   // 4 bytes por píxel, B G R A
+	FormatRGBA8                   // 4 bytes por píxel, R G B A
+)
+
+var formatNames = map[Format]string{FormatBC1: "BC1", FormatBC3: "BC3", FormatBGRA8: "BGRA8", FormatRGBA8: "RGBA8"}
+
+func (f Format) String() string {
+	if n, ok := formatNames[f]; ok {
+		return n
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// size devuelve los bytes de una imagen de w×h en el formato f.
+func (f Format) size(w, h int) int {
+	switch f {
+	case FormatBC1:
+		return ((w + 3) / 4) * ((h + 3) / 4) * 8
+	case FormatBC3:
+		return ((w + 3) / 4) * ((h + 3) / 4) * 16
+	}
+	return w * h * 4
+}
+
+// Info describe una textura.
+type Info struct {
+	Width, Height int
+	Format        Format
+	Mipmaps       bool
+}
+
+// Decode decodifica una textura TEX o DDS según su cabecera.
+func Decode(b []byte) (image.Image, error) {
+	switch {
+	case len(b) >= 4 && string(b[:4]) == "TEX\x00":
+		return DecodeTEX(b)
+	case len(b) >= 4 && string(b[:4]) == "DDS ":
+		return DecodeDDS(b)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Formatos de TEX.
+const (
+	texETC1  = 1
+	texETC2  = 2
+	texETC2A = 3
+	texDXT1  = 10
+	texDXT5  = 12
+	texBGRA8 = 20
+)
+
+// ParseTEX lee la cabecera de un TEX.
+func ParseTEX(b []byte) (Info, error) {
+	if len(b) < 4 || string(b[:4]) != "TEX\x00" {
+		return Info{}, ErrUnknownFormat
+	}
+	if len(b) < 12 {
+		return Info{}, fmt.Errorf("%w: truncated header", ErrCorrupt)
+	}
+	info := Info{
+		Width:   int(binary.LittleEndian.Uint16(b[4:])),
+		Height:  int(binary.LittleEndian.Uint16(b[6:])),
+		Mipmaps: b[11] != 0,
+	}
+	switch b[9] {
+	case texDXT1:
+		info.Format = FormatBC1
+	case texDXT5:
+		info.Format = FormatBC3
+	case texBGRA8:
+		info.Format = FormatBGRA8
+	case texETC1, texETC2, texETC2A:
+		return info, fmt.Errorf("%w: ETC (TEX format %d)", ErrUnsupportedFormat, b[9])
+	default:
+		return info, fmt.Errorf("%w: TEX format %d", ErrUnsupportedFormat, b[9])
+	}
+	return info, checkSize(info)
+}
+
+// DecodeTEX decodifica un TEX.
+func DecodeTEX(b []byte) (image.Image, error) {
+	info, err := ParseTEX(b)
+	if err != nil {
+		return nil, err
+	}
+	data := b[12:]
+	n := info.Format.size(info.Width, info.Height)
+	if len(data) < n {
+		return nil, fmt.Errorf("%w: %d bytes of data, want %d", ErrCorrupt, len(data), n)
+	}
+	// Con mipmaps el nivel completo es el último.
+	return decode(info, data[len(data)-n:]), nil
+}
+
+// Campos de la cabecera DDS (desplazamientos desde el principio del fichero).
+const (
+	ddsHeaderSize  = 4 + 124
+	ddsDX10Size    = 20
+	ddsPixelFlags  = 80
+	ddsFourCC      = 84
+	ddsRGBBitCount = 88
+	ddsRMask       = 92
+	ddsFlagAlpha   = 0x1
+	ddsFlagFourCC  = 0x4
+	ddsFlagRGB     = 0x40
+	ddsMipMapCount = 28
+	dxgiBC1        = 71
+	dxgiBC1SRGB    = 72
+	dxgiBC3        = 77
+	dxgiBC3SRGB    = 78
+	dxgiRGBA8      = 28
+	dxgiRGBA8SRGB  = 29
+	dxgiBGRA8      = 87
+	dxgiBGRA8SRGB  = 91
+)
+
+// ParseDDS lee la cabecera de un DDS y devuelve también dónde empiezan los datos.
+func ParseDDS(b []byte) (Info, int, error) {
+	if len(b) < 4 || string(b[:4]) != "DDS " {
+		return Info{}, 0, ErrUnknownFormat
+	}
+	if len(b) < ddsHeaderSize || binary.LittleEndian.Uint32(b[4:]) != 124 {
+		return Info{}, 0, fmt.Errorf("%w: bad DDS header", ErrCorrupt)
+	}
+	u32 := func(off int) uint32 { return binary.LittleEndian.Uint32(b[off:]) }
+	info := Info{
+		Height:  int(u32(12)),
+		Width:   int(u32(16)),
+		Mipmaps: u32(ddsMipMapCount) > 1,
+	}
+	offset := ddsHeaderSize
+	flags := u32(ddsPixelFlags)
+	fourCC := string(b[ddsFourCC : ddsFourCC+4])
+	switch {
+	case flags&ddsFlagFourCC != 0 && fourCC == "DXT1":
+		info.Format = FormatBC1
+	case flags&ddsFlagFourCC != 0 && fourCC == "DXT5":
+		info.Format = FormatBC3
+	case flags&ddsFlagFourCC != 0 && fourCC == "DX10":
+		if len(b) < ddsHeaderSize+ddsDX10Size {
+			return info, 0, fmt.Errorf("%w: truncated DX10 header", ErrCorrupt)
+		}
+		offset += ddsDX10Size
+		switch dxgi := u32(ddsHeaderSize); dxgi {
+		case dxgiBC1, dxgiBC1SRGB:
+			info.Format = FormatBC1
+		case dxgiBC3, dxgiBC3SRGB:
+			info.Format = FormatBC3
+		case dxgiBGRA8, dxgiBGRA8SRGB:
+			info.Format = FormatBGRA8
+		case dxgiRGBA8, dxgiRGBA8SRGB:
+			info.Format = FormatRGBA8
+		default:
+			return info, 0, fmt.Errorf("%w: DXGI format %d", ErrUnsupportedFormat, dxgi)
+		}
+	case flags&ddsFlagFourCC != 0:
+		return info, 0, fmt.Errorf("%w: DDS %q", ErrUnsupportedFormat, fourCC)
+	case flags&ddsFlagRGB != 0 && u32(ddsRGBBitCount) == 32:
+		r, g, bl, a := u32(ddsRMask), u32(ddsRMask+4), u32(ddsRMask+8), u32(ddsRMask+12)
+		if flags&ddsFlagAlpha == 0 {
+			a = 0
+		}
+		switch {
+		case r == 0x00ff0000 && g == 0x0000ff00 && bl == 0x000000ff && (a == 0xff000000 || a == 0):
+			info.Format = FormatBGRA8
+		case r == 0x000000ff && g == 0x0000ff00 && bl == 0x00ff0000 && (a == 0xff000000 || a == 0):
+			info.Format = FormatRGBA8
+		default:
+			return info, 0, fmt.Errorf("%w: DDS RGB masks %08x %08x %08x %08x", ErrUnsupportedFormat, r, g, bl, a)
+		}
+	default:
+		return info, 0, fmt.Errorf("%w: DDS pixel format (flags %#x, %d bits)", ErrUnsupportedFormat, flags, u32(ddsRGBBitCount))
+	}
+	return info, offset, checkSize(info)
+}
+
+// DecodeDDS decodifica un DDS.
+func DecodeDDS(b []byte) (image.Image, error) {
+	info, offset, err := ParseDDS(b)
+	if err != nil {
+		return nil, err
+	}
+	data := b[offset:]
+	n := info.Format.size(info.Width, info.Height)
+	if len(data) < n {
+		return nil, fmt.Errorf("%w: %d bytes of data, want %d", ErrCorrupt, len(data), n)
+	}
+	img := decode(info, data[:n])
+	// Sin canal alfa se decodifica como opaco.
+	if (info.Format == FormatBGRA8 || info.Format == FormatRGBA8) && !ddsHasAlpha(b) {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img, nil
+}
+
+func ddsHasAlpha(b []byte) bool {
+	if string(b[ddsFourCC:ddsFourCC+4]) == "DX10" {
+		return true
+	}
+	return binary.LittleEndian.Uint32(b[ddsPixelFlags:])&ddsFlagAlpha != 0 && binary.LittleEndian.Uint32(b[ddsRMask+12:]) != 0
+}
+
+func checkSize(info Info) error {
+	if info.Width <= 0 || info.Height <= 0 || info.Width > MaxDimension || info.Height > MaxDimension {
+		return fmt.Errorf("%w: size %dx%d", ErrCorrupt, info.Width, info.Height)
+	}
+	return nil
+}
+
+// decode convierte data (exactamente el nivel completo) a NRGBA.
+func decode(info Info, data []byte) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, info.Width, info.Height))
+	switch info.Format {
+	case FormatBC1, FormatBC3:
+		decodeBlocks(img, data, info.Format)
+	case FormatBGRA8:
+		for i := 0; i+3 < len(data); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = data[i+2], data[i+1], data[i], data[i+3]
+		}
+	case FormatRGBA8:
+		copy(img.Pix, data)
+	}
+	return img
+}
+
+// --- End of texture.go ---
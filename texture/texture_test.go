@@ -0,0 +1,170 @@
+package texture
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func texHeader(w, h int, format uint8, mipmaps bool) []byte {
+	b := []byte("TEX\x00")
+	b = binary.LittleEndian.AppendUint16(b, uint16(w))
+	b = binary.LittleEndian.AppendUint16(b, uint16(h))
+	m := uint8(0)
+	if mipmaps {
+		m = 1
+	}
+	return append(b, 1, format, 0, m)
+}
+
+// ddsHeader construye una cabecera DDS con fourCC o, si fourCC es "", BGRA de 32 bits.
+func ddsHeader(w, h int, fourCC string, alpha bool) []byte {
+	b := make([]byte, ddsHeaderSize)
+	copy(b, "DDS ")
+	binary.LittleEndian.PutUint32(b[4:], 124)
+	binary.LittleEndian.PutUint32(b[12:], uint32(h))
+	binary.LittleEndian.PutUint32(b[16:], uint32(w))
+	binary.LittleEndian.PutUint32(b[76:], 32)
+	if fourCC != "" {
+		binary.LittleEndian.PutUint32(b[ddsPixelFlags:], ddsFlagFourCC)
+		copy(b[ddsFourCC:], fourCC)
+		return b
+	}
+	flags := uint32(ddsFlagRGB)
+	if alpha {
+		flags |= ddsFlagAlpha
+		binary.LittleEndian.PutUint32(b[ddsRMask+12:], 0xff000000)
+	}
+	binary.LittleEndian.PutUint32(b[ddsPixelFlags:], flags)
+	binary.LittleEndian.PutUint32(b[ddsRGBBitCount:], 32)
+	binary.LittleEndian.PutUint32(b[ddsRMask:], 0x00ff0000)
+	binary.LittleEndian.PutUint32(b[ddsRMask+4:], 0x0000ff00)
+	binary.LittleEndian.PutUint32(b[ddsRMask+8:], 0x000000ff)
+	return b
+}
+
+// bc1Block crea un bloque con los colores c0 y c1 (RGB565) y el mismo índice en todos
+// los píxeles salvo el primero, que usa first.
+func bc1Block(c0, c1 uint16, index, first uint32) []byte {
+	var idx uint32
+	for i := 0; i < 16; i++ {
+		idx |= index << (2 * i)
+	}
+	idx = idx&^3 | first
+	b := binary.LittleEndian.AppendUint16(nil, c0)
+	b = binary.LittleEndian.AppendUint16(b, c1)
+	return binary.LittleEndian.AppendUint32(b, idx)
+}
+
+func nrgba(img image.Image, x, y int) color.NRGBA {
+	return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+}
+
+func TestBC1(t *testing.T) {
+	const red, blue = 0xf800, 0x001f
+	// 4 colores (c0 > c1): índice 2 = 2/3 rojo + 1/3 azul.
+	img, err := Decode(append(texHeader(4, 4, texDXT1, false), bc1Block(red, blue, 2, 0)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nrgba(img, 0, 0); got != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("pixel 0 = %v", got)
+	}
+	if got := nrgba(img, 3, 3); got != (color.NRGBA{170, 0, 85, 255}) {
+		t.Errorf("interpolated = %v", got)
+	}
+	// 3 colores (c0 <= c1): índice 3 transparente, índice 2 la media.
+	img, err = Decode(append(texHeader(4, 4, texDXT1, false), bc1Block(blue, red, 3, 2)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nrgba(img, 1, 0); got.A != 0 {
+		t.Errorf("punch-through alpha = %v", got)
+	}
+	if got := nrgba(img, 0, 0); got != (color.NRGBA{127, 0, 127, 255}) {
+		t.Errorf("3-color midpoint = %v", got)
+	}
+}
+
+func TestBC3AndMipmaps(t *testing.T) {
+	// Bloque de alfa: a0=255, a1=0, índice 1 (a1) en todos salvo el primero (a0).
+	alpha := []byte{255, 0}
+	var idx uint64
+	for i := 1; i < 16; i++ {
+		idx |= 1 << (3 * i)
+	}
+	for i := 0; i < 6; i++ {
+		alpha = append(alpha, byte(idx>>(8*i)))
+	}
+	block := append(alpha, bc1Block(0x07e0, 0x07e0, 0, 0)...) // verde
+	// 5×3 con mipmaps: 2×1 bloques para el nivel completo, precedidos por los mipmaps
+	// pequeños (2×1 y 1×1, un bloque cada uno) con basura.
+	data := texHeader(5, 3, texDXT5, true)
+	data = append(data, make([]byte, 32)...)
+	data = append(data, block...)
+	data = append(data, block...)
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 5, 3) {
+		t.Fatalf("bounds = %v", img.Bounds())
+	}
+	if got := nrgba(img, 0, 0); got != (color.NRGBA{0, 255, 0, 255}) {
+		t.Errorf("pixel 0 = %v", got)
+	}
+	if got := nrgba(img, 4, 2); got != (color.NRGBA{0, 255, 0, 0}) {
+		t.Errorf("last pixel = %v", got)
+	}
+}
+
+func TestDDS(t *testing.T) {
+	// BGRA sin comprimir de 2×1 con alfa.
+	data := append(ddsHeader(2, 1, "", true), 1, 2, 3, 4, 5, 6, 7, 8)
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nrgba(img, 0, 0); got != (color.NRGBA{3, 2, 1, 4}) {
+		t.Errorf("BGRA pixel = %v", got)
+	}
+	// Sin canal alfa se trata como opaco.
+	img, err = Decode(append(ddsHeader(2, 1, "", false), 1, 2, 3, 0, 5, 6, 7, 0))
+	if err != nil || nrgba(img, 1, 0) != (color.NRGBA{7, 6, 5, 255}) {
+		t.Errorf("opaque BGRA = %v, %v", nrgba(img, 1, 0), err)
+	}
+	// DXT1 y DX10/BC1; el mipmap grande va primero.
+	dx10 := binary.LittleEndian.AppendUint32(ddsHeader(4, 4, "DX10", false), dxgiBC1)
+	dx10 = append(dx10, make([]byte, ddsDX10Size-4)...)
+	for _, hdr := range [][]byte{ddsHeader(4, 4, "DXT1", false), dx10} {
+		data := append(hdr, bc1Block(0xffff, 0, 0, 0)...)
+		data = append(data, make([]byte, 8)...) // mipmaps
+		img, err := Decode(data)
+		if err != nil || nrgba(img, 2, 2) != (color.NRGBA{255, 255, 255, 255}) {
+			t.Errorf("%q: %v, %v", hdr[ddsFourCC:ddsFourCC+4], img, err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"unknown", []byte("\x89PNG"), ErrUnknownFormat},
+		{"etc1", append(texHeader(4, 4, texETC1, false), make([]byte, 8)...), ErrUnsupportedFormat},
+		{"truncated", append(texHeader(8, 8, texDXT5, false), make([]byte, 16)...), ErrCorrupt},
+		{"zero size", texHeader(0, 4, texBGRA8, false), ErrCorrupt},
+		{"short header", []byte("TEX\x00\x04"), ErrCorrupt},
+		{"bc7", ddsHeader(4, 4, "BC7 ", false), ErrUnsupportedFormat},
+		{"dds truncated", append(ddsHeader(4, 4, "DXT5", false), make([]byte, 8)...), ErrCorrupt},
+	}
+	for _, c := range cases {
+		if _, err := Decode(c.data); !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
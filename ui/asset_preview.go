@@ -0,0 +1,232 @@
+// skinhunter/ui/asset_preview.go
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"path"
+	"strings"
+
+	"skinhunter/fantome"
+	"skinhunter/installs"
+	"skinhunter/mesh"
+	"skinhunter/texture"
+	"skinhunter/wad"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// meshThumbnailSize es el lado de las miniaturas de mallas.
+const meshThumbnailSize = 512
+
+// meshYaw gira un poco la cámara para que las mallas no se vean completamente planas.
+const meshYaw = 25
+
+// previewable indica si assetPreview sabe mostrar el fichero p.
+func previewable(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".tex", ".dds", ".skn":
+		return true
+	}
+	return false
+}
+
+// assetPreview muestra un fichero de un paquete instalado: las texturas decodificadas y
+// las mallas dibujadas en CPU (sombreadas o en alambre), con opción de exportar la
+// imagen a PNG.
+type assetPreview struct {
+	ctx    context.Context
+	parent fyne.Window
+	seq    int // Descarta resultados de cargas anteriores
+
+	mesh      *mesh.Mesh // Malla mostrada, para volver a dibujarla al cambiar el modo
+	meshInfo  string
+	current   image.Image
+	currentOf string
+
+	image     *canvas.Image
+	status    *widget.Label
+	wireframe *widget.Check
+	exportBtn *widget.Button
+	content   fyne.CanvasObject
+}
+
+func newAssetPreview(ctx context.Context, parent fyne.Window) *assetPreview {
+	p := &assetPreview{ctx: ctx, parent: parent}
+	p.image = canvas.NewImageFromImage(nil)
+	p.image.FillMode = canvas.ImageFillContain
+	p.image.SetMinSize(fyne.NewSize(160, 160))
+	p.status = widget.NewLabel("")
+	p.status.Wrapping = fyne.TextWrapWord
+	p.wireframe = widget.NewCheck("Wireframe", func(bool) { p.renderMesh() })
+	p.wireframe.Hide()
+	p.exportBtn = widget.NewButtonWithIcon("Export PNG", theme.DocumentSaveIcon(), p.export)
+	p.exportBtn.Disable()
+	bottom := container.NewVBox(p.status, container.NewHBox(p.exportBtn, p.wireframe))
+	p.content = container.NewBorder(nil, bottom, nil, nil, p.image)
+	return p
+}
+
+// Clear vacía la vista previa y muestra msg.
+func (p *assetPreview) Clear(msg string) {
+	p.seq++
+	p.mesh, p.meshInfo = nil, ""
+	p.setImage(nil, "")
+	p.wireframe.Hide()
+	p.status.SetText(msg)
+}
+
+func (p *assetPreview) setImage(img image.Image, of string) {
+	p.current, p.currentOf = img, of
+	p.image.Image = img
+	p.image.Refresh()
+	if img == nil {
+		p.exportBtn.Disable()
+	} else {
+		p.exportBtn.Enable()
+	}
+}
+
+// Show lee el fichero filePath (hash) del paquete de e y lo muestra.
+func (p *assetPreview) Show(e installs.Entry, filePath string, hash uint64) {
+	p.Clear("Loading " + path.Base(filePath) + "...")
+	seq, wire := p.seq, p.wireframe.Checked
+	isMesh := strings.EqualFold(path.Ext(filePath), ".skn")
+	go func() {
+		var img image.Image
+		var m *mesh.Mesh
+		var info string
+		raw, err := fantome.ReadFile(e.PackagePath, hash)
+		if err == nil && isMesh {
+			m, err = mesh.ParseSKN(raw)
+			if err == nil {
+				info = meshSummary(e, filePath, m)
+				img = mesh.Render(m, mesh.Options{Size: meshThumbnailSize, Yaw: meshYaw, Wireframe: wire})
+			}
+		} else if err == nil {
+			img, err = texture.Decode(raw)
+			if err == nil {
+				b := img.Bounds()
+				info = fmt.Sprintf("%s (%d×%d)", path.Base(filePath), b.Dx(), b.Dy())
+			}
+		}
+		if p.ctx.Err() != nil {
+			return
+		}
+		fyne.Do(func() {
+			if seq != p.seq {
+				return
+			}
+			if err != nil {
+				log.Printf("ERROR: Preview %s of %s: %v", filePath, e.Name, err)
+				p.status.SetText(ErrorMessage(err))
+				return
+			}
+			p.mesh, p.meshInfo = m, info
+			if m != nil {
+				p.wireframe.Show()
+			}
+			p.setImage(img, filePath)
+			p.status.SetText(info)
+		})
+	}()
+}
+
+// meshSummary describe m: vértices, triángulos, materiales y, si el paquete trae el
+// .skl con el mismo nombre, sus huesos.
+func meshSummary(e installs.Entry, filePath string, m *mesh.Mesh) string {
+	materials := make([]string, len(m.Submeshes))
+	for i, s := range m.Submeshes {
+		materials[i] = s.Material
+	}
+	info := fmt.Sprintf("%s: %d vertices, %d triangles, %d submeshes (%s)",
+		path.Base(filePath), len(m.Vertices), m.TriangleCount(), len(m.Submeshes), strings.Join(materials, ", "))
+	sklPath := strings.TrimSuffix(filePath, path.Ext(filePath)) + ".skl"
+	raw, err := fantome.ReadFile(e.PackagePath, wad.HashPath(sklPath))
+	if err != nil {
+		return info + "."
+	}
+	s, err := mesh.ParseSKL(raw)
+	if err != nil {
+		log.Printf("WARN: Skeleton %s: %v", sklPath, err)
+		return info + "."
+	}
+	info += fmt.Sprintf(", %d joints", len(s.Joints))
+	if !s.Compatible(m) {
+		info += " (the skeleton doesn't match the mesh)"
+	}
+	return info + "."
+}
+
+// renderMesh vuelve a dibujar la malla mostrada en el modo elegido.
+func (p *assetPreview) renderMesh() {
+	m, of, info := p.mesh, p.currentOf, p.meshInfo
+	if m == nil {
+		return
+	}
+	p.seq++
+	seq, wire := p.seq, p.wireframe.Checked
+	go func() {
+		img := mesh.Render(m, mesh.Options{Size: meshThumbnailSize, Yaw: meshYaw, Wireframe: wire})
+		fyne.Do(func() {
+			if seq != p.seq {
+				return
+			}
+			p.setImage(img, of)
+			p.status.SetText(info)
+		})
+	}()
+}
+
+// export guarda la imagen mostrada como PNG.
+func (p *assetPreview) export() {
+	img, name := p.current, p.currentOf
+	if img == nil {
+		return
+	}
+	d := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			return
+		}
+		go func() {
+			err := png.Encode(wc, img)
+			if closeErr := wc.Close(); err == nil {
+				err = closeErr
+			}
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("ERROR: Export %s to %s: %v", name, wc.URI().Path(), err)
+					dialog.ShowError(fmt.Errorf("%s: %w", path.Base(name), err), p.parent)
+				}
+			})
+		}()
+	}, p.parent)
+	d.SetFileName(strings.TrimSuffix(path.Base(name), path.Ext(name)) + ".png")
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".png"}))
+	d.Show()
+}
+
+// ShowAssetPreview abre en un diálogo la vista previa del fichero filePath (hash) del
+// paquete de e.
+func ShowAssetPreview(e installs.Entry, filePath string, hash uint64, parent fyne.Window) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := newAssetPreview(ctx, parent)
+	title := widget.NewLabelWithStyle(filePath, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	title.Truncation = fyne.TextTruncateEllipsis
+	d := dialog.NewCustom("Preview", "Close", container.NewBorder(title, nil, nil, nil, p.content), parent)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(640, 600))
+	d.Show()
+	p.Show(e, filePath, hash)
+}
+
+// --- End of asset_preview.go ---
@@ -0,0 +1,121 @@
+// skinhunter/ui/bin_view.go
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"skinhunter/bin"
+	"skinhunter/fantome"
+	"skinhunter/hashes"
+	"skinhunter/installs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowBinFile abre el .bin hash del paquete de e y muestra los recursos que referencia
+// (mallas, texturas, partículas...) y su volcado JSON, con los nombres resueltos
+// mediante las tablas de hashes si están disponibles.
+func ShowBinFile(e installs.Entry, hash uint64, name string, parent fyne.Window) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var assets []bin.Asset
+	var lines []string
+	var dump string
+
+	assetList := widget.NewList(
+		func() int { return len(assets) },
+		func() fyne.CanvasObject {
+			kind := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			p := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			p.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, kind, nil, p)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			c := o.(*fyne.Container)
+			c.Objects[1].(*widget.Label).SetText(assets[id].Kind)
+			c.Objects[0].(*widget.Label).SetText(assets[id].Path)
+		},
+	)
+	// Una fila por línea: un Label con todo el JSON es muy lento con .bin grandes.
+	jsonList := widget.NewList(
+		func() int { return len(lines) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(lines[id])
+		},
+	)
+	status := widget.NewLabel("Reading " + name + "...")
+	status.Wrapping = fyne.TextWrapWord
+	copyBtn := widget.NewButtonWithIcon("Copy JSON", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(dump)
+	})
+	copyBtn.Disable()
+
+	go func() {
+		raw, err := fantome.ReadFile(e.PackagePath, hash)
+		var f *bin.File
+		if err == nil {
+			f, err = bin.Parse(raw)
+		}
+		if err != nil {
+			log.Printf("ERROR: Bin file %s of %s (ID %d): %v", name, e.Name, e.ItemID(), err)
+			fyne.Do(func() { status.SetText(ErrorMessage(err)) })
+			return
+		}
+		// Sin tablas se muestran los hashes en hex.
+		files, err := hashes.Shared.Table(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("WARN: Hash tables: %v", err)
+		}
+		names, err := hashes.SharedBin.Table(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("WARN: Bin hash tables: %v", err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		resolver := bin.Names{Bin: names, Files: files}
+		out, err := f.JSON(resolver)
+		if err != nil {
+			log.Printf("ERROR: JSON dump of %s: %v", name, err)
+		}
+		newAssets := f.Assets(resolver)
+		summary := fmt.Sprintf("%d entries, %d referenced assets.", len(f.Entries), len(newAssets))
+		if f.IsPatch {
+			summary = fmt.Sprintf("Patch file, %d entries and %d patches, %d referenced assets.", len(f.Entries), len(f.Patches), len(newAssets))
+		}
+		fyne.Do(func() {
+			assets = newAssets
+			dump = string(out)
+			lines = strings.Split(dump, "\n")
+			assetList.Refresh()
+			jsonList.Refresh()
+			status.SetText(summary)
+			copyBtn.Enable()
+		})
+	}()
+
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon("Assets", theme.FileImageIcon(), assetList),
+		container.NewTabItemWithIcon("JSON", theme.DocumentIcon(), jsonList),
+	)
+	title := widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	title.Truncation = fyne.TextTruncateEllipsis
+	top := container.NewVBox(title, container.NewBorder(nil, nil, nil, copyBtn, status))
+	d := dialog.NewCustom("Bin file", "Close", container.NewBorder(top, nil, nil, nil, tabs), parent)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(760, 560))
+	d.Show()
+}
+
+// --- End of bin_view.go ---
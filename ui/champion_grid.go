@@ -2,21 +2,26 @@
 package ui
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"strings"
 
 	"skinhunter/data"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
 // NewChampionGrid crea la vista usando container.NewGridWrap.
 // *** CORRECTION: Reverted to simple GridWrap version ***
-func NewChampionGrid(champions []data.ChampionSummary, onChampionSelect func(champ data.ChampionSummary)) fyne.CanvasObject {
+// Los retratos se cargan con el imageLoader compartido; cancelar ctx descarta los pendientes.
+// Encima va una barra de roles, nombre y orden que reordena las tarjetas ya creadas.
+func NewChampionGrid(ctx context.Context, champions []data.ChampionSummary, onChampionSelect func(champ data.ChampionSummary)) fyne.CanvasObject {
 	log.Println("Creating Champion Grid UI (GridWrap)...")
 
 	if champions == nil || len(champions) == 0 {
@@ -30,7 +35,8 @@ func NewChampionGrid(champions []data.ChampionSummary, onChampionSelect func(cha
 	cellSize := fyne.NewSize(cellWidth, cellHeight)
 	imgTargetSize := fyne.NewSize(80, 80)
 
-	championWidgets := make([]fyne.CanvasObject, 0, len(champions))
+	cards := make(map[int]fyne.CanvasObject, len(champions))
+	priorities := make(map[int]*loadPriority, len(champions))
 
 	// Build widgets directly from the provided data
 	for _, champ := range champions {
@@ -41,28 +47,16 @@ func NewChampionGrid(champions []data.ChampionSummary, onChampionSelect func(cha
 		placeholderRect.SetMinSize(imgTargetSize)
 		imageContainer := container.NewStack(placeholderRect, container.NewCenter(placeholderIcon))
 
-		// Launch goroutine for image loading
-		go func(c data.ChampionSummary, imgCont *fyne.Container) {
-			imageURL := data.GetChampionSquarePortraitURL(c)
-			if imageURL == data.GetPlaceholderImageURL() {
-				return
-			}
-			uri, err := storage.ParseURI(imageURL)
-			if err != nil {
-				log.Printf("ERROR: ChampGrid failed to parse URI [%s] for champ %d: %v", imageURL, c.ID, err)
-				return
-			}
-			loadedImage := canvas.NewImageFromURI(uri)
-			loadedImage.FillMode = canvas.ImageFillContain
-			loadedImage.SetMinSize(imgTargetSize)
-
-			fyne.Do(func() { // Use fyne.Do for UI update
-				if imgCont != nil && imgCont.Visible() {
-					imgCont.Objects = []fyne.CanvasObject{loadedImage}
-					imgCont.Refresh()
-				}
+		// Queue image loading (bounded worker pool, visible first once the tracker runs)
+		prio := newLoadPriority(priorityOffscreen)
+		if imageURL := data.GetChampionSquarePortraitURL(champCopy); imageURL != data.GetPlaceholderImageURL() {
+			loadImageAsync(ctx, imageURL, imgTargetSize, canvas.ImageFillContain, prio, func(loadedImage *canvas.Image) {
+				imageContainer.Objects = []fyne.CanvasObject{loadedImage}
+				imageContainer.Refresh()
+			}, func(err error) {
+				log.Printf("ERROR: ChampGrid failed to load image [%s] for champ %d: %v", imageURL, champCopy.ID, err)
 			})
-		}(champCopy, imageContainer)
+		}
 
 		nameLabel := widget.NewLabel(champCopy.Name)
 		nameLabel.Alignment = fyne.TextAlignCenter
@@ -74,14 +68,98 @@ func NewChampionGrid(champions []data.ChampionSummary, onChampionSelect func(cha
 			onChampionSelect(champCopy)
 		})
 		tappableCard.SetMinSize(cellSize)
-		championWidgets = append(championWidgets, tappableCard)
+		cards[champCopy.ID] = tappableCard
+		priorities[champCopy.ID] = prio
 	}
 
-	grid := container.NewGridWrap(cellSize, championWidgets...)
+	grid := container.NewGridWrap(cellSize)
 	paddedGrid := container.NewPadded(grid)
 	scrollContainer := container.NewScroll(paddedGrid)
+	tracker := newViewportTracker(scrollContainer, theme.Padding())
+	emptyLabel := widget.NewLabel("No champions match the current filters.")
+	emptyLabel.Hide()
+	countLabel := widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Italic: true})
+	skinCounts := data.SkinCountsByChampion()
+
+	// applyFilter coloca en el grid solo las tarjetas que pasan el filtro; las demás
+	// pasan a prioridad mínima para que sus retratos no adelanten a los visibles.
+	applyFilter := func(filter data.ChampionFilter, sortBy data.ChampionSort) {
+		shown := data.FilterChampions(champions, filter, sortBy, skinCounts)
+		for _, p := range priorities {
+			p.v.Store(priorityOffscreen)
+		}
+		tracker.Reset()
+		objs := make([]fyne.CanvasObject, 0, len(shown))
+		for _, c := range shown {
+			objs = append(objs, cards[c.ID])
+			tracker.Track(cards[c.ID], priorities[c.ID])
+		}
+		grid.Objects = objs
+		grid.Refresh()
+		scrollContainer.ScrollToTop()
+		emptyLabel.Hidden = len(shown) > 0
+		emptyLabel.Refresh()
+		if filter.IsZero() {
+			countLabel.SetText(fmt.Sprintf("%d champions", len(champions)))
+		} else {
+			countLabel.SetText(fmt.Sprintf("%d of %d champions", len(shown), len(champions)))
+		}
+		tracker.Update()
+	}
+	filterBar := newChampionFilterBar(countLabel, applyFilter)
+	applyFilter(data.ChampionFilter{}, data.SortChampionsByName)
+
 	log.Printf("Champion grid UI built.")
-	return scrollContainer
+	return container.NewBorder(container.NewPadded(filterBar), nil, nil, nil, container.NewStack(tracker.Container(), container.NewCenter(emptyLabel)))
+}
+
+// newChampionFilterBar crea los controles de roles, nombre y orden del grid de
+// campeones; status se muestra a la derecha del nombre. onChange recibe el estado
+// completo tras cada cambio.
+func newChampionFilterBar(status fyne.CanvasObject, onChange func(filter data.ChampionFilter, sortBy data.ChampionSort)) fyne.CanvasObject {
+	var filter data.ChampionFilter
+	sortBy := data.SortChampionsByName
+	notify := func() { onChange(filter, sortBy) }
+
+	labels := make([]string, len(data.ChampionRoles))
+	for i, r := range data.ChampionRoles {
+		labels[i] = strings.ToUpper(r[:1]) + r[1:]
+	}
+	roles := widget.NewCheckGroup(labels, func(selected []string) {
+		filter.Roles = make([]string, len(selected))
+		for i, l := range selected {
+			filter.Roles[i] = strings.ToLower(l)
+		}
+		notify()
+	})
+	roles.Horizontal = true
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Filter by name...")
+	nameEntry.OnChanged = func(text string) {
+		filter.Name = text
+		notify()
+	}
+
+	sorts := []data.ChampionSort{data.SortChampionsByName, data.SortChampionsByID, data.SortChampionsBySkinCount}
+	sortNames := make([]string, len(sorts))
+	for i, s := range sorts {
+		sortNames[i] = s.String()
+	}
+	sortSel := widget.NewSelect(sortNames, nil)
+	sortSel.SetSelected(sortBy.String())
+	sortSel.OnChanged = func(name string) {
+		for _, s := range sorts {
+			if s.String() == name {
+				sortBy = s
+			}
+		}
+		notify()
+	}
+
+	nameBox := container.NewGridWrap(fyne.NewSize(200, nameEntry.MinSize().Height), nameEntry)
+	row1 := container.NewHBox(nameBox, status, layout.NewSpacer(), widget.NewLabel("Sort by:"), sortSel)
+	return container.NewVBox(row1, roles)
 }
 
 // --- Remove ChampionGridItem, NewChampionGridItemTemplate ---
@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"runtime/debug"
@@ -15,7 +16,6 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
@@ -35,13 +35,19 @@ type ChampionView struct {
 	bioLabel        *widget.Label
 	viewMoreButton  *widget.Button
 	skinsTitleLabel *widget.Label
+	filterBar       *SkinFilterBar // Filtros y orden de las skins; se conservan al cambiar de campeón
 
 	// Reusable SkinsGrid Instance (using GridWrap internally now)
 	skinsGridWidget *SkinsGrid // Holds the GridWrap based grid
 
 	currentChampID int
 	loading        *fyne.Container
+	errorBox       *fyne.Container // Panel de error con "Retry"; sustituye a mainLayout si falla la carga
 	currentDetails *data.DetailedChampionData
+
+	// loadCtx vive mientras se muestra currentChampID; se cancela al cambiar de campeón.
+	loadCtx    context.Context
+	loadCancel context.CancelFunc
 }
 
 // NewChampionView creates the initial structure of the champion view.
@@ -70,7 +76,9 @@ func NewChampionView(
 	bioAndButton := container.NewVBox(v.bioLabel, container.NewHBox(layout.NewSpacer(), v.viewMoreButton))
 	skinsIcon := widget.NewIcon(theme.ColorPaletteIcon())
 	skinsTitleHeader := container.NewHBox(skinsIcon, v.skinsTitleLabel)
-	topSectionContent := container.NewVBox(champHeader, widget.NewSeparator(), bioAndButton, widget.NewSeparator(), container.NewPadded(skinsTitleHeader))
+	v.filterBar = NewSkinFilterBar()
+	v.filterBar.OnChanged = func(data.SkinFilter, data.SkinSort) { v.applySkinFilter() }
+	topSectionContent := container.NewVBox(champHeader, widget.NewSeparator(), bioAndButton, widget.NewSeparator(), container.NewPadded(skinsTitleHeader), v.filterBar)
 	v.topSection = container.NewPadded(topSectionContent)
 
 	// Create the reusable SkinsGrid instance ONCE
@@ -99,9 +107,11 @@ func NewChampionView(
 
 	v.loading = container.NewCenter(container.NewVBox(widget.NewLabel("Loading..."), widget.NewProgressBarInfinite()))
 	v.loading.Hide()
+	v.errorBox = container.NewStack()
+	v.errorBox.Hide()
 	// Main layout uses the SkinsGridWidget directly in the Center
 	v.mainLayout = container.NewBorder(v.topSection, nil, nil, nil, v.skinsGridWidget) // Place widget directly
-	v.content = container.NewStack(v.mainLayout, v.loading)
+	v.content = container.NewStack(v.mainLayout, v.loading, v.errorBox)
 
 	return v
 }
@@ -120,7 +130,14 @@ func (v *ChampionView) UpdateContent(championSummary data.ChampionSummary) {
 	log.Printf("ChampionView: Updating content for %s (ID: %d)", championSummary.Name, championSummary.ID)
 	v.currentChampID = championSummary.ID
 	v.currentDetails = nil
+	if v.loadCancel != nil {
+		v.loadCancel()
+	}
+	v.loadCtx, v.loadCancel = context.WithCancel(context.Background())
+	ctx := v.loadCtx
 
+	v.errorBox.Hide()
+	v.mainLayout.Show()
 	v.loading.Show()
 	v.updateTopSectionPlaceholders(championSummary.Name)
 	v.skinsGridWidget.UpdateSkins(nil) // Clear grid / show placeholder immediately
@@ -132,23 +149,36 @@ func (v *ChampionView) UpdateContent(championSummary data.ChampionSummary) {
 				log.Printf("PANIC recovered: %v\n%s", r, string(debug.Stack())) /* handle */
 			}
 		}()
-		details, err := data.FetchChampionDetails(champID)
+		details, err := data.FetchChampionDetails(ctx, champID)
+		if ctx.Err() != nil {
+			log.Printf("ChampionView: Discarding details for %s (ID: %d), view changed.", name, champID)
+			return
+		}
 		updateUIFunc := func() {}
 
 		if err != nil {
 			log.Printf("ChampionView ERROR fetching details for %s (ID: %d): %v", name, champID, err)
 			updateUIFunc = func() {
-				v.skinsGridWidget.showPlaceholder(fmt.Sprintf("Error loading skins for %s", name)) // Show error in grid
+				if ctx.Err() != nil {
+					return
+				}
+				v.errorBox.Objects = []fyne.CanvasObject{
+					NewErrorPanel(fmt.Sprintf("Error loading %s", name), err, func() { v.Reload(championSummary) }),
+				}
+				v.mainLayout.Hide()
+				v.errorBox.Show()
 				v.loading.Hide()
 				v.content.Refresh()
 			}
 		} else {
 			log.Printf("ChampionView: Details fetched successfully for %s", details.Name)
-			v.currentDetails = details
 			updateUIFunc = func() {
+				if ctx.Err() != nil {
+					return
+				}
+				v.currentDetails = details
 				v.updateTopSection(*details)
-				// *** CORRECTION: Call UpdateSkins (not StartPopulatingSkins) ***
-				v.skinsGridWidget.UpdateSkins(details.Skins) // Trigger incremental population
+				v.applySkinFilter() // Puebla el grid con los filtros actuales
 				v.loading.Hide()
 				v.content.Refresh()
 			}
@@ -157,6 +187,52 @@ func (v *ChampionView) UpdateContent(championSummary data.ChampionSummary) {
 	}(championSummary.ID, championSummary.Name)
 }
 
+// Cancel aborta la carga de detalles y las imágenes pendientes del campeón mostrado.
+// La siguiente UpdateContent vuelve a cargar aunque sea el mismo campeón.
+func (v *ChampionView) Cancel() {
+	if v.loadCancel != nil {
+		v.loadCancel()
+		v.loadCancel = nil
+	}
+	v.skinsGridWidget.Cancel()
+	v.currentChampID = -1
+}
+
+// Reload vuelve a pedir los detalles de championSummary aunque ya sea el campeón actual.
+func (v *ChampionView) Reload(championSummary data.ChampionSummary) {
+	v.currentChampID = -1
+	v.UpdateContent(championSummary)
+}
+
+// applySkinFilter vuelve a poblar el grid con las skins ya cargadas que pasan los
+// filtros de la barra, sin volver a pedir los detalles.
+func (v *ChampionView) applySkinFilter() {
+	if v.currentDetails == nil {
+		return
+	}
+	filter, sortBy := v.filterBar.Filter()
+	skins := data.FilterSkins(v.currentDetails.Skins, filter, sortBy)
+	total, shown := 0, 0
+	for _, s := range v.currentDetails.Skins {
+		if !s.IsBase {
+			total++
+		}
+	}
+	for _, s := range skins {
+		if !s.IsBase {
+			shown++
+		}
+	}
+	if filter.IsZero() {
+		v.skinsTitleLabel.SetText(fmt.Sprintf("%s Skins", v.currentDetails.Name))
+		v.skinsGridWidget.SetEmptyText("This champion has no additional skins.")
+	} else {
+		v.skinsTitleLabel.SetText(fmt.Sprintf("%s Skins (%d of %d)", v.currentDetails.Name, shown, total))
+		v.skinsGridWidget.SetEmptyText("No skins match the current filters.")
+	}
+	v.skinsGridWidget.UpdateSkins(skins)
+}
+
 // updateTopSection updates the widgets in the top part of the view.
 func (v *ChampionView) updateTopSection(details data.DetailedChampionData) { /* ... as before ... */
 	log.Printf("Updating top section for %s", details.Name)
@@ -242,25 +318,17 @@ func (v *ChampionView) updateChampionImage(details data.DetailedChampionData) {
 	placeholderRect.SetMinSize(imgAreaSize)
 	v.champImage.Objects = []fyne.CanvasObject{placeholderRect, container.NewCenter(placeholderIcon)}
 	v.champImage.Refresh()
-	go func(c data.DetailedChampionData, stack *fyne.Container) {
-		imageUrl := data.Asset(c.SquarePortraitPath)
-		if imageUrl == data.GetPlaceholderImageURL() {
-			return
-		}
-		imgUri, parseErr := storage.ParseURI(imageUrl)
-		if parseErr != nil {
-			return
+	imageUrl := data.Asset(details.SquarePortraitPath)
+	if imageUrl == data.GetPlaceholderImageURL() || v.loadCtx == nil {
+		return
+	}
+	stack := v.champImage
+	loadImageAsync(v.loadCtx, imageUrl, imgAreaSize, canvas.ImageFillContain, nil, func(imgWidget *canvas.Image) {
+		if v.currentChampID == details.ID {
+			stack.Objects = []fyne.CanvasObject{imgWidget}
+			stack.Refresh()
 		}
-		imgWidget := canvas.NewImageFromURI(imgUri)
-		imgWidget.SetMinSize(imgAreaSize)
-		imgWidget.FillMode = canvas.ImageFillContain
-		fyne.Do(func() {
-			if v.currentChampID == c.ID && stack != nil && stack.Visible() {
-				stack.Objects = []fyne.CanvasObject{imgWidget}
-				stack.Refresh()
-			}
-		})
-	}(details, v.champImage)
+	}, nil)
 }
 
 // --- End of champion_view.go ---
@@ -0,0 +1,75 @@
+// skinhunter/ui/error_panel.go
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"skinhunter/bin"
+	"skinhunter/data"
+	"skinhunter/fantome"
+	"skinhunter/game"
+	"skinhunter/hashes"
+	"skinhunter/installs"
+	"skinhunter/mesh"
+	"skinhunter/overlay"
+	"skinhunter/texture"
+	"skinhunter/wad"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// userMessages son los traductores de error de cada paquete, en orden de prioridad:
+// los errores de red de data van antes que los de los paquetes que descargan.
+var userMessages = []func(error) (string, bool){
+	data.UserMessage,
+	game.UserMessage,
+	installs.UserMessage,
+	fantome.UserMessage,
+	wad.UserMessage,
+	bin.UserMessage,
+	texture.UserMessage,
+	mesh.UserMessage,
+	overlay.UserMessage,
+	hashes.UserMessage,
+}
+
+// ErrorMessage traduce un error a un texto para el usuario preguntando a cada
+// paquete por sus errores. El error original se sigue registrando en el log por
+// quien lo recibe.
+func ErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "The server took too long to answer. Please try again."
+	}
+	for _, userMessage := range userMessages {
+		if msg, ok := userMessage(err); ok {
+			return msg
+		}
+	}
+	return fmt.Sprintf("Something went wrong: %v", err)
+}
+
+// NewErrorPanel crea el bloque centrado de error con el mensaje de ErrorMessage.
+// Si onRetry no es nil se añade un botón "Retry".
+func NewErrorPanel(title string, err error, onRetry func()) fyne.CanvasObject {
+	titleLabel := widget.NewLabelWithStyle(title, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	msgLabel := widget.NewLabelWithStyle(ErrorMessage(err), fyne.TextAlignCenter, fyne.TextStyle{})
+	msgLabel.Wrapping = fyne.TextWrapWord
+	box := container.NewVBox(container.NewCenter(widget.NewIcon(theme.ErrorIcon())), titleLabel, widget.NewSeparator(), msgLabel)
+	if onRetry != nil {
+		retryBtn := widget.NewButtonWithIcon("Retry", theme.ViewRefreshIcon(), onRetry)
+		retryBtn.Importance = widget.HighImportance
+		box.Add(container.NewCenter(retryBtn))
+	}
+	return container.NewPadded(container.NewVBox(layout.NewSpacer(), box, layout.NewSpacer()))
+}
+
+// --- End of error_panel.go ---
@@ -0,0 +1,146 @@
+// skinhunter/ui/image_loader.go
+package ui
+
+import (
+	"context"
+	"image"
+	"sync/atomic"
+
+	"skinhunter/imagecache"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+// imageLoaderWorkers limita las descargas de imágenes simultáneas de toda la UI.
+const imageLoaderWorkers = 6
+
+// Prioridades de carga según la posición del item respecto al viewport.
+const (
+	priorityOffscreen int32 = 0
+	priorityNear      int32 = 1 // Dentro de una pantalla de distancia
+	priorityVisible   int32 = 2
+)
+
+var imageLoader = imagecache.NewLoader(imagecache.Shared, imageLoaderWorkers)
+
+// loadPriority es la prioridad de un item; la escribe el hilo de UI (viewportTracker)
+// y la leen los workers del loader.
+type loadPriority struct{ v atomic.Int32 }
+
+func newLoadPriority(initial int32) *loadPriority {
+	p := &loadPriority{}
+	p.v.Store(initial)
+	return p
+}
+func (p *loadPriority) get() int {
+	if p == nil {
+		return int(priorityVisible)
+	}
+	return int(p.v.Load())
+}
+
+// loadImageAsync encola la carga de imgURL y llama a apply en el hilo de UI con el
+// canvas.Image listo, salvo que ctx se haya cancelado (vista cambiada o diálogo cerrado).
+// onError (opcional) también se llama en el hilo de UI.
+func loadImageAsync(ctx context.Context, imgURL string, minSize fyne.Size, fill canvas.ImageFill, prio *loadPriority, apply func(img *canvas.Image), onError func(err error)) {
+	imageLoader.Enqueue(imagecache.Request{
+		URL:      imgURL,
+		Ctx:      ctx,
+		Priority: prio.get,
+		Done: func(img image.Image, err error) {
+			fyne.Do(func() {
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					if onError != nil {
+						onError(err)
+					}
+					return
+				}
+				ci := canvas.NewImageFromImage(img)
+				ci.FillMode = fill
+				ci.SetMinSize(minSize)
+				apply(ci)
+			})
+		},
+	})
+}
+
+// viewportTracker actualiza la prioridad de carga de los items de un grid según
+// si están dentro del área visible de su Scroll. Solo se usa desde el hilo de UI.
+type viewportTracker struct {
+	scroll  *container.Scroll
+	offsetY float32 // Desplazamiento del grid dentro del contenido del scroll (padding)
+	items   []trackedItem
+}
+
+type trackedItem struct {
+	obj  fyne.CanvasObject
+	prio *loadPriority
+}
+
+func newViewportTracker(scroll *container.Scroll, offsetY float32) *viewportTracker {
+	t := &viewportTracker{scroll: scroll, offsetY: offsetY}
+	prev := scroll.OnScrolled
+	scroll.OnScrolled = func(p fyne.Position) {
+		if prev != nil {
+			prev(p)
+		}
+		t.Update()
+	}
+	return t
+}
+
+// Container devuelve el scroll envuelto en un layout que llama a Update tras cada
+// layout (el primero y los cambios de tamaño), no solo al hacer scroll.
+func (t *viewportTracker) Container() fyne.CanvasObject {
+	return container.New(&viewportLayout{tracker: t}, t.scroll)
+}
+
+type viewportLayout struct{ tracker *viewportTracker }
+
+func (l *viewportLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	for _, o := range objects {
+		o.Move(fyne.NewPos(0, 0))
+		o.Resize(size)
+	}
+	l.tracker.Update()
+}
+func (l *viewportLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return objects[0].MinSize()
+}
+
+// Track registra obj con la prioridad que usan las cargas de sus imágenes.
+func (t *viewportTracker) Track(obj fyne.CanvasObject, prio *loadPriority) {
+	t.items = append(t.items, trackedItem{obj: obj, prio: prio})
+}
+
+// Reset olvida todos los items (al repoblar el grid).
+func (t *viewportTracker) Reset() { t.items = nil }
+
+// Update recalcula las prioridades con la posición actual del scroll.
+func (t *viewportTracker) Update() {
+	top := t.scroll.Offset.Y
+	height := t.scroll.Size().Height
+	if height <= 0 {
+		return // Aún sin layout: se mantiene la prioridad inicial
+	}
+	bottom := top + height
+	for _, it := range t.items {
+		y := it.obj.Position().Y + t.offsetY
+		h := it.obj.Size().Height
+		switch {
+		case y+h >= top && y <= bottom:
+			it.prio.v.Store(priorityVisible)
+		case y+h >= top-height && y <= bottom+height:
+			it.prio.v.Store(priorityNear)
+		default:
+			it.prio.v.Store(priorityOffscreen)
+		}
+	}
+}
+
+// --- End of image_loader.go ---
@@ -0,0 +1,333 @@
+// skinhunter/ui/installed_view.go
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"skinhunter/data"
+	"skinhunter/fantome"
+	"skinhunter/installs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// InstalledView muestra las entradas del registro de instalaciones (skins del
+// catálogo y mods .fantome importados) como tarjetas con acciones de desinstalar,
+// reinstalar y exportar, en el orden de carga del overlay: cada tarjeta se puede
+// desactivar o subir y bajar de prioridad. Se repinta sola cuando el registro cambia.
+type InstalledView struct {
+	widget.BaseWidget
+	parent      fyne.Window
+	registry    *installs.Registry
+	onOpen      func(skin data.Skin)
+	onReinstall func(entry installs.Entry)
+
+	content    fyne.CanvasObject
+	grid       *fyne.Container
+	countLabel *widget.Label
+	emptyBox   fyne.CanvasObject
+	overlay    *overlayPanel
+
+	// cancel aborta las imágenes y comprobaciones del último Reload.
+	cancel context.CancelFunc
+}
+
+// NewInstalledView crea la vista. onOpen abre el diálogo de la skin al pulsar una
+// tarjeta; onReinstall vuelve a instalar el paquete de una entrada.
+func NewInstalledView(parent fyne.Window, registry *installs.Registry, onOpen func(skin data.Skin), onReinstall func(entry installs.Entry)) *InstalledView {
+	v := &InstalledView{parent: parent, registry: registry, onOpen: onOpen, onReinstall: onReinstall}
+	v.ExtendBaseWidget(v)
+
+	v.grid = container.NewGridWrap(fyne.NewSize(230, 300))
+	v.countLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	emptyMsg := widget.NewLabelWithStyle("No skins installed yet.\nOpen a skin and press Download to install it,\nor import (or drop here) a .fantome mod.", fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+	v.emptyBox = container.NewCenter(container.NewVBox(container.NewCenter(widget.NewIcon(theme.DownloadIcon())), emptyMsg))
+	importBtn := widget.NewButtonWithIcon("Import .fantome...", theme.FolderOpenIcon(), v.showImportDialog)
+	header := container.NewBorder(nil, nil, container.NewHBox(widget.NewIcon(theme.DownloadIcon()), v.countLabel), importBtn)
+	v.overlay = newOverlayPanel(parent, registry)
+	body := container.NewStack(container.NewScroll(container.NewPadded(v.grid)), v.emptyBox)
+	v.content = container.NewBorder(container.NewPadded(container.NewVBox(header, v.overlay.content)), nil, nil, nil, body)
+
+	registry.OnChange(func() { fyne.Do(v.Reload) })
+	v.Reload()
+	return v
+}
+
+// CreateRenderer returns the renderer for the InstalledView.
+func (v *InstalledView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.content)
+}
+
+// Reload vuelve a construir las tarjetas a partir del registro, en orden de carga.
+func (v *InstalledView) Reload() {
+	if v.cancel != nil {
+		v.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	v.cancel = cancel
+
+	entries := v.registry.LoadOrder()
+	order := make([]int, len(entries))
+	for i, e := range entries {
+		order[i] = e.ItemID()
+	}
+	cards := make([]fyne.CanvasObject, 0, len(entries))
+	for i, e := range entries {
+		cards = append(cards, v.newCard(ctx, e, order, i))
+	}
+	v.grid.Objects = cards
+	v.grid.Refresh()
+	v.overlay.Refresh()
+	switch len(entries) {
+	case 0:
+		v.countLabel.SetText("Installed")
+		v.emptyBox.Show()
+	case 1:
+		v.countLabel.SetText("1 installed item")
+		v.emptyBox.Hide()
+	default:
+		v.countLabel.SetText(fmt.Sprintf("%d installed items", len(entries)))
+		v.emptyBox.Hide()
+	}
+}
+
+// newCard crea la tarjeta de e, que ocupa la posición i del orden de carga order. La
+// imagen y la comprobación del paquete se hacen en segundo plano.
+func (v *InstalledView) newCard(ctx context.Context, e installs.Entry, order []int, i int) fyne.CanvasObject {
+	imgSize := fyne.NewSize(210, 140)
+	placeholderRect := canvas.NewRectangle(theme.InputBorderColor())
+	placeholderRect.SetMinSize(imgSize)
+	imageStack := container.NewStack(placeholderRect, container.NewCenter(widget.NewIcon(theme.BrokenImageIcon())))
+	if e.IsMod() && e.Mod.ImagePath != "" {
+		img := canvas.NewImageFromFile(e.Mod.ImagePath)
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(imgSize)
+		imageStack.Objects = []fyne.CanvasObject{img}
+	}
+
+	name := e.Name
+	if name == "" {
+		name = fmt.Sprintf("Skin %d", e.ItemID())
+	}
+	nameLabel := widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	nameLabel.Truncation = fyne.TextTruncateEllipsis
+	dateText := "Installed " + e.InstalledAt.Local().Format("2006-01-02 15:04")
+	if e.IsMod() {
+		dateText = "Imported " + e.InstalledAt.Local().Format("2006-01-02")
+		if by := modByline(e.Mod); by != "" {
+			dateText = by + " · " + dateText
+		}
+	}
+	dateLabel := widget.NewLabelWithStyle(dateText, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+	dateLabel.Truncation = fyne.TextTruncateEllipsis
+	warning := container.NewHBox(widget.NewIcon(theme.WarningIcon()), widget.NewLabel(""))
+	warning.Hide()
+
+	uninstallBtn := widget.NewButtonWithIcon("Uninstall", theme.DeleteIcon(), func() { v.confirmUninstall(e, name) })
+	uninstallBtn.Importance = widget.DangerImportance
+	reinstallBtn := widget.NewButtonWithIcon("Reinstall", theme.ViewRefreshIcon(), func() {
+		if v.onReinstall != nil {
+			v.onReinstall(e)
+		}
+	})
+	exportBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() { v.showExportDialog(e, name) })
+	contentsBtn := widget.NewButtonWithIcon("", theme.ListIcon(), func() { ShowPackageContents(e, v.parent) })
+	actions := container.NewBorder(nil, nil, nil, container.NewHBox(contentsBtn, exportBtn), container.NewGridWithColumns(2, reinstallBtn, uninstallBtn))
+
+	enabledCheck := widget.NewCheck(fmt.Sprintf("#%d in overlay", i+1), nil)
+	enabledCheck.SetChecked(!e.Disabled)
+	enabledCheck.OnChanged = func(on bool) { v.setEnabled(e, name, on) }
+	upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { v.move(order, i, i-1) })
+	downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { v.move(order, i, i+1) })
+	if i == 0 {
+		upBtn.Disable()
+	}
+	if i == len(order)-1 {
+		downBtn.Disable()
+	}
+	priority := container.NewBorder(nil, nil, nil, container.NewHBox(upBtn, downBtn), enabledCheck)
+
+	var skin *data.Skin
+	content := container.NewBorder(nil, container.NewVBox(nameLabel, dateLabel, warning, priority, actions), nil, nil, imageStack)
+	card := NewTappableCard(widget.NewCard("", "", content), func() {
+		if skin != nil && v.onOpen != nil {
+			v.onOpen(*skin)
+		}
+	})
+	card.SetMinSize(fyne.NewSize(230, 300))
+
+	go func() {
+		if e.IsMod() {
+			v.checkPackage(ctx, e, name, warning, reinstallBtn)
+			return
+		}
+		s, err := data.GetSkinDetails(ctx, e.SkinID)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("InstalledView: no catalog data for skin %d: %v", e.SkinID, err)
+		} else {
+			imgURL := data.GetSkinTileURL(s)
+			for _, ch := range s.Chromas {
+				if ch.ID == e.ChromaID {
+					imgURL = data.GetChromaImageURL(ch)
+				}
+			}
+			fyne.Do(func() { skin = &s })
+			if imgURL != data.GetPlaceholderImageURL() {
+				loadImageAsync(ctx, imgURL, imgSize, canvas.ImageFillContain, nil, func(img *canvas.Image) {
+					imageStack.Objects = []fyne.CanvasObject{img}
+					imageStack.Refresh()
+				}, nil)
+			}
+		}
+		v.checkPackage(ctx, e, name, warning, reinstallBtn)
+	}()
+	return card
+}
+
+// checkPackage comprueba el paquete de e y, si falla, muestra el aviso de la tarjeta.
+// Se llama en segundo plano.
+func (v *InstalledView) checkPackage(ctx context.Context, e installs.Entry, name string, warning *fyne.Container, reinstallBtn *widget.Button) {
+	verr := v.registry.Verify(e.ItemID())
+	if verr == nil || ctx.Err() != nil {
+		return
+	}
+	msg := "Package damaged, reinstall it"
+	if errors.Is(verr, installs.ErrPackageMissing) {
+		msg = "Package missing, reinstall it"
+	}
+	if e.IsMod() {
+		msg = strings.Replace(msg, "reinstall", "import", 1) + " again"
+	}
+	log.Printf("InstalledView: %s (ID %d): %v", name, e.ItemID(), verr)
+	fyne.Do(func() {
+		warning.Objects[1].(*widget.Label).SetText(msg)
+		warning.Show()
+		reinstallBtn.Importance = widget.HighImportance
+		reinstallBtn.Refresh()
+	})
+}
+
+// modByline devuelve "by <autor> · v<versión>" con lo que haya de ambos.
+func modByline(m *installs.ModInfo) string {
+	var parts []string
+	if m.Author != "" {
+		parts = append(parts, "by "+m.Author)
+	}
+	if m.Version != "" {
+		parts = append(parts, "v"+strings.TrimPrefix(m.Version, "v"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (v *InstalledView) showImportDialog() {
+	d := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil || rc == nil {
+			return
+		}
+		path := rc.URI().Path()
+		rc.Close()
+		v.Import(path)
+	}, v.parent)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".fantome", ".zip"}))
+	d.Show()
+}
+
+// Import importa en segundo plano el .fantome de path; los errores se muestran en
+// un diálogo. El registro avisa a la vista cuando termina.
+func (v *InstalledView) Import(path string) {
+	go func() {
+		e, err := fantome.Import(path, filepath.Join(installs.SharedDownloader.Dir(), "mods"), v.registry)
+		if err != nil {
+			log.Printf("ERROR: Import %s: %v", path, err)
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("%s: %s", filepath.Base(path), ErrorMessage(err)), v.parent)
+			})
+			return
+		}
+		log.Printf("InstalledView: imported %s (ID %d) from %s", e.Name, e.ItemID(), path)
+	}()
+}
+
+func (v *InstalledView) showExportDialog(e installs.Entry, name string) {
+	d := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			return
+		}
+		dest := wc.URI().Path()
+		wc.Close()
+		go func() {
+			err := fantome.Export(e, dest, exportWadName(e))
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("ERROR: Export %s (ID %d) to %s: %v", name, e.ItemID(), dest, err)
+					dialog.ShowError(fmt.Errorf("%s: %s", name, ErrorMessage(err)), v.parent)
+					return
+				}
+				dialog.ShowInformation("Export", fmt.Sprintf("%s saved to %s", name, dest), v.parent)
+			})
+		}()
+	}, v.parent)
+	d.SetFileName(strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name) + ".fantome")
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".fantome"}))
+	d.Show()
+}
+
+// exportWadName es el WAD del campeón de e ("Ahri.wad.client"), para empaquetar
+// skins del catálogo instaladas como WAD suelto.
+func exportWadName(e installs.Entry) string {
+	champs, err := data.FetchAllChampions(context.Background()) // Ya en caché tras la carga
+	if err == nil {
+		for _, c := range champs {
+			if c.ID == e.ChampionID && c.Alias != "" {
+				return c.Alias + ".wad.client"
+			}
+		}
+	}
+	return fmt.Sprintf("%d.wad.client", e.ChampionID)
+}
+
+// setEnabled incluye o saca e del overlay.
+func (v *InstalledView) setEnabled(e installs.Entry, name string, on bool) {
+	if err := v.registry.SetEnabled(e.ItemID(), on); err != nil {
+		log.Printf("ERROR: Enable %s (ID %d): %v", name, e.ItemID(), err)
+		dialog.ShowError(fmt.Errorf("%s: %s", name, ErrorMessage(err)), v.parent)
+	}
+}
+
+// move intercambia las posiciones i y j del orden de carga order.
+func (v *InstalledView) move(order []int, i, j int) {
+	ids := append([]int(nil), order...)
+	ids[i], ids[j] = ids[j], ids[i]
+	if err := v.registry.SetOrder(ids); err != nil {
+		log.Printf("ERROR: Reorder installs: %v", err)
+		dialog.ShowError(fmt.Errorf("could not change the order: %s", ErrorMessage(err)), v.parent)
+	}
+}
+
+func (v *InstalledView) confirmUninstall(e installs.Entry, name string) {
+	dialog.ShowConfirm("Uninstall", fmt.Sprintf("Uninstall %s?", name), func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := v.registry.Remove(e.ItemID()); err != nil {
+			log.Printf("ERROR: Uninstall %s (ID %d): %v", name, e.ItemID(), err)
+			dialog.ShowError(fmt.Errorf("could not uninstall %s: %s", name, ErrorMessage(err)), v.parent)
+		}
+	}, v.parent)
+}
+
+// --- End of installed_view.go ---
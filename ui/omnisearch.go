@@ -0,0 +1,168 @@
+// skinhunter/ui/omnisearch.go
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"skinhunter/data"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// omniSearchPerKind limita los resultados que se muestran de cada tipo.
+const omniSearchPerKind = 8
+
+// OmniSearchActions son las acciones de navegación de los resultados.
+// Un chroma abre el diálogo de su skin (OnSkin).
+type OmniSearchActions struct {
+	OnChampion func(champ data.ChampionSummary)
+	OnSkin     func(skin data.Skin)
+	OnSkinLine func(line data.SkinLine)
+}
+
+// omniRow es una fila de la lista: cabecera de grupo (result == nil) o resultado.
+type omniRow struct {
+	header string
+	result *data.SearchResult
+}
+
+// ShowOmniSearch abre el diálogo de búsqueda global. Los resultados se actualizan
+// al escribir, agrupados por tipo; al elegir uno se cierra el diálogo y se navega.
+func ShowOmniSearch(parent fyne.Window, actions OmniSearchActions) {
+	var rows []omniRow
+	var dlg dialog.Dialog
+
+	status := widget.NewLabelWithStyle("Type to search champions, skins, chromas and skin lines.", fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+	list := widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject {
+			name := widget.NewLabel("Result")
+			name.Truncation = fyne.TextTruncateEllipsis
+			detail := widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Italic: true})
+			return container.NewBorder(nil, nil, widget.NewIcon(theme.SearchIcon()), detail, name)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			if id >= len(rows) {
+				return
+			}
+			row := rows[id]
+			border := o.(*fyne.Container)
+			name := border.Objects[0].(*widget.Label)
+			icon := border.Objects[1].(*widget.Icon)
+			detail := border.Objects[2].(*widget.Label)
+			if row.result == nil {
+				name.TextStyle = fyne.TextStyle{Bold: true}
+				name.SetText(row.header)
+				icon.Hide()
+				detail.SetText("")
+				return
+			}
+			name.TextStyle = fyne.TextStyle{}
+			name.SetText(row.result.Name)
+			icon.SetResource(searchKindIcon(row.result.Kind))
+			icon.Show()
+			detail.SetText(searchResultDetail(*row.result))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.Unselect(id)
+		if id >= len(rows) || rows[id].result == nil {
+			return
+		}
+		r := *rows[id].result
+		if dlg != nil {
+			dlg.Hide()
+		}
+		switch r.Kind {
+		case data.SearchChampion:
+			if actions.OnChampion != nil {
+				actions.OnChampion(r.Champion)
+			}
+		case data.SearchSkin, data.SearchChroma:
+			if actions.OnSkin != nil {
+				actions.OnSkin(r.Skin)
+			}
+		case data.SearchSkinLine:
+			if actions.OnSkinLine != nil {
+				actions.OnSkinLine(r.SkinLine)
+			}
+		}
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Search Champions, Skins...")
+	entry.OnChanged = func(text string) {
+		results := data.Search(text, omniSearchPerKind)
+		rows = rows[:0]
+		for i := range results {
+			if i == 0 || results[i].Kind != results[i-1].Kind {
+				rows = append(rows, omniRow{header: results[i].Kind.String()})
+			}
+			rows = append(rows, omniRow{result: &results[i]})
+		}
+		switch {
+		case strings.TrimSpace(text) == "":
+			status.SetText("Type to search champions, skins, chromas and skin lines.")
+		case len(results) == 0:
+			status.SetText(fmt.Sprintf("No results for %q.", text))
+		default:
+			status.SetText(fmt.Sprintf("%d results", len(results)))
+		}
+		list.UnselectAll()
+		list.Refresh()
+		list.ScrollToTop()
+	}
+	// Enter abre el primer resultado.
+	entry.OnSubmitted = func(string) {
+		for i, row := range rows {
+			if row.result != nil {
+				list.OnSelected(i)
+				return
+			}
+		}
+	}
+
+	content := container.NewBorder(container.NewVBox(entry, status), nil, nil, nil, list)
+	dlg = dialog.NewCustom("Search", "Close", content, parent)
+	dlg.Resize(fyne.NewSize(520, 480))
+	dlg.Show()
+	parent.Canvas().Focus(entry)
+}
+
+func searchKindIcon(kind data.SearchKind) fyne.Resource {
+	switch kind {
+	case data.SearchChampion:
+		return theme.AccountIcon()
+	case data.SearchSkinLine:
+		return theme.ListIcon()
+	case data.SearchChroma:
+		return theme.ColorChromaticIcon()
+	}
+	return theme.ColorPaletteIcon()
+}
+
+// searchResultDetail es el texto secundario de una fila (rareza, skin del chroma...).
+func searchResultDetail(r data.SearchResult) string {
+	switch r.Kind {
+	case data.SearchChampion:
+		return strings.Join(r.Champion.Roles, ", ")
+	case data.SearchSkin:
+		rarity, _ := data.Rarity(r.Skin)
+		if r.Skin.IsLegacy {
+			return rarity + " · Legacy"
+		}
+		return rarity
+	case data.SearchChroma:
+		return r.Skin.Name
+	case data.SearchSkinLine:
+		return "Skin line"
+	}
+	return ""
+}
+
+// --- End of omnisearch.go ---
@@ -0,0 +1,219 @@
+// skinhunter/ui/overlay_panel.go
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"skinhunter/game"
+	"skinhunter/hashes"
+	"skinhunter/installs"
+	"skinhunter/overlay"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// overlayPanel es la barra del overlay en la vista Installed: el estado del último
+// overlay (al día o no con el registro), el botón para construirlo y el detalle de
+// qué instalación ganó cada fichero en conflicto.
+type overlayPanel struct {
+	parent   fyne.Window
+	registry *installs.Registry
+
+	result   *overlay.Result // nil = sin construir
+	building bool
+
+	status     *widget.Label
+	buildBtn   *widget.Button
+	detailsBtn *widget.Button
+	content    fyne.CanvasObject
+}
+
+func newOverlayPanel(parent fyne.Window, registry *installs.Registry) *overlayPanel {
+	p := &overlayPanel{parent: parent, registry: registry}
+	p.status = widget.NewLabel("")
+	p.status.Wrapping = fyne.TextWrapWord
+	p.buildBtn = widget.NewButtonWithIcon("Build overlay", theme.MediaPlayIcon(), p.build)
+	p.detailsBtn = widget.NewButtonWithIcon("Details", theme.InfoIcon(), p.showDetails)
+	p.content = container.NewBorder(nil, nil, widget.NewIcon(theme.StorageIcon()), container.NewHBox(p.detailsBtn, p.buildBtn), p.status)
+	p.Refresh()
+
+	go func() {
+		res, err := overlay.LoadResult(overlay.Dir)
+		if err != nil {
+			log.Printf("WARN: Overlay result in %s: %v", overlay.Dir, err)
+		}
+		fyne.Do(func() {
+			if p.result == nil {
+				p.result = res
+				p.Refresh()
+			}
+		})
+	}()
+	return p
+}
+
+// Refresh actualiza el estado frente al registro actual.
+func (p *overlayPanel) Refresh() {
+	if p.building {
+		return
+	}
+	p.buildBtn.Enable()
+	p.detailsBtn.Disable()
+	res := p.result
+	if res == nil {
+		p.status.SetText("Overlay not built yet. Build it to apply the enabled items in this order.")
+		return
+	}
+	p.detailsBtn.Enable()
+	if !res.UpToDate(p.registry.LoadOrder()) {
+		p.buildBtn.Importance = widget.HighImportance
+		p.buildBtn.Refresh()
+		p.status.SetText("Overlay out of date: build it again to apply your changes.")
+		return
+	}
+	p.buildBtn.Importance = widget.MediumImportance
+	p.buildBtn.Refresh()
+	text := fmt.Sprintf("Overlay built %s: %d items, %d WADs, %d contested files",
+		res.BuiltAt.Local().Format("2006-01-02 15:04"), len(res.Mods), len(res.Wads), len(res.Conflicts))
+	if n := unmatchedFiles(res); n > 0 {
+		text += fmt.Sprintf(", %d files not in any game WAD", n)
+	}
+	p.status.SetText(text + ".")
+}
+
+func unmatchedFiles(res *overlay.Result) int {
+	n := 0
+	for _, u := range res.Unmatched {
+		n += u.Files
+	}
+	return n
+}
+
+// build construye el overlay con las entradas activas del registro, en su orden. El
+// avance se ve en un diálogo; cerrarlo con Cancel para la construcción y deja el
+// overlay anterior como estaba.
+func (p *overlayPanel) build() {
+	inst, err := game.Current()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("overlay: %s", ErrorMessage(err)), p.parent)
+		return
+	}
+	entries := p.registry.LoadOrder()
+	p.building = true
+	p.buildBtn.Disable()
+	p.detailsBtn.Disable()
+	p.status.SetText("Building overlay...")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bar := widget.NewProgressBar()
+	label := widget.NewLabel("Reading the installed packages...")
+	d := dialog.NewCustom("Building overlay", "Cancel", container.NewVBox(label, bar), p.parent)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+	go func() {
+		res, err := overlay.Build(ctx, inst, entries, overlay.Dir, func(done, total int) {
+			fyne.Do(func() {
+				if total > 0 {
+					bar.SetValue(float64(done) / float64(total))
+				}
+				label.SetText(fmt.Sprintf("Writing WADs: %d of %d...", done, total))
+			})
+		})
+		fyne.Do(func() {
+			p.building = false
+			d.Hide()
+			if errors.Is(err, context.Canceled) {
+				log.Printf("Overlay: build cancelled")
+				p.Refresh()
+				return
+			}
+			if err != nil {
+				log.Printf("ERROR: Build overlay: %v", err)
+				p.Refresh()
+				dialog.ShowError(fmt.Errorf("overlay: %s", ErrorMessage(err)), p.parent)
+				return
+			}
+			p.result = res
+			p.Refresh()
+		})
+	}()
+}
+
+// showDetails muestra el resultado del último overlay: el orden aplicado, quién ganó
+// cada fichero en conflicto y lo que no iba a ningún WAD del juego.
+func (p *overlayPanel) showDetails() {
+	res := p.result
+	if res == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var lines []string
+	list := widget.NewList(
+		func() int { return len(lines) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(lines[id]) },
+	)
+	list.OnSelected = func(id widget.ListItemID) { list.Unselect(id) }
+	status := widget.NewLabel("Loading hash tables...")
+	d := dialog.NewCustom("Overlay", "Close", container.NewBorder(status, nil, nil, nil, list), p.parent)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(720, 520))
+	d.Show()
+
+	go func() {
+		table, err := hashes.Shared.Table(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Sin tablas los ficheros se muestran por su hash.
+			log.Printf("WARN: Hash tables: %v", err)
+		}
+		newLines := overlayLines(res, table)
+		fyne.Do(func() {
+			lines = newLines
+			list.Refresh()
+			status.SetText(fmt.Sprintf("Built %s in %s", res.BuiltAt.Local().Format("2006-01-02 15:04"), res.Dir))
+		})
+	}()
+}
+
+// overlayLines describe res línea a línea, con las rutas resueltas con table.
+func overlayLines(res *overlay.Result, table *hashes.Table) []string {
+	lines := []string{fmt.Sprintf("Applied items, highest priority first (%d)", len(res.Mods))}
+	for i, m := range res.Mods {
+		lines = append(lines, fmt.Sprintf("    %d. %s", i+1, m.Name))
+	}
+	lines = append(lines, fmt.Sprintf("Contested files (%d)", len(res.Conflicts)))
+	for _, c := range res.Conflicts {
+		losers := make([]string, len(c.Losers))
+		for i, m := range c.Losers {
+			losers[i] = m.Name
+		}
+		lines = append(lines, fmt.Sprintf("    %s: %s — %s wins over %s",
+			path.Base(c.Wad), table.Name(c.Hash), c.Winner.Name, strings.Join(losers, ", ")))
+	}
+	if len(res.Unmatched) > 0 {
+		lines = append(lines, fmt.Sprintf("Files not in any game WAD, skipped (%d)", unmatchedFiles(res)))
+		for _, u := range res.Unmatched {
+			lines = append(lines, fmt.Sprintf("    %s: %d files in %s", u.Mod.Name, u.Files, u.Wad))
+		}
+	}
+	return lines
+}
+
+// --- End of overlay_panel.go ---
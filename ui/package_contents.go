@@ -0,0 +1,141 @@
+// skinhunter/ui/package_contents.go
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"skinhunter/fantome"
+	"skinhunter/hashes"
+	"skinhunter/installs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// contentsRow es una fila del listado: la cabecera de un WAD o uno de sus ficheros.
+type contentsRow struct {
+	text   string
+	header bool
+	known  bool
+	hash   uint64
+}
+
+// ShowPackageContents muestra qué ficheros del juego sustituye el paquete de e, con
+// las rutas resueltas mediante las tablas de hashes (los hashes desconocidos se
+// muestran en hex). Al elegir un .bin (o un hash desconocido, que puede serlo) se abre
+// con ShowBinFile, y las texturas y mallas con ShowAssetPreview.
+func ShowPackageContents(e installs.Entry, parent fyne.Window) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var rows []contentsRow
+	list := widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			l := o.(*widget.Label)
+			r := rows[id]
+			l.TextStyle = fyne.TextStyle{Bold: r.header, Italic: !r.header && !r.known, Monospace: !r.header}
+			l.SetText(r.text)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.Unselect(id)
+		r := rows[id]
+		switch {
+		case r.header:
+		case r.known && previewable(r.text):
+			ShowAssetPreview(e, r.text, r.hash, parent)
+		case !r.known || strings.HasSuffix(strings.ToLower(r.text), ".bin"):
+			ShowBinFile(e, r.hash, r.text, parent)
+		}
+	}
+	status := widget.NewLabel("Reading package and loading hash tables...")
+	status.Wrapping = fyne.TextWrapWord
+	updateBtn := widget.NewButtonWithIcon("Update hash tables", theme.ViewRefreshIcon(), nil)
+	updateBtn.Disable()
+
+	var load func(update bool)
+	load = func(update bool) {
+		updateBtn.Disable()
+		go func() {
+			contents, err := fantome.Contents(e.PackagePath)
+			if err != nil {
+				log.Printf("ERROR: Contents of %s (ID %d): %v", e.Name, e.ItemID(), err)
+				fyne.Do(func() { status.SetText(ErrorMessage(err)) })
+				return
+			}
+			var table *hashes.Table
+			if update {
+				table, err = hashes.Shared.Update(ctx)
+			} else {
+				table, err = hashes.Shared.Table(ctx)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				// Sin tablas se listan igualmente los hashes.
+				log.Printf("WARN: Hash tables: %v", err)
+			}
+			newRows, files, unknown := contentsRows(contents, table)
+			summary := fmt.Sprintf("%d files in %d WADs, %d unknown hashes (%d paths known).", files, len(contents), unknown, table.Len())
+			if err != nil {
+				summary = ErrorMessage(err) + " " + summary
+			}
+			fyne.Do(func() {
+				rows = newRows
+				list.Refresh()
+				status.SetText(summary)
+				updateBtn.Enable()
+			})
+		}()
+	}
+	updateBtn.OnTapped = func() {
+		status.SetText("Updating hash tables...")
+		load(true)
+	}
+
+	title := widget.NewLabelWithStyle(e.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	top := container.NewVBox(title, container.NewBorder(nil, nil, nil, updateBtn, status))
+	content := container.NewBorder(top, nil, nil, nil, list)
+	d := dialog.NewCustom("Package contents", "Close", content, parent)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(720, 520))
+	d.Show()
+	load(false)
+}
+
+// contentsRows construye el listado: cada WAD con sus rutas conocidas ordenadas y
+// después los hashes desconocidos. Devuelve también el total de ficheros y de hashes
+// desconocidos.
+func contentsRows(contents []fantome.WadContents, table *hashes.Table) (rows []contentsRow, files, unknown int) {
+	for _, wc := range contents {
+		var known, missing []contentsRow
+		for _, h := range wc.Hashes {
+			if p, ok := table.Path(h); ok {
+				known = append(known, contentsRow{text: p, known: true, hash: h})
+			} else {
+				missing = append(missing, contentsRow{text: fmt.Sprintf("%016x (unknown)", h), hash: h})
+			}
+		}
+		sort.Slice(known, func(i, j int) bool { return known[i].text < known[j].text })
+		rows = append(rows, contentsRow{text: fmt.Sprintf("%s (%d files)", wc.Name, len(wc.Hashes)), header: true})
+		rows = append(rows, known...)
+		rows = append(rows, missing...)
+		files += len(wc.Hashes)
+		unknown += len(missing)
+	}
+	return rows, files, unknown
+}
+
+// --- End of package_contents.go ---
@@ -0,0 +1,209 @@
+// skinhunter/ui/settings_dialog.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"skinhunter/data"
+	"skinhunter/game"
+	"skinhunter/hashes"
+	"skinhunter/imagecache"
+	"skinhunter/installs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Claves de preferencias compartidas con main.go.
+const (
+	PrefDataSource   = "dataSource"
+	PrefGameVersion  = "gameVersion"
+	PrefLocale       = "locale"
+	PrefImageCacheMB = "imageCacheMB"
+	PrefPackageRepo  = "packageRepository"
+	PrefGameDir      = "gameDirectory"
+	PrefHashTables   = "hashTablesLocation"
+)
+
+// DefaultImageCacheMB es el presupuesto de disco por defecto de la caché de imágenes.
+const DefaultImageCacheMB = int(imagecache.DefaultDiskBudget >> 20)
+
+// ShowSettingsDialog muestra los ajustes de la app. onSaved se llama (en el hilo de UI)
+// solo si cambió algún ajuste de datos y se guardó correctamente; los ajustes de la
+// caché de imágenes se aplican directamente.
+func ShowSettingsDialog(prefs fyne.Preferences, parent fyne.Window, onSaved func()) {
+	sourceEntry := widget.NewEntry()
+	sourceEntry.SetPlaceHolder("CommunityDragon (default)")
+	sourceEntry.SetText(prefs.String(PrefDataSource))
+	sourceHelp := widget.NewLabel("Leave empty for CommunityDragon, or enter a mirror URL or a local directory\nwith the CommunityDragon layout (plugins/rcp-be-lol-game-data/...).\nUse {version} in the location to apply the pinned patch to a mirror.")
+	sourceHelp.TextStyle = fyne.TextStyle{Italic: true}
+
+	versionOptions := []string{"latest"}
+	if patch := data.GetPatch(); patch != "latest" {
+		versionOptions = append(versionOptions, patch)
+	}
+	versionEntry := widget.NewSelectEntry(versionOptions)
+	versionEntry.SetPlaceHolder("latest")
+	versionEntry.SetText(prefs.String(PrefGameVersion))
+	localeSelect := widget.NewSelect(data.Locales, nil)
+	localeSelect.SetSelected(data.DefaultLocale)
+	if l, err := data.NormalizeLocale(prefs.String(PrefLocale)); err == nil {
+		localeSelect.SetSelected(l)
+	}
+	detectedLabel := widget.NewLabel(fmt.Sprintf("Loaded data: patch %s (%s)", data.GetPatch(), data.GetCDragonVersion()))
+
+	cacheEntry := widget.NewEntry()
+	cacheEntry.SetText(strconv.Itoa(prefs.IntWithFallback(PrefImageCacheMB, DefaultImageCacheMB)))
+	cacheUsageLabel := widget.NewLabel("Calculating usage...")
+	updateUsage := func() {
+		go func() {
+			usage := imagecache.Shared.DiskUsage()
+			fyne.Do(func() { cacheUsageLabel.SetText(fmt.Sprintf("In use: %.1f MB", float64(usage)/(1<<20))) })
+		}()
+	}
+	updateUsage()
+	clearCacheButton := widget.NewButtonWithIcon("Clear cache", theme.DeleteIcon(), func() {
+		go func() {
+			err := imagecache.Shared.Clear()
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, parent)
+				}
+				updateUsage()
+			})
+		}()
+	})
+	cacheRow := container.NewBorder(nil, nil, nil, container.NewHBox(cacheUsageLabel, clearCacheButton), cacheEntry)
+
+	repoEntry := widget.NewEntry()
+	repoEntry.SetPlaceHolder("https://example.com/packages or a local directory")
+	repoEntry.SetText(prefs.String(PrefPackageRepo))
+
+	gameEntry := widget.NewEntry()
+	gameEntry.SetPlaceHolder("Folder that contains LeagueClient and Game")
+	gameStatus := widget.NewLabel("")
+	gameStatus.Wrapping = fyne.TextWrapWord
+	gameEntry.OnChanged = func(dir string) {
+		switch inst, err := game.Validate(dir); {
+		case err == nil:
+			gameStatus.SetText("Found the game data in " + inst.DataDir)
+		case errors.Is(err, game.ErrNotConfigured):
+			gameStatus.SetText("Not set: installing skins is disabled.")
+		default:
+			gameStatus.SetText(fmt.Sprintf("Invalid: %v", err))
+		}
+	}
+	gameEntry.SetText(prefs.String(PrefGameDir))
+	gameEntry.OnChanged(gameEntry.Text)
+	browseGameBtn := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				gameEntry.SetText(uri.Path())
+			}
+		}, parent)
+	})
+	detectGameBtn := widget.NewButtonWithIcon("Detect", theme.SearchIcon(), nil)
+	detectGameBtn.OnTapped = func() {
+		detectGameBtn.Disable()
+		gameStatus.SetText("Searching...")
+		go func() {
+			found := game.Detect()
+			fyne.Do(func() {
+				detectGameBtn.Enable()
+				if len(found) == 0 {
+					gameStatus.SetText("No installation found. Choose the folder manually.")
+					return
+				}
+				gameEntry.SetText(found[0].Root)
+				if len(found) > 1 {
+					gameStatus.SetText(fmt.Sprintf("Found %d installations, using the first one.", len(found)))
+				}
+			})
+		}()
+	}
+	gameRow := container.NewBorder(nil, nil, nil, container.NewHBox(browseGameBtn, detectGameBtn), gameEntry)
+
+	hashEntry := widget.NewEntry()
+	hashEntry.SetPlaceHolder(hashes.DefaultLocation)
+	hashEntry.SetText(prefs.String(PrefHashTables))
+
+	form := widget.NewForm(
+		widget.NewFormItem("Data source", sourceEntry),
+		widget.NewFormItem("Game patch", versionEntry),
+		widget.NewFormItem("Language", localeSelect),
+		widget.NewFormItem("Image cache (MB)", cacheRow),
+		widget.NewFormItem("Package repository", repoEntry),
+		widget.NewFormItem("Game folder", gameRow),
+		widget.NewFormItem("", gameStatus),
+		widget.NewFormItem("Hash tables", hashEntry),
+	)
+	content := container.NewVBox(form, sourceHelp, detectedLabel)
+
+	dialog.ShowCustomConfirm("Settings", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		cacheMB, err := strconv.Atoi(strings.TrimSpace(cacheEntry.Text))
+		if err != nil || cacheMB < 0 {
+			dialog.ShowError(fmt.Errorf("invalid image cache size %q", cacheEntry.Text), parent)
+			return
+		}
+		if cacheMB != prefs.IntWithFallback(PrefImageCacheMB, DefaultImageCacheMB) {
+			prefs.SetInt(PrefImageCacheMB, cacheMB)
+			imagecache.Shared.SetDiskBudget(int64(cacheMB) << 20)
+			log.Printf("Settings saved: image cache = %d MB", cacheMB)
+		}
+
+		if repo := strings.TrimSpace(repoEntry.Text); repo != prefs.String(PrefPackageRepo) {
+			prefs.SetString(PrefPackageRepo, repo)
+			installs.SharedDownloader.SetRepository(repo)
+			log.Printf("Settings saved: package repository = %q", repo)
+		}
+
+		if dir := strings.TrimSpace(gameEntry.Text); dir != prefs.String(PrefGameDir) {
+			prefs.SetString(PrefGameDir, dir)
+			game.SetDir(dir)
+			log.Printf("Settings saved: game directory = %q", dir)
+		}
+
+		if loc := strings.TrimSpace(hashEntry.Text); loc != prefs.String(PrefHashTables) {
+			prefs.SetString(PrefHashTables, loc)
+			hashes.Shared.SetLocation(loc)
+			hashes.SharedBin.SetLocation(loc)
+			log.Printf("Settings saved: hash tables = %q", loc)
+		}
+
+		newSource := sourceEntry.Text
+		newVersion := versionEntry.Text
+		if newVersion == "latest" {
+			newVersion = ""
+		}
+		newLocale := localeSelect.Selected
+		if newLocale == data.DefaultLocale {
+			newLocale = ""
+		}
+		if newSource == prefs.String(PrefDataSource) && newVersion == prefs.String(PrefGameVersion) && newLocale == prefs.String(PrefLocale) {
+			return
+		}
+		if _, err := data.NewSourceFromLocation(newSource, newVersion, newLocale); err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		prefs.SetString(PrefDataSource, newSource)
+		prefs.SetString(PrefGameVersion, newVersion)
+		prefs.SetString(PrefLocale, newLocale)
+		log.Printf("Settings saved: data source = %q, patch = %q, locale = %q", newSource, newVersion, newLocale)
+		if onSaved != nil {
+			onSaved()
+		}
+	}, parent)
+}
+
+// --- End of settings_dialog.go ---
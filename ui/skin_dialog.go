@@ -2,20 +2,23 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
 	"log"
 	"runtime/debug"
+	"strings"
 	"sync"
 
 	"skinhunter/data"
+	"skinhunter/installs"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
@@ -43,7 +46,11 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 	}
 	log.Printf("Found %d chromas associated with skin ID %d ('%s')", len(filteredChromas), skin.ID, skin.Name)
 
+	// Las cargas de imágenes del diálogo se cancelan al cerrarlo.
+	ctx, cancel := context.WithCancel(context.Background())
+
 	var downloadButton *widget.Button
+	var downloading bool // Hay una descarga en curso lanzada desde este diálogo
 	var circlesGrid, imagesGrid *fyne.Container
 	var chromaCircleItems = make(map[int]*chromaItemUI)
 	var chromaImageItems = make(map[int]*chromaItemUI)
@@ -58,25 +65,12 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 	placeholderRect.SetMinSize(imgMinSize)
 	imageStack.Add(placeholderRect)
 	imageStack.Add(container.NewCenter(placeholderIcon))
-	go func(s data.Skin, stack *fyne.Container) { /* ... Carga Imagen Splash ... */
-		splashUrl := data.GetSkinSplashURL(s)
-		if splashUrl == data.GetPlaceholderImageURL() {
-			return
-		}
-		splashUri, err := storage.ParseURI(splashUrl)
-		if err != nil {
-			return
-		}
-		splashImage := canvas.NewImageFromURI(splashUri)
-		splashImage.FillMode = canvas.ImageFillContain
-		splashImage.SetMinSize(imgMinSize)
-		fyne.Do(func() {
-			if stack != nil && stack.Visible() {
-				stack.Objects = []fyne.CanvasObject{splashImage}
-				stack.Refresh()
-			}
-		})
-	}(skin, imageStack)
+	if splashUrl := data.GetSkinSplashURL(skin); splashUrl != data.GetPlaceholderImageURL() { /* ... Carga Imagen Splash ... */
+		loadImageAsync(ctx, splashUrl, imgMinSize, canvas.ImageFillContain, nil, func(splashImage *canvas.Image) {
+			imageStack.Objects = []fyne.CanvasObject{splashImage}
+			imageStack.Refresh()
+		}, nil)
+	}
 
 	desc := skin.Description
 	if desc == "" {
@@ -98,15 +92,32 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 	// -----------------------------------------
 
 	// --- Derecha: Chromas y Acciones ---
-	updateSelectionUI := func(newID int) { /* ... sin cambios ... */
-		*selectedChromaID = newID
-		if downloadButton != nil {
-			btnTxt := "Download Skin"
-			if *selectedChromaID != skin.ID {
-				btnTxt = "Download Chroma"
-			}
-			downloadButton.SetText(btnTxt)
+	// updateDownloadButton refleja si la variante elegida ya está instalada o si
+	// instalarla sustituiría otra skin del campeón.
+	updateDownloadButton := func() {
+		if downloadButton == nil || downloading {
+			return
+		}
+		if installs.Shared.IsInstalled(*selectedChromaID) {
+			downloadButton.SetText("Installed")
+			downloadButton.SetIcon(theme.ConfirmIcon())
+			downloadButton.Disable()
+			return
+		}
+		btnTxt, icon := "Download Skin", theme.DownloadIcon()
+		if *selectedChromaID != skin.ID {
+			btnTxt = "Download Chroma"
 		}
+		if len(installs.Shared.Conflicts(installs.Entry{SkinID: skin.ID, ChromaID: *selectedChromaID})) > 0 {
+			btnTxt, icon = strings.Replace(btnTxt, "Download", "Replace with", 1), theme.ViewRefreshIcon()
+		}
+		downloadButton.SetText(btnTxt)
+		downloadButton.SetIcon(icon)
+		downloadButton.Enable()
+	}
+	updateSelectionUI := func(newID int) {
+		*selectedChromaID = newID
+		updateDownloadButton()
 		if circlesGrid != nil {
 			circlesGrid.Refresh()
 		}
@@ -126,13 +137,21 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 	circlesGrid = container.NewGridWithColumns(4)
 	imagesGrid = container.NewGridWithColumns(4)
 	defaultCircleUI := createChromaCircleItem("Default", nil, skin.ID, selectedChromaID, updateSelectionUI)
-	defaultImageUI := createChromaImageItem("Default", data.Chroma{ID: skin.ID, OriginSkinID: skin.ID}, skin.ID, selectedChromaID, updateSelectionUI)
+	defaultImageUI := createChromaImageItem(ctx, "Default", data.Chroma{ID: skin.ID, OriginSkinID: skin.ID}, skin.ID, selectedChromaID, updateSelectionUI)
 	circlesGrid.Add(defaultCircleUI.widget)
 	imagesGrid.Add(defaultImageUI.widget)
+	// Los nombres de skins.json ya vienen en el idioma elegido; el JSON de Supabase
+	// (solo en inglés) únicamente rellena los que faltan.
+	unnamedChromas := make(map[int]bool)
 	for _, chroma := range filteredChromas {
 		chromaCopy := chroma
-		circleUI := createChromaCircleItem("Loading...", chromaCopy.Colors, chromaCopy.ID, selectedChromaID, updateSelectionUI)
-		imageUI := createChromaImageItem("Loading...", chromaCopy, chromaCopy.ID, selectedChromaID, updateSelectionUI)
+		name := strings.TrimSpace(chromaCopy.Name)
+		if name == "" {
+			name = "Loading..."
+			unnamedChromas[chromaCopy.ID] = true
+		}
+		circleUI := createChromaCircleItem(name, chromaCopy.Colors, chromaCopy.ID, selectedChromaID, updateSelectionUI)
+		imageUI := createChromaImageItem(ctx, name, chromaCopy, chromaCopy.ID, selectedChromaID, updateSelectionUI)
 		circlesGrid.Add(circleUI.widget)
 		imagesGrid.Add(imageUI.widget)
 		uiMutex.Lock()
@@ -151,6 +170,97 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 
 	chromaTabs := container.NewAppTabs(container.NewTabItemWithIcon("Colors", theme.ColorPaletteIcon(), circlesTabContent), container.NewTabItemWithIcon("Previews", theme.DocumentIcon(), imagesTabContent))
 	chromaTabs.SetTabLocation(container.TabLocationTop)
+	// Texturas y modelos del paquete instalado de esta skin; se leen al abrir la pestaña.
+	textures := newTexturePreview(ctx, parent)
+	packageTab := container.NewTabItemWithIcon("Package contents", theme.FolderOpenIcon(), textures.content)
+	chromaTabs.Append(packageTab)
+	chromaTabs.OnSelected = func(tab *container.TabItem) {
+		if tab == packageTab {
+			textures.Activate()
+		}
+	}
+
+	// Descarga en curso: barra de progreso y botón para cancelarla. La descarga
+	// sigue aunque se cierre el diálogo; su avance también se ve en la barra de estado.
+	var downloadCancel context.CancelFunc
+	progressBar := widget.NewProgressBar()
+	progressLabel := widget.NewLabel("")
+	cancelDownloadBtn := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), func() {
+		if downloadCancel != nil {
+			downloadCancel()
+		}
+	})
+	progressBox := container.NewVBox(progressLabel, container.NewBorder(nil, nil, nil, cancelDownloadBtn, progressBar))
+	progressBox.Hide()
+	startDownload := func(item installs.Item) {
+		downloading = true
+		downloadButton.Disable()
+		progressBar.SetValue(0)
+		progressLabel.SetText(fmt.Sprintf("Downloading %s...", item.Name))
+		progressBox.Show()
+		dlCtx, dlCancel := context.WithCancel(context.Background())
+		downloadCancel = dlCancel
+		go func() {
+			defer dlCancel()
+			_, err := installs.SharedDownloader.Download(dlCtx, item, func(p installs.Progress) {
+				fyne.Do(func() {
+					if f := p.Fraction(); f >= 0 {
+						progressBar.SetValue(f)
+					} else {
+						progressLabel.SetText(fmt.Sprintf("Downloading %s... %.1f MB", item.Name, float64(p.Downloaded)/(1<<20)))
+					}
+				})
+			})
+			fyne.Do(func() {
+				downloading = false
+				downloadCancel = nil
+				progressBox.Hide()
+				updateDownloadButton()
+				switch {
+				case errors.Is(err, context.Canceled):
+					log.Printf("Download of %s cancelled", item.Name)
+				case err != nil:
+					dialog.ShowError(fmt.Errorf("%s: %s", item.Name, ErrorMessage(err)), parent)
+				default:
+					if currentApp := fyne.CurrentApp(); currentApp != nil {
+						currentApp.SendNotification(&fyne.Notification{Title: "Skin installed", Content: item.Name})
+					}
+				}
+			})
+		}()
+	}
+
+	// Lo instalado de este campeón (cualquier skin), con opción de desinstalar.
+	champID := data.GetChampionIDFromSkinID(skin.ID)
+	installedBox := container.NewVBox()
+	refreshInstallState := func() {
+		updateDownloadButton()
+		installedBox.Objects = nil
+		var skinEntry installs.Entry
+		skinInstalled := false
+		for _, e := range installs.Shared.ForChampion(champID) {
+			entry := e
+			if entry.SkinID == skin.ID && !skinInstalled {
+				skinEntry, skinInstalled = entry, true
+			}
+			label := widget.NewLabel("Installed: " + entry.Name)
+			label.Truncation = fyne.TextTruncateEllipsis
+			uninstallBtn := widget.NewButtonWithIcon("Uninstall", theme.DeleteIcon(), func() {
+				dialog.ShowConfirm("Uninstall", fmt.Sprintf("Uninstall %s?", entry.Name), func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := installs.Shared.Remove(entry.ItemID()); err != nil {
+						log.Printf("ERROR: Uninstall %s (ID %d): %v", entry.Name, entry.ItemID(), err)
+						dialog.ShowError(fmt.Errorf("could not uninstall %s: %s", entry.Name, ErrorMessage(err)), parent)
+					}
+				}, parent)
+			})
+			installedBox.Add(container.NewBorder(nil, nil, widget.NewIcon(theme.ConfirmIcon()), uninstallBtn, label))
+		}
+		installedBox.Refresh()
+		textures.SetEntry(skinEntry, skinInstalled)
+	}
 
 	creditsText := "Note: Downloading may require credits (Not Implemented)."
 	creditsInfoLabel := widget.NewLabel(creditsText)
@@ -164,56 +274,42 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 		container.NewPadded(selectDownloadLabel), // Con padding
 		chromaTabs,                               // Tabs con scroll
 		layout.NewSpacer(),                       // Empuja créditos abajo
+		progressBox,
+		installedBox,
 		container.NewPadded(creditsBox),
 	)
 	// Añadir padding general al panel derecho
 	paddedRightPanel := container.NewPadded(rightPanel)
 	// -----------------------------------------
 
-	// --- Goroutine para fetch nombres (Sin cambios aquí) ---
-	go func(champID int, skinID int) { /* ... como antes ... */
+	// --- Goroutine para fetch de los nombres que no trae skins.json ---
+	fetchChromaNames := func(champID int, skinID int) {
 		defer func() {
 			if r := recover(); r != nil {
 				log.Printf("PANIC recovered fetching chroma names: %v\n%s", r, string(debug.Stack()))
 			}
 		}()
 		log.Printf("Fetching rich chroma data for champion %d from Supabase...", champID)
-		richData, err := data.FetchChampionJsonFromSupabase(champID)
+		richData, err := data.FetchChampionJsonFromSupabase(ctx, champID)
+		if ctx.Err() != nil {
+			return // Diálogo cerrado antes de terminar
+		}
 		if err != nil {
 			log.Printf("WARN: Failed to fetch rich chroma data: %v", err)
 			return
 		}
-		chromaNames := make(map[int]string)
-		if skinsData, ok := richData["skins"].([]interface{}); ok { /* ... parseo JSON ... */
-			for _, skinInterface := range skinsData {
-				if skinMap, ok := skinInterface.(map[string]interface{}); ok {
-					currentSkinIDFloat, okID := skinMap["id"].(float64)
-					if !okID {
-						continue
-					}
-					currentSkinID := int(currentSkinIDFloat)
-					if currentSkinID == skinID {
-						if chromasData, ok := skinMap["chromas"].([]interface{}); ok {
-							for _, chromaInterface := range chromasData {
-								if chromaMap, ok := chromaInterface.(map[string]interface{}); ok {
-									chromaIDFloat, okCID := chromaMap["id"].(float64)
-									chromaNameStr, okCName := chromaMap["name"].(string)
-									if okCID && okCName {
-										chromaNames[int(chromaIDFloat)] = chromaNameStr
-									}
-								}
-							}
-						}
-						break
-					}
-				}
-			}
-		}
+		chromaNames := richData.ChromaNames(skinID)
 		log.Printf("Found %d chroma names from Supabase.", len(chromaNames))
 		fyne.Do(func() {
+			if ctx.Err() != nil {
+				return
+			}
 			uiMutex.Lock()
 			defer uiMutex.Unlock()
 			for id, name := range chromaNames {
+				if !unnamedChromas[id] {
+					continue
+				}
 				if itemUI, ok := chromaCircleItems[id]; ok && itemUI.nameLabel != nil {
 					itemUI.nameLabel.SetText(name)
 				}
@@ -228,7 +324,10 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 				imagesGrid.Refresh()
 			}
 		})
-	}(data.GetChampionIDFromSkinID(skin.ID), skin.ID)
+	}
+	if len(unnamedChromas) > 0 {
+		go fetchChromaNames(data.GetChampionIDFromSkinID(skin.ID), skin.ID)
+	}
 
 	// --- Layout principal del diálogo (HBox) ---
 	// Poner panel izquierdo y derecho uno al lado del otro
@@ -239,33 +338,39 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 	dialogMainContent.Offset = 0.5 // Ajusta el punto de división si es necesario (0.0 a 1.0)
 
 	// --- Botones de Acción (Abajo a la derecha) ---
-	downloadButton = widget.NewButtonWithIcon("Download Skin", theme.DownloadIcon(), func() { /* ... descarga ... */
-		if selectedChromaID == nil {
+	downloadButton = widget.NewButtonWithIcon("Download Skin", theme.DownloadIcon(), func() {
+		if selectedChromaID == nil || downloading {
 			return
 		}
-		downloadID := *selectedChromaID
-		downloadType := "Skin"
-		downloadName := skin.Name
-		if downloadID != skin.ID {
-			downloadType = "Chroma"
-			found := false
+		item := installs.Item{ChampionID: champID, SkinID: skin.ID, Name: skin.Name}
+		if downloadID := *selectedChromaID; downloadID != skin.ID {
+			item.ChromaID = downloadID
+			item.Name = fmt.Sprintf("%s (Chroma %d)", skin.Name, downloadID)
 			for _, ch := range filteredChromas {
-				if ch.ID == downloadID {
-					downloadName = ch.Name
-					found = true
+				if ch.ID == downloadID && strings.TrimSpace(ch.Name) != "" {
+					item.Name = ch.Name
 					break
 				}
 			}
-			if !found {
-				downloadName = fmt.Sprintf("Chroma ID %d", downloadID)
-			}
 		}
-		log.Printf("DOWNLOAD ACTION: Type=%s, Name=%s, ID=%d (Origin Skin: %d)", downloadType, downloadName, downloadID, skin.ID)
-		currentApp := fyne.CurrentApp()
-		if currentApp != nil {
-			currentApp.SendNotification(&fyne.Notification{Title: "Download Initiated", Content: fmt.Sprintf("Starting download for %s: %s (ID: %d)", downloadType, downloadName, downloadID)})
+		log.Printf("DOWNLOAD ACTION: %s (ID %d, origin skin %d)", item.Name, item.ItemID(), skin.ID)
+		// Solo una skin por campeón: si hay otra instalada se pide confirmación para sustituirla.
+		conflicts := installs.Shared.Conflicts(installs.Entry{ChampionID: item.ChampionID, SkinID: item.SkinID, ChromaID: item.ChromaID})
+		if len(conflicts) == 0 {
+			startDownload(item)
+			return
+		}
+		names := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			names[i] = c.Name
 		}
-		dialog.ShowInformation("Download", fmt.Sprintf("Placeholder: Download initiated for %s: %s (ID: %d)", downloadType, downloadName, downloadID), parent)
+		msg := fmt.Sprintf("%s is already installed for this champion.\nOnly one skin per champion can be installed.\n\nReplace it with %s?", strings.Join(names, ", "), item.Name)
+		dialog.ShowCustomConfirm("Replace installed skin", "Replace", "Cancel", widget.NewLabel(msg), func(ok bool) {
+			if ok {
+				item.Replace = true
+				startDownload(item)
+			}
+		}, parent)
 	})
 	closeButton := widget.NewButton("Close", func() {})
 	// Usa Border para poner los botones abajo a la derecha
@@ -288,6 +393,12 @@ func ShowSkinDialog(skin data.Skin, allChromasForChamp []data.Chroma, parent fyn
 
 	customDialog := dialog.NewCustom(skin.Name, "", finalDialogContent, parent)
 	closeButton.OnTapped = customDialog.Hide
+	refreshInstallState()
+	removeListener := installs.Shared.OnChange(func() { fyne.Do(refreshInstallState) })
+	customDialog.SetOnClosed(func() {
+		removeListener()
+		cancel()
+	})
 	customDialog.Resize(fyne.NewSize(850, 550))
 	customDialog.Show()
 }
@@ -355,7 +466,7 @@ func createChromaCircleItem(name string, hexColors []string, itemID int, selecte
 }
 
 // Helper createChromaImageItem - Ajusta placeholder
-func createChromaImageItem(name string, chroma data.Chroma, itemID int, selectedID *int, onSelect func(id int)) chromaItemUI {
+func createChromaImageItem(ctx context.Context, name string, chroma data.Chroma, itemID int, selectedID *int, onSelect func(id int)) chromaItemUI {
 	const imgSize float32 = 64
 	imgAreaSize := fyne.NewSize(imgSize, imgSize)
 	imageStack := container.NewStack()
@@ -366,25 +477,12 @@ func createChromaImageItem(name string, chroma data.Chroma, itemID int, selected
 	imageStack.Add(placeholderRect)
 	imageStack.Add(container.NewCenter(placeholderIcon))
 	if name != "Default" {
-		go func(ch data.Chroma, stack *fyne.Container) { /* ... carga imagen async ... */
-			imageURL := data.GetChromaImageURL(ch)
-			if imageURL == data.GetPlaceholderImageURL() {
-				return
-			}
-			chromaUri, err := storage.ParseURI(imageURL)
-			if err != nil {
-				return
-			}
-			imgWidget := canvas.NewImageFromURI(chromaUri)
-			imgWidget.FillMode = canvas.ImageFillContain
-			imgWidget.SetMinSize(imgAreaSize)
-			fyne.Do(func() {
-				if stack != nil && stack.Visible() {
-					stack.Objects = []fyne.CanvasObject{imgWidget}
-					stack.Refresh()
-				}
-			})
-		}(chroma, imageStack)
+		if imageURL := data.GetChromaImageURL(chroma); imageURL != data.GetPlaceholderImageURL() { /* ... carga imagen async ... */
+			loadImageAsync(ctx, imageURL, imgAreaSize, canvas.ImageFillContain, nil, func(imgWidget *canvas.Image) {
+				imageStack.Objects = []fyne.CanvasObject{imgWidget}
+				imageStack.Refresh()
+			}, nil)
+		}
 	} else {
 		defaultIcon := widget.NewIcon(theme.RadioButtonCheckedIcon())
 		imageStack.Objects = []fyne.CanvasObject{placeholderRect, container.NewCenter(defaultIcon)}
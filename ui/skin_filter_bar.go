@@ -0,0 +1,112 @@
+// skinhunter/ui/skin_filter_bar.go
+package ui
+
+import (
+	"skinhunter/data"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SkinFilterBar es la barra de filtros y orden de un grid de skins. Solo guarda el
+// estado; quien la usa aplica data.FilterSkins en OnChanged sin volver a pedir datos.
+type SkinFilterBar struct {
+	widget.BaseWidget
+	// OnChanged se llama tras cualquier cambio del usuario.
+	OnChanged func(filter data.SkinFilter, sortBy data.SkinSort)
+
+	content  fyne.CanvasObject
+	rarities *widget.CheckGroup
+	legacy   *widget.Check
+	chromas  *widget.Check
+	sortSel  *widget.Select
+	resetBtn *widget.Button
+
+	filter data.SkinFilter
+	sortBy data.SkinSort
+	quiet  bool // true mientras se fija el estado por código (Reset)
+}
+
+// NewSkinFilterBar crea la barra sin filtros y ordenada por ID.
+func NewSkinFilterBar() *SkinFilterBar {
+	b := &SkinFilterBar{}
+	b.ExtendBaseWidget(b)
+
+	b.rarities = widget.NewCheckGroup(data.SkinRarities, func(selected []string) {
+		b.filter.Rarities = append([]string(nil), selected...)
+		b.changed()
+	})
+	b.rarities.Horizontal = true
+	b.legacy = widget.NewCheck("Legacy only", func(on bool) {
+		b.filter.LegacyOnly = on
+		b.changed()
+	})
+	b.chromas = widget.NewCheck("Has chromas", func(on bool) {
+		b.filter.HasChromas = on
+		b.changed()
+	})
+
+	b.resetBtn = widget.NewButtonWithIcon("", theme.ContentClearIcon(), b.Reset)
+	b.resetBtn.Disable()
+
+	sorts := []data.SkinSort{data.SortSkinsByID, data.SortSkinsByName, data.SortSkinsByRarity}
+	sortNames := make([]string, len(sorts))
+	for i, s := range sorts {
+		sortNames[i] = s.String()
+	}
+	b.sortSel = widget.NewSelect(sortNames, func(name string) {
+		for _, s := range sorts {
+			if s.String() == name {
+				b.sortBy = s
+			}
+		}
+		b.changed()
+	})
+	b.sortSel.SetSelected(data.SortSkinsByID.String())
+
+	row1 := container.NewHBox(widget.NewLabel("Rarity:"), b.rarities)
+	row2 := container.NewHBox(b.legacy, b.chromas, layout.NewSpacer(), widget.NewLabel("Sort by:"), b.sortSel, b.resetBtn)
+	b.content = container.NewVBox(row1, row2)
+	return b
+}
+
+// CreateRenderer returns the renderer for the SkinFilterBar.
+func (b *SkinFilterBar) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(b.content)
+}
+
+// Filter devuelve el filtro y el orden actuales.
+func (b *SkinFilterBar) Filter() (data.SkinFilter, data.SkinSort) {
+	return b.filter, b.sortBy
+}
+
+// Reset quita todos los filtros y vuelve al orden por ID, avisando una sola vez.
+func (b *SkinFilterBar) Reset() {
+	b.quiet = true
+	b.rarities.SetSelected(nil)
+	b.legacy.SetChecked(false)
+	b.chromas.SetChecked(false)
+	b.sortSel.SetSelected(data.SortSkinsByID.String())
+	b.quiet = false
+	b.filter, b.sortBy = data.SkinFilter{}, data.SortSkinsByID
+	b.changed()
+}
+
+func (b *SkinFilterBar) changed() {
+	if b.quiet {
+		return
+	}
+	if b.filter.IsZero() && b.sortBy == data.SortSkinsByID {
+		b.resetBtn.Disable()
+	} else {
+		b.resetBtn.Enable()
+	}
+	if b.OnChanged != nil {
+		b.OnChanged(b.filter, b.sortBy)
+	}
+}
+
+// --- End of skin_filter_bar.go ---
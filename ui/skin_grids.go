@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
@@ -10,6 +11,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	// "image/color" // No longer needed directly here
 	// "fyne.io/fyne/v2/canvas" // No longer needed directly here
@@ -27,6 +29,11 @@ type SkinsGrid struct {
 	scroll        *container.Scroll
 	gridContainer *fyne.Container // The GridWrap container
 	cellSize      fyne.Size
+	emptyText     string
+	tracker       *viewportTracker
+
+	// cancel aborta la población y las cargas de imágenes de la última UpdateSkins.
+	cancel context.CancelFunc
 
 	mu sync.Mutex
 }
@@ -36,35 +43,59 @@ func NewSkinsGrid(onSkinSelect func(skin data.Skin)) *SkinsGrid {
 	sg := &SkinsGrid{
 		onSkinSelect: onSkinSelect,
 		cellSize:     fyne.NewSize(210, 200),
+		emptyText:    "This champion has no additional skins.",
 	}
 	sg.ExtendBaseWidget(sg)
 	sg.gridContainer = container.NewGridWrap(sg.cellSize)
 	paddedGrid := container.NewPadded(sg.gridContainer)
 	sg.scroll = container.NewScroll(paddedGrid)
+	sg.tracker = newViewportTracker(sg.scroll, theme.Padding())
 	return sg
 }
 
+// Cancel detiene la población en curso y descarta las imágenes pendientes.
+func (sg *SkinsGrid) Cancel() {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.cancel != nil {
+		sg.cancel()
+		sg.cancel = nil
+	}
+}
+
 // CreateRenderer returns the renderer for the SkinsGrid.
 func (sg *SkinsGrid) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(sg.scroll)
 }
 
+// SetEmptyText cambia el mensaje que se muestra cuando no hay skins que enseñar.
+func (sg *SkinsGrid) SetEmptyText(text string) { sg.emptyText = text }
+
 // UpdateSkins clears the grid and populates it incrementally in the background.
 func (sg *SkinsGrid) UpdateSkins(skins []data.Skin) {
 	log.Printf("SkinsGrid: Updating with %d skins...", len(skins))
+	sg.Cancel()
+	ctx, cancel := context.WithCancel(context.Background())
 	sg.mu.Lock()
 	if sg.gridContainer == nil {
 		sg.mu.Unlock()
+		cancel()
 		return
 	}
+	sg.cancel = cancel
 	sg.gridContainer.Objects = []fyne.CanvasObject{}
 	sg.gridContainer.Refresh()
-	sg.scroll.Refresh()
+	sg.scroll.ScrollToTop()
+	sg.tracker.Reset()
 	sg.mu.Unlock()
 
 	go func(skinsToProcess []data.Skin) {
 		if skinsToProcess == nil {
-			fyne.Do(func() { sg.showPlaceholder("No skins data available.") })
+			fyne.Do(func() {
+				if ctx.Err() == nil {
+					sg.showPlaceholder("No skins data available.")
+				}
+			})
 			return
 		}
 		nonBaseSkins := make([]data.Skin, 0, len(skinsToProcess))
@@ -74,17 +105,30 @@ func (sg *SkinsGrid) UpdateSkins(skins []data.Skin) {
 			}
 		}
 		if len(nonBaseSkins) == 0 {
-			fyne.Do(func() { sg.showPlaceholder("This champion has no additional skins.") })
+			fyne.Do(func() {
+				if ctx.Err() == nil {
+					sg.showPlaceholder(sg.emptyText)
+				}
+			})
 			return
 		}
 
 		log.Printf("SkinsGrid POPULATE: Starting item creation for %d skins...", len(nonBaseSkins))
-		fyne.Do(func() { sg.showPlaceholder("Loading skins...") })
+		fyne.Do(func() {
+			if ctx.Err() == nil {
+				sg.showPlaceholder("Loading skins...")
+			}
+		})
 
 		for i, skin := range nonBaseSkins {
+			if ctx.Err() != nil {
+				log.Printf("SkinsGrid POPULATE: Cancelled after %d of %d skins.", i, len(nonBaseSkins))
+				return
+			}
 			skinCopy := skin
+			prio := newLoadPriority(priorityVisible)
 			// Create SkinItem which returns a TappableCard (defined in utils.go)
-			item := SkinItem(skinCopy, func(selectedSkin data.Skin) {
+			item := SkinItem(ctx, skinCopy, prio, func(selectedSkin data.Skin) {
 				if sg.onSkinSelect != nil {
 					sg.onSkinSelect(selectedSkin)
 				}
@@ -93,7 +137,7 @@ func (sg *SkinsGrid) UpdateSkins(skins []data.Skin) {
 				fyne.Do(func() {
 					sg.mu.Lock()
 					defer sg.mu.Unlock()
-					if sg.gridContainer != nil {
+					if ctx.Err() == nil && sg.gridContainer != nil {
 						if len(sg.gridContainer.Objects) == 1 {
 							if _, ok := sg.gridContainer.Objects[0].(*fyne.Container); ok {
 								sg.gridContainer.Objects = []fyne.CanvasObject{}
@@ -101,6 +145,7 @@ func (sg *SkinsGrid) UpdateSkins(skins []data.Skin) {
 						}
 						sg.gridContainer.Objects = append(sg.gridContainer.Objects, item)
 						sg.gridContainer.Refresh() // Refresh grid on add
+						sg.tracker.Track(item, prio)
 						if i > 0 && i%10 == 0 {
 							sg.scroll.Refresh()
 						} // Refresh scroll periodically
@@ -110,8 +155,9 @@ func (sg *SkinsGrid) UpdateSkins(skins []data.Skin) {
 			time.Sleep(20 * time.Millisecond)
 		}
 		fyne.Do(func() {
-			if sg.scroll != nil {
+			if ctx.Err() == nil && sg.scroll != nil {
 				sg.scroll.Refresh()
+				sg.tracker.Update()
 			}
 			log.Printf("SkinsGrid POPULATE: Population complete.")
 		})
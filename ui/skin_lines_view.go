@@ -0,0 +1,159 @@
+// skinhunter/ui/skin_lines_view.go
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"skinhunter/data"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SkinLinesView lista las skin lines a la izquierda y, al elegir una, muestra
+// todas sus skins (de cualquier campeón) en un SkinsGrid a la derecha.
+type SkinLinesView struct {
+	widget.BaseWidget
+	onSkinSelect func(skin data.Skin, allChromas []data.Chroma)
+
+	content    *container.Split
+	filter     *widget.Entry
+	list       *widget.List
+	titleLabel *widget.Label
+	descLabel  *widget.Label
+	skinsGrid  *SkinsGrid
+
+	allLines      []data.SkinLine
+	filteredLines []data.SkinLine
+	currentLineID int
+}
+
+// NewSkinLinesView crea la vista; los datos se cargan con Reload.
+func NewSkinLinesView(onSkinSelect func(skin data.Skin, allChromas []data.Chroma)) *SkinLinesView {
+	v := &SkinLinesView{onSkinSelect: onSkinSelect, currentLineID: -1}
+	v.ExtendBaseWidget(v)
+
+	v.filter = widget.NewEntry()
+	v.filter.SetPlaceHolder("Filter skin lines...")
+	v.filter.OnChanged = func(string) { v.applyFilter() }
+
+	v.list = widget.NewList(
+		func() int { return len(v.filteredLines) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("Skin line")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			if id < len(v.filteredLines) {
+				o.(*widget.Label).SetText(v.filteredLines[id].Name)
+			}
+		},
+	)
+	v.list.OnSelected = func(id widget.ListItemID) {
+		if id < len(v.filteredLines) {
+			v.showSkinLine(v.filteredLines[id])
+		}
+	}
+
+	v.titleLabel = widget.NewLabelWithStyle("Select a skin line", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	v.descLabel = widget.NewLabel("")
+	v.descLabel.Wrapping = fyne.TextWrapWord
+	v.descLabel.Hide()
+	v.skinsGrid = NewSkinsGrid(func(skin data.Skin) {
+		if v.onSkinSelect != nil {
+			v.onSkinSelect(skin, skin.Chromas)
+		}
+	})
+	v.skinsGrid.SetEmptyText("This skin line has no skins.")
+
+	left := container.NewBorder(container.NewPadded(v.filter), nil, nil, nil, v.list)
+	header := container.NewVBox(container.NewHBox(widget.NewIcon(theme.ColorPaletteIcon()), v.titleLabel), v.descLabel, widget.NewSeparator())
+	right := container.NewBorder(container.NewPadded(header), nil, nil, nil, v.skinsGrid)
+	v.content = container.NewHSplit(left, right)
+	v.content.Offset = 0.25
+	return v
+}
+
+// CreateRenderer returns the renderer for the SkinLinesView.
+func (v *SkinLinesView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.content)
+}
+
+// Reload vuelve a leer las skin lines de la capa de datos. Si ctx se cancela
+// antes de terminar, la vista no se toca.
+func (v *SkinLinesView) Reload(ctx context.Context) {
+	go func() {
+		lines, err := data.GetSkinLines(ctx)
+		fyne.Do(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("SkinLinesView ERROR loading skin lines: %v", err)
+				v.allLines = nil
+				v.titleLabel.SetText("Skin lines are not available")
+				v.skinsGrid.showPlaceholder(ErrorMessage(err))
+			} else {
+				v.allLines = lines
+			}
+			v.applyFilter()
+		})
+	}()
+}
+
+func (v *SkinLinesView) applyFilter() {
+	term := strings.ToLower(strings.TrimSpace(v.filter.Text))
+	v.filteredLines = v.filteredLines[:0]
+	for _, sl := range v.allLines {
+		if term == "" || strings.Contains(strings.ToLower(sl.Name), term) {
+			v.filteredLines = append(v.filteredLines, sl)
+		}
+	}
+	v.list.UnselectAll()
+	v.list.Refresh()
+}
+
+// ShowSkinLine selecciona la skin line id en la lista (quitando el filtro) y muestra sus skins.
+func (v *SkinLinesView) ShowSkinLine(id int) {
+	if v.filter.Text != "" {
+		v.filter.SetText("") // OnChanged reaplica el filtro
+	}
+	for i, sl := range v.filteredLines {
+		if sl.ID == id {
+			v.list.Select(i)
+			v.list.ScrollTo(i)
+			return
+		}
+	}
+	log.Printf("SkinLinesView: skin line %d not found", id)
+}
+
+func (v *SkinLinesView) showSkinLine(sl data.SkinLine) {
+	if sl.ID == v.currentLineID {
+		return
+	}
+	v.currentLineID = sl.ID
+	log.Printf("SkinLinesView: Showing skin line %s (ID: %d)", sl.Name, sl.ID)
+	skins, err := data.GetSkinsForSkinLine(sl.ID)
+	if err != nil {
+		v.titleLabel.SetText(sl.Name)
+		v.skinsGrid.showPlaceholder(fmt.Sprintf("Error loading skins for %s", sl.Name))
+		return
+	}
+	v.titleLabel.SetText(fmt.Sprintf("%s Collection (%d skins)", sl.Name, len(skins)))
+	if sl.Description != "" {
+		v.descLabel.SetText(sl.Description)
+		v.descLabel.Show()
+	} else {
+		v.descLabel.Hide()
+	}
+	v.skinsGrid.UpdateSkins(skins)
+}
+
+// --- End of skin_lines_view.go ---
@@ -0,0 +1,116 @@
+// skinhunter/ui/skin_query_view.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"skinhunter/data"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Consultas de ejemplo del desplegable; la sintaxis completa está en data/query.go.
+var exampleSkinQueries = []string{
+	`rarity:ultimate legacy:true`,
+	`rarity:>=legendary chromas:>3`,
+	`line:"Star Guardian" rarity:epic`,
+	`champion:ahri -legacy:true`,
+	`(line:"Spirit Blossom" OR line:"Star Guardian") chromas:true`,
+}
+
+const skinQueryHelp = "Fields: name, champion, line, rarity, legacy, base, chromas, id. " +
+	"Compare with >, >=, <, <=; combine with OR, -term and (...). Quote values with spaces."
+
+// SkinQueryView ejecuta una consulta estructurada (data.ParseSkinQuery) sobre todas
+// las skins del catálogo y muestra el resultado en un SkinsGrid.
+type SkinQueryView struct {
+	widget.BaseWidget
+	onSkinSelect func(skin data.Skin, allChromas []data.Chroma)
+
+	content   fyne.CanvasObject
+	entry     *widget.Entry
+	status    *widget.Label
+	skinsGrid *SkinsGrid
+}
+
+// NewSkinQueryView crea la vista vacía; la consulta se ejecuta con Enter o "Run".
+func NewSkinQueryView(onSkinSelect func(skin data.Skin, allChromas []data.Chroma)) *SkinQueryView {
+	v := &SkinQueryView{onSkinSelect: onSkinSelect}
+	v.ExtendBaseWidget(v)
+
+	v.entry = widget.NewEntry()
+	v.entry.SetPlaceHolder(`e.g. rarity:epic legacy:true chromas:>3 line:"Star Guardian"`)
+	v.entry.OnSubmitted = func(string) { v.Run() }
+	runBtn := widget.NewButtonWithIcon("Run", theme.SearchIcon(), v.Run)
+	runBtn.Importance = widget.HighImportance
+	examples := widget.NewSelect(exampleSkinQueries, func(q string) {
+		if q != "" {
+			v.entry.SetText(q)
+			v.Run()
+		}
+	})
+	examples.PlaceHolder = "Examples"
+
+	help := widget.NewLabelWithStyle(skinQueryHelp, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+	help.Wrapping = fyne.TextWrapWord
+	v.status = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	v.status.Wrapping = fyne.TextWrapWord
+
+	v.skinsGrid = NewSkinsGrid(func(skin data.Skin) {
+		if v.onSkinSelect != nil {
+			v.onSkinSelect(skin, skin.Chromas)
+		}
+	})
+	v.skinsGrid.SetEmptyText("No skins match this query.")
+	v.skinsGrid.showPlaceholder("Enter a query to list matching skins.")
+
+	bar := container.NewBorder(nil, nil, nil, container.NewHBox(examples, runBtn), v.entry)
+	header := container.NewVBox(bar, help, v.status, widget.NewSeparator())
+	v.content = container.NewBorder(container.NewPadded(header), nil, nil, nil, v.skinsGrid)
+	return v
+}
+
+// CreateRenderer returns the renderer for the SkinQueryView.
+func (v *SkinQueryView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.content)
+}
+
+// Run analiza y ejecuta la consulta del campo de texto.
+func (v *SkinQueryView) Run() {
+	text := v.entry.Text
+	q, err := data.ParseSkinQuery(text)
+	if err != nil {
+		v.status.SetText(err.Error())
+		var qe *data.QueryError
+		if !errors.As(err, &qe) || qe.Msg != "empty query" {
+			log.Printf("SkinQueryView: %v", err)
+		}
+		return
+	}
+	skins, err := data.QuerySkins(q)
+	if err != nil {
+		log.Printf("SkinQueryView ERROR running %q: %v", text, err)
+		v.status.SetText(ErrorMessage(err))
+		v.skinsGrid.showPlaceholder("Skin data is not loaded yet.")
+		return
+	}
+	shown := 0
+	for _, s := range skins {
+		if !s.IsBase { // SkinsGrid no muestra las skins base
+			shown++
+		}
+	}
+	log.Printf("SkinQueryView: %q matched %d skins", text, shown)
+	v.status.SetText(fmt.Sprintf("%d skins", shown))
+	v.skinsGrid.UpdateSkins(skins)
+}
+
+// Cancel detiene la carga de imágenes del resultado actual.
+func (v *SkinQueryView) Cancel() { v.skinsGrid.Cancel() }
+
+// --- End of skin_query_view.go ---
@@ -0,0 +1,152 @@
+// skinhunter/ui/texture_preview.go
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+
+	"skinhunter/fantome"
+	"skinhunter/hashes"
+	"skinhunter/installs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// textureRow es una textura o malla del paquete.
+type textureRow struct {
+	path string
+	hash uint64
+}
+
+// texturePreview es la pestaña "Package" del diálogo de skin: lista las texturas
+// (.tex y .dds) y mallas (.skn, .skl) del paquete instalado y muestra la elegida con
+// assetPreview. No lee nada hasta que se abre la pestaña.
+type texturePreview struct {
+	ctx context.Context
+
+	entry    installs.Entry
+	hasEntry bool
+	active   bool   // La pestaña se ha abierto alguna vez
+	loadedOf string // Paquete del listado actual ("" = sin listar)
+	seq      int    // Descarta listados de paquetes anteriores
+
+	textures []textureRow
+	list     *widget.List
+	preview  *assetPreview
+	content  fyne.CanvasObject
+}
+
+func newTexturePreview(ctx context.Context, parent fyne.Window) *texturePreview {
+	p := &texturePreview{ctx: ctx, preview: newAssetPreview(ctx, parent)}
+	p.list = widget.NewList(
+		func() int { return len(p.textures) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(path.Base(p.textures[id].path))
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		f := p.textures[id]
+		p.preview.Show(p.entry, f.path, f.hash)
+	}
+	split := container.NewHSplit(p.list, p.preview.content)
+	split.Offset = 0.45
+	p.content = split
+	return p
+}
+
+// SetEntry cambia el paquete que se muestra (ok = false si la skin no está instalada).
+func (p *texturePreview) SetEntry(e installs.Entry, ok bool) {
+	p.entry, p.hasEntry = e, ok
+	if p.active {
+		p.load()
+	}
+}
+
+// Activate se llama al abrir la pestaña.
+func (p *texturePreview) Activate() {
+	p.active = true
+	p.load()
+}
+
+// load lista las texturas del paquete si no está ya listado.
+func (p *texturePreview) load() {
+	if !p.hasEntry {
+		p.seq++
+		p.loadedOf = ""
+		p.textures = nil
+		p.list.Refresh()
+		p.preview.Clear("Install this skin to browse the textures and models of its package.")
+		return
+	}
+	key := p.entry.PackagePath + "\x00" + p.entry.Checksum
+	if key == p.loadedOf {
+		return
+	}
+	p.loadedOf = key
+	p.seq++
+	seq, e := p.seq, p.entry
+	p.textures = nil
+	p.list.UnselectAll()
+	p.list.Refresh()
+	p.preview.Clear("Reading package and loading hash tables...")
+	go func() {
+		rows, err := textureRows(p.ctx, e)
+		if p.ctx.Err() != nil {
+			return
+		}
+		fyne.Do(func() {
+			if seq != p.seq {
+				return
+			}
+			if err != nil {
+				log.Printf("ERROR: Textures of %s (ID %d): %v", e.Name, e.ItemID(), err)
+				p.loadedOf = "" // Se reintenta al volver a abrir la pestaña
+				p.preview.Clear(ErrorMessage(err))
+				return
+			}
+			p.textures = rows
+			p.list.Refresh()
+			if len(rows) == 0 {
+				p.preview.Clear("No textures or models with a known path in this package.")
+			} else {
+				p.preview.Clear(fmt.Sprintf("%d textures and models. Select one to preview it.", len(rows)))
+			}
+		})
+	}()
+}
+
+// textureRows devuelve las texturas y mallas con ruta conocida del paquete de e, ordenadas.
+func textureRows(ctx context.Context, e installs.Entry) ([]textureRow, error) {
+	contents, err := fantome.Contents(e.PackagePath)
+	if err != nil {
+		return nil, err
+	}
+	table, err := hashes.Shared.Table(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows []textureRow
+	seen := make(map[uint64]bool)
+	for _, wc := range contents {
+		for _, h := range wc.Hashes {
+			if p, ok := table.Path(h); ok && !seen[h] && previewable(p) {
+				seen[h] = true
+				rows = append(rows, textureRow{p, h})
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].path < rows[j].path })
+	return rows, nil
+}
+
+// --- End of texture_preview.go ---
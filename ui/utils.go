@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"context"
 	"image/color"
 	"log"
 	"net/url"
@@ -11,7 +12,6 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
@@ -19,8 +19,9 @@ import (
 )
 
 // SkinItem crea el objeto visual para una skin, usando TappableCard.
-// Ya no necesita parámetro 'app' o 'window', usa fyne.Do internamente.
-func SkinItem(skin data.Skin, onSelect func(skin data.Skin)) fyne.CanvasObject {
+// Las imágenes se cargan con el imageLoader compartido: ctx cancela las cargas
+// pendientes (cambio de campeón) y prio decide el orden frente a otros items.
+func SkinItem(ctx context.Context, skin data.Skin, prio *loadPriority, onSelect func(skin data.Skin)) fyne.CanvasObject {
 	if skin.IsBase {
 		return nil
 	}
@@ -37,25 +38,12 @@ func SkinItem(skin data.Skin, onSelect func(skin data.Skin)) fyne.CanvasObject {
 	placeholderRect.SetMinSize(imgSize)
 	imageContainer := container.NewStack(placeholderRect, container.NewCenter(placeholderIcon))
 
-	go func() { // Load main image
-		imgURL := data.GetSkinTileURL(skin)
-		if imgURL == data.GetPlaceholderImageURL() {
-			return
-		}
-		uri, err := storage.ParseURI(imgURL)
-		if err != nil {
-			return
-		}
-		loadedImage := canvas.NewImageFromURI(uri)
-		loadedImage.FillMode = canvas.ImageFillContain
-		loadedImage.SetMinSize(imgSize)
-		fyne.Do(func() {
-			if imageContainer != nil && imageContainer.Visible() {
-				imageContainer.Objects = []fyne.CanvasObject{loadedImage}
-				imageContainer.Refresh()
-			}
-		})
-	}()
+	if imgURL := data.GetSkinTileURL(skin); imgURL != data.GetPlaceholderImageURL() { // Load main image
+		loadImageAsync(ctx, imgURL, imgSize, canvas.ImageFillContain, prio, func(loadedImage *canvas.Image) {
+			imageContainer.Objects = []fyne.CanvasObject{loadedImage}
+			imageContainer.Refresh()
+		}, nil)
+	}
 
 	_, rarityIconURL := data.Rarity(skin)
 	var rarityIconWidget fyne.CanvasObject = layout.NewSpacer()
@@ -64,21 +52,10 @@ func SkinItem(skin data.Skin, onSelect func(skin data.Skin)) fyne.CanvasObject {
 		rarityPlaceholder.SetMinSize(rarityIconSize)
 		rarityIconContainer := container.NewStack(rarityPlaceholder)
 		rarityIconWidget = rarityIconContainer
-		go func(url string, cont *fyne.Container, size fyne.Size) { // Load rarity icon
-			uri, err := storage.ParseURI(url)
-			if err != nil {
-				return
-			}
-			icon := canvas.NewImageFromURI(uri)
-			icon.SetMinSize(size)
-			icon.FillMode = canvas.ImageFillContain
-			fyne.Do(func() {
-				if cont != nil && cont.Visible() {
-					cont.Objects = []fyne.CanvasObject{icon}
-					cont.Refresh()
-				}
-			})
-		}(rarityIconURL, rarityIconContainer, rarityIconSize)
+		loadImageAsync(ctx, rarityIconURL, rarityIconSize, canvas.ImageFillContain, prio, func(icon *canvas.Image) { // Load rarity icon
+			rarityIconContainer.Objects = []fyne.CanvasObject{icon}
+			rarityIconContainer.Refresh()
+		}, nil)
 	}
 
 	topIcons := []fyne.CanvasObject{}
@@ -86,38 +63,17 @@ func SkinItem(skin data.Skin, onSelect func(skin data.Skin)) fyne.CanvasObject {
 		placeholder := canvas.NewRectangle(color.Transparent)
 		placeholder.SetMinSize(size)
 		iconContainer := container.NewStack(placeholder)
-		go func(url string, cont *fyne.Container) { // Load top icons
-			if url == data.GetPlaceholderImageURL() || url == "" {
-				fyne.Do(func() {
-					if cont != nil && cont.Visible() {
-						ph := widget.NewIcon(theme.QuestionIcon())
-						cont.Objects = []fyne.CanvasObject{ph}
-						cont.Refresh()
-					}
-				})
-				return
-			}
-			uri, err := storage.ParseURI(url)
-			if err != nil {
-				fyne.Do(func() {
-					if cont != nil && cont.Visible() {
-						eh := widget.NewIcon(theme.ErrorIcon())
-						cont.Objects = []fyne.CanvasObject{eh}
-						cont.Refresh()
-					}
-				})
-				return
-			}
-			iconImage := canvas.NewImageFromURI(uri)
-			iconImage.SetMinSize(size)
-			iconImage.FillMode = canvas.ImageFillContain
-			fyne.Do(func() {
-				if cont != nil && cont.Visible() {
-					cont.Objects = []fyne.CanvasObject{iconImage}
-					cont.Refresh()
-				}
-			})
-		}(iconURL, iconContainer)
+		if iconURL == data.GetPlaceholderImageURL() || iconURL == "" { // Load top icons
+			iconContainer.Objects = []fyne.CanvasObject{widget.NewIcon(theme.QuestionIcon())}
+			return iconContainer
+		}
+		loadImageAsync(ctx, iconURL, size, canvas.ImageFillContain, prio, func(iconImage *canvas.Image) {
+			iconContainer.Objects = []fyne.CanvasObject{iconImage}
+			iconContainer.Refresh()
+		}, func(error) {
+			iconContainer.Objects = []fyne.CanvasObject{widget.NewIcon(theme.ErrorIcon())}
+			iconContainer.Refresh()
+		})
 		return iconContainer
 	}
 	if skin.IsLegacy {
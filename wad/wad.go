@@ -0,0 +1,330 @@
+// skinhunter/wad/wad.go
+package wad
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/zeebo/xxh3"
+)
+
+// Archivos WAD (.wad.client) del juego, versión 3.x.
+//
+// Cabecera (272 bytes): "RW", versión mayor y menor (1 byte cada una), firma ECDSA
+// (256 bytes), checksum de la cabecera (u64) y número de entradas (u32). Le sigue la
+// tabla de entradas (32 bytes cada una, ordenadas por PathHash) y después los datos.
+// Todo en little-endian.
+
+const (
+	headerSize = 4 + 256 + 8 + 4
+	entrySize  = 32
+
+	// MaxEntrySize limita el tamaño descomprimido que se acepta de una entrada.
+	MaxEntrySize = 1 << 30
+)
+
+var (
+	// ErrBadMagic indica que el fichero no empieza por "RW".
+	ErrBadMagic = errors.New("not a WAD file")
+	// ErrUnsupportedVersion indica una versión distinta de la 3.x.
+	ErrUnsupportedVersion = errors.New("unsupported WAD version")
+	// ErrCorrupt indica una tabla de entradas o unos datos inconsistentes.
+	ErrCorrupt = errors.New("corrupt WAD file")
+	// ErrNotFound indica que no hay entrada con ese hash.
+	ErrNotFound = errors.New("WAD entry not found")
+	// ErrChecksumMismatch indica que los datos de una entrada no cuadran con su checksum.
+	ErrChecksumMismatch = errors.New("WAD entry checksum mismatch")
+)
+
+// UserMessage da el texto para el usuario de un WAD ilegible o una entrada ausente.
+func UserMessage(err error) (msg string, ok bool) {
+	switch {
+	case errors.Is(err, ErrBadMagic), errors.Is(err, ErrCorrupt), errors.Is(err, ErrUnsupportedVersion):
+		return "The mod contains a damaged or unsupported WAD file.", true
+	case errors.Is(err, ErrNotFound):
+		return "The file isn't in the installed package.", true
+	}
+	return "", false
+}
+
+// Compression es el tipo de almacenamiento de una entrada.
+type Compression uint8
+
+const (
+	CompressionNone      Compression = 0
+	CompressionGzip      Compression = 1
+	CompressionLink      Compression = 2 // Los datos son la ruta de otro fichero
+	CompressionZstd      Compression = 3
+	CompressionZstdMulti Compression = 4 // Zstd con subchunks; puede empezar con bytes sin comprimir
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionLink:
+		return "link"
+	case CompressionZstd:
+		return "zstd"
+	case CompressionZstdMulti:
+		return "zstd-multi"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(c))
+}
+
+// Entry es una entrada de la tabla de un WAD.
+type Entry struct {
+	PathHash       uint64 // xxh64 de la ruta en minúsculas; ver HashPath
+	Offset         uint32
+	CompressedSize uint32 // Tamaño en el fichero
+	Size           uint32 // Tamaño descomprimido
+	Compression    Compression
+	SubchunkCount  uint8 // Solo en v3.1+; se conserva tal cual
+	Duplicate      bool  // Comparte los datos con otra entrada
+	FirstSubchunk  uint16
+	Checksum       uint64 // v3.0: primeros 8 bytes del SHA-256; v3.1+: xxh3 de los datos guardados
+}
+
+// HashPath devuelve el hash con el que el juego identifica la ruta p.
+func HashPath(p string) uint64 {
+	return xxhash.Sum64String(strings.ToLower(strings.ReplaceAll(p, "\\", "/")))
+}
+
+// Reader lee un WAD. Es seguro para uso concurrente.
+type Reader struct {
+	Major, Minor uint8
+	Entries      []Entry // En el orden del fichero (por PathHash)
+
+	r      io.ReaderAt
+	index  map[uint64]int
+	closer io.Closer
+}
+
+// Open abre el WAD path. Hay que llamar a Close al terminar.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader lee la cabecera y la tabla de entradas de r, de size bytes.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	var hdr [headerSize]byte
+	n, err := r.ReadAt(hdr[:], 0)
+	if n < 2 || hdr[0] != 'R' || hdr[1] != 'W' {
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return nil, ErrBadMagic
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: truncated header: %v", ErrCorrupt, err)
+	}
+	wr := &Reader{Major: hdr[2], Minor: hdr[3], r: r}
+	if wr.Major != 3 {
+		return nil, fmt.Errorf("%w: %d.%d", ErrUnsupportedVersion, wr.Major, wr.Minor)
+	}
+	count := binary.LittleEndian.Uint32(hdr[headerSize-4:])
+	if int64(count) > (size-headerSize)/entrySize {
+		return nil, fmt.Errorf("%w: %d entries do not fit in %d bytes", ErrCorrupt, count, size)
+	}
+	toc := make([]byte, int(count)*entrySize)
+	if _, err := r.ReadAt(toc, headerSize); err != nil {
+		return nil, fmt.Errorf("%w: read entry table: %v", ErrCorrupt, err)
+	}
+	wr.Entries = make([]Entry, count)
+	wr.index = make(map[uint64]int, count)
+	for i := range wr.Entries {
+		b := toc[i*entrySize : (i+1)*entrySize]
+		e := Entry{
+			PathHash:       binary.LittleEndian.Uint64(b[0:]),
+			Offset:         binary.LittleEndian.Uint32(b[8:]),
+			CompressedSize: binary.LittleEndian.Uint32(b[12:]),
+			Size:           binary.LittleEndian.Uint32(b[16:]),
+			Compression:    Compression(b[20] & 0x0f),
+			SubchunkCount:  b[20] >> 4,
+			Duplicate:      b[21] != 0,
+			FirstSubchunk:  binary.LittleEndian.Uint16(b[22:]),
+			Checksum:       binary.LittleEndian.Uint64(b[24:]),
+		}
+		if int64(e.Offset)+int64(e.CompressedSize) > size {
+			return nil, fmt.Errorf("%w: entry %016x out of bounds", ErrCorrupt, e.PathHash)
+		}
+		wr.Entries[i] = e
+		wr.index[e.PathHash] = i
+	}
+	return wr, nil
+}
+
+// Close cierra el fichero si el Reader se creó con Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Lookup busca la entrada de hash.
+func (r *Reader) Lookup(hash uint64) (Entry, bool) {
+	i, ok := r.index[hash]
+	if !ok {
+		return Entry{}, false
+	}
+	return r.Entries[i], true
+}
+
+// Extract devuelve los datos descomprimidos de la entrada de hash.
+func (r *Reader) Extract(hash uint64) ([]byte, error) {
+	e, ok := r.Lookup(hash)
+	if !ok {
+		return nil, fmt.Errorf("%w: %016x", ErrNotFound, hash)
+	}
+	return r.ReadEntry(e)
+}
+
+// ReadRaw devuelve los datos de e tal como están guardados, tras comprobar su checksum
+// (si la entrada tiene uno).
+func (r *Reader) ReadRaw(e Entry) ([]byte, error) {
+	raw := make([]byte, e.CompressedSize)
+	if _, err := r.r.ReadAt(raw, int64(e.Offset)); err != nil {
+		return nil, fmt.Errorf("%w: read entry %016x: %v", ErrCorrupt, e.PathHash, err)
+	}
+	if e.Checksum != 0 && checksum(r.Minor, raw) != e.Checksum {
+		return nil, fmt.Errorf("%w: %016x", ErrChecksumMismatch, e.PathHash)
+	}
+	return raw, nil
+}
+
+// ReadEntry devuelve los datos descomprimidos de e. Las entradas CompressionLink se
+// devuelven tal cual (la ruta enlazada).
+func (r *Reader) ReadEntry(e Entry) ([]byte, error) {
+	if e.Size > MaxEntrySize {
+		return nil, fmt.Errorf("%w: entry %016x too large (%d bytes)", ErrCorrupt, e.PathHash, e.Size)
+	}
+	raw, err := r.ReadRaw(e)
+	if err != nil {
+		return nil, err
+	}
+	out, err := decompress(e.Compression, raw, int(e.Size))
+	if err != nil {
+		return nil, fmt.Errorf("%w: entry %016x (%s): %v", ErrCorrupt, e.PathHash, e.Compression, err)
+	}
+	if e.Compression != CompressionLink && len(out) != int(e.Size) {
+		return nil, fmt.Errorf("%w: entry %016x is %d bytes, want %d", ErrCorrupt, e.PathHash, len(out), e.Size)
+	}
+	return out, nil
+}
+
+// checksum calcula el checksum de una entrada para la versión menor minor.
+func checksum(minor uint8, raw []byte) uint64 {
+	if minor == 0 {
+		sum := sha256.Sum256(raw)
+		return binary.LittleEndian.Uint64(sum[:8])
+	}
+	return xxh3.Hash(raw)
+}
+
+var (
+	zstdOnce    sync.Once
+	zstdDecoder *zstd.Decoder
+	zstdEncoder *zstd.Encoder
+)
+
+// zstdCodecs crea el codificador y decodificador compartidos (ambos admiten uso concurrente).
+func zstdCodecs() (*zstd.Decoder, *zstd.Encoder) {
+	zstdOnce.Do(func() {
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxEntrySize))
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	})
+	return zstdDecoder, zstdEncoder
+}
+
+// maxPrealloc limita lo que se reserva de entrada para descomprimir: el tamaño viene
+// de la tabla y puede mentir, así que a partir de ahí el búfer crece con los datos.
+const maxPrealloc = 16 << 20
+
+func decompress(c Compression, raw []byte, size int) ([]byte, error) {
+	out := func() []byte { return make([]byte, 0, min(size, maxPrealloc)) }
+	switch c {
+	case CompressionNone, CompressionLink:
+		return raw, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		buf := bytes.NewBuffer(out())
+		// Un byte de más para detectar entradas más largas de lo anunciado.
+		if _, err := io.Copy(buf, io.LimitReader(zr, int64(size)+1)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		dec, _ := zstdCodecs()
+		return dec.DecodeAll(raw, out())
+	case CompressionZstdMulti:
+		return decompressMulti(raw, size, out())
+	}
+	return nil, fmt.Errorf("unknown compression %d", uint8(c))
+}
+
+// decompressMulti descomprime una entrada con subchunks: los que no se comprimen van
+// delante tal cual y el resto son tramas zstd seguidas, que DecodeAll decodifica de una
+// vez. La parte sin comprimir puede contener la firma de zstd por casualidad, así que
+// la primera trama es la primera cabecera válida desde la que el total sale de size bytes.
+func decompressMulti(raw []byte, size int, out []byte) ([]byte, error) {
+	if len(raw) == size {
+		return raw, nil // Ningún subchunk comprimido
+	}
+	dec, _ := zstdCodecs()
+	var lastErr error
+	for start := 0; start < len(raw); start++ {
+		var h zstd.Header
+		if h.Decode(raw[start:]) != nil || h.Skippable {
+			continue
+		}
+		if h.HasFCS && uint64(start)+h.FrameContentSize > uint64(size) {
+			continue
+		}
+		got, err := dec.DecodeAll(raw[start:], append(out[:0], raw[:start]...))
+		if err == nil && len(got) == size {
+			return got, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("frames from offset %d give %d bytes, want %d", start, len(got), size)
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("no zstd frame in %d bytes", len(raw))
+}
+
+// --- End of wad.go ---
@@ -0,0 +1,254 @@
+package wad
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	big := bytes.Repeat([]byte("skinhunter "), 4096)
+	files := []struct {
+		path string
+		data []byte
+		c    Compression
+	}{
+		{"data/characters/ahri/skins/skin15.bin", []byte("PROP bin data"), CompressionNone},
+		{"assets/characters/ahri/skins/skin15/ahri.tex", big, CompressionZstd},
+		{"assets/characters/ahri/skins/skin15/ahri_tail.dds", big[:1000], CompressionGzip},
+		{"assets/characters/ahri/skins/skin15/empty.bin", nil, CompressionZstd},
+		{"assets/characters/ahri/skins/skin15/link.tex", []byte("assets/shared/x.tex"), CompressionLink},
+		{"assets/characters/ahri/skins/skin15/copy.tex", big, CompressionZstd}, // Mismos datos: duplicado
+	}
+	w := NewWriter()
+	for _, f := range files {
+		if err := w.Add(HashPath(f.path), f.data, f.c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "Ahri.wad.client")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Major != 3 || r.Minor != 3 || len(r.Entries) != len(files) {
+		t.Fatalf("version %d.%d with %d entries", r.Major, r.Minor, len(r.Entries))
+	}
+	for i := 1; i < len(r.Entries); i++ {
+		if r.Entries[i-1].PathHash >= r.Entries[i].PathHash {
+			t.Fatalf("entries not sorted by hash")
+		}
+	}
+	for _, f := range files {
+		got, err := r.Extract(HashPath(f.path))
+		if err != nil {
+			t.Fatalf("Extract %s: %v", f.path, err)
+		}
+		if !bytes.Equal(got, f.data) {
+			t.Errorf("Extract %s: got %d bytes, want %d", f.path, len(got), len(f.data))
+		}
+		if e, _ := r.Lookup(HashPath(f.path)); e.Compression != f.c {
+			t.Errorf("%s stored as %s, want %s", f.path, e.Compression, f.c)
+		}
+	}
+	a, _ := r.Lookup(HashPath(files[1].path))
+	b, _ := r.Lookup(HashPath(files[5].path))
+	if a.Offset != b.Offset || a.Duplicate == b.Duplicate {
+		t.Errorf("identical data not shared: %+v / %+v", a, b)
+	}
+	if _, err := r.Extract(HashPath("missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Extract missing = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCopyRaw(t *testing.T) {
+	w := NewWriter()
+	data := bytes.Repeat([]byte{1, 2, 3}, 500)
+	if err := w.Add(42, data, CompressionZstd); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := r.Lookup(42)
+	raw, err := r.ReadRaw(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2 := NewWriter()
+	w2.AddRaw(e, raw)
+	var buf2 bytes.Buffer
+	if _, err := w2.WriteTo(&buf2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), buf2.Bytes()) {
+		t.Errorf("copying a raw entry changed the archive")
+	}
+}
+
+func TestWriteFileStreams(t *testing.T) {
+	big := bytes.Repeat([]byte("skinhunter "), 4096)
+	w := NewWriter()
+	reads := 0
+	lazy := func(data []byte) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			reads++
+			return data, nil
+		}
+	}
+	w.AddFunc(1, CompressionZstd, lazy(big))
+	w.AddFunc(2, CompressionZstd, lazy(big)) // Mismos datos: duplicado
+	raw, _ := compress(CompressionGzip, []byte("gzip data"))
+	w.AddRawFunc(Entry{PathHash: 3, Size: 9, Compression: CompressionGzip}, lazy(raw))
+	if err := w.Add(4, []byte("in memory"), CompressionNone); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "lazy.wad.client")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if reads != 3 {
+		t.Errorf("lazy entries read %d times, want 3", reads)
+	}
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	want := map[uint64]string{1: string(big), 2: string(big), 3: "gzip data", 4: "in memory"}
+	for h, data := range want {
+		if got, err := r.Extract(h); err != nil || string(got) != data {
+			t.Errorf("Extract %d: %d bytes, %v", h, len(got), err)
+		}
+	}
+	if a, _ := r.Lookup(1); a.Size != uint32(len(big)) {
+		t.Errorf("lazy entry size = %d, want %d", a.Size, len(big))
+	}
+	if a, b := r.Entries[0], r.Entries[1]; a.Offset != b.Offset || !b.Duplicate {
+		t.Errorf("identical lazy data not shared: %+v / %+v", a, b)
+	}
+
+	// Si falla una lectura no queda nada escrito.
+	errRead := errors.New("read failed")
+	w.AddRawFunc(Entry{PathHash: 5}, func() ([]byte, error) { return nil, errRead })
+	failed := filepath.Join(t.TempDir(), "failed.wad.client")
+	if err := w.WriteFile(failed); !errors.Is(err, errRead) {
+		t.Errorf("WriteFile = %v, want the read error", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(filepath.Dir(failed), "*")); len(files) != 0 {
+		t.Errorf("failed write left %v", files)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	w := NewWriter()
+	if err := w.Add(7, []byte("hello world"), CompressionNone); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w.WriteTo(&buf)
+	good := buf.Bytes()
+
+	open := func(b []byte) (*Reader, error) { return NewReader(bytes.NewReader(b), int64(len(b))) }
+	if _, err := open([]byte("PK\x03\x04 not a wad")); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("bad magic = %v", err)
+	}
+	v2 := append([]byte(nil), good...)
+	v2[2] = 2
+	if _, err := open(v2); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("v2 = %v", err)
+	}
+	if _, err := open(good[:headerSize+10]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("truncated = %v", err)
+	}
+	tampered := append([]byte(nil), good...)
+	tampered[len(tampered)-1] ^= 0xff
+	r, err := open(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Extract(7); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("tampered data = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestHashPath(t *testing.T) {
+	p := "ASSETS/Characters/Ahri/Skins/Skin15/Ahri.tex"
+	if HashPath(p) != HashPath(strings.ToLower(p)) || HashPath(`assets\characters\ahri.tex`) != HashPath("assets/characters/ahri.tex") {
+		t.Errorf("HashPath should ignore case and separators")
+	}
+	if HashPath("a") == HashPath("b") {
+		t.Errorf("HashPath collision")
+	}
+	// XXH64 con semilla 0 de la ruta en minúsculas. Los dos primeros son vectores de
+	// referencia de XXH64.
+	vectors := map[string]uint64{
+		"":                                     0xef46db3751d8e999,
+		"asdf":                                 0x415872f599cea71e,
+		"DATA/Characters/Ahri/Skins/Skin0.bin": 0x49e643f9c8a74bc7,
+		`data\characters\ahri\ahri.bin`:        0xa847c7a46bc6730e,
+	}
+	for p, want := range vectors {
+		if got := HashPath(p); got != want {
+			t.Errorf("HashPath(%q) = %016x, want %016x", p, got, want)
+		}
+	}
+}
+
+func TestDecompressZstdMulti(t *testing.T) {
+	_, enc := zstdCodecs()
+	// Subchunk sin comprimir que contiene la firma de zstd, y luego dos tramas.
+	plain := append([]byte("raw \x28\xb5\x2f\xfd subchunk "), bytes.Repeat([]byte{0}, 40)...)
+	a, b := bytes.Repeat([]byte("first "), 300), bytes.Repeat([]byte("second "), 300)
+	raw := append(append([]byte(nil), plain...), enc.EncodeAll(a, nil)...)
+	raw = enc.EncodeAll(b, raw)
+	want := append(append(append([]byte(nil), plain...), a...), b...)
+	got, err := decompress(CompressionZstdMulti, raw, len(want))
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("decompress = %d bytes, %v; want %d bytes", len(got), err, len(want))
+	}
+	if got, err := decompress(CompressionZstdMulti, plain, len(plain)); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("uncompressed-only entry = %q, %v", got, err)
+	}
+	if _, err := decompress(CompressionZstdMulti, plain, len(plain)+10); err == nil {
+		t.Errorf("entry without frames and the wrong size should fail")
+	}
+}
+
+func TestDecompressLyingSize(t *testing.T) {
+	_, enc := zstdCodecs()
+	small := enc.EncodeAll([]byte("tiny"), nil)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("tiny"))
+	zw.Close()
+	for _, tc := range []struct {
+		c   Compression
+		raw []byte
+	}{{CompressionZstd, small}, {CompressionGzip, gz.Bytes()}} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		out, err := decompress(tc.c, tc.raw, MaxEntrySize)
+		runtime.ReadMemStats(&after)
+		if err != nil || string(out) != "tiny" {
+			t.Errorf("%s: decompress = %q, %v", tc.c, out, err)
+		}
+		if n := after.TotalAlloc - before.TotalAlloc; n > 2*maxPrealloc {
+			t.Errorf("%s: allocated %d bytes for a 4-byte entry", tc.c, n)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// skinhunter/wad/writer.go
+package wad
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Escritura de WAD v3.3: checksums xxh3 y sin subchunks. Las entradas con los mismos
+// datos guardados se escriben una sola vez y el resto se marcan como Duplicate.
+
+const (
+	writeMajor = 3
+	writeMinor = 3
+)
+
+type pendingEntry struct {
+	entry    Entry
+	raw      []byte                 // Datos ya comprimidos
+	read     func() ([]byte, error) // Si no es nil, da los datos al escribir
+	compress bool                   // read da los datos sin comprimir: se comprimen con entry.Compression
+}
+
+// load devuelve la entrada y sus datos guardados, leyéndolos si hace falta.
+func (p pendingEntry) load() (Entry, []byte, error) {
+	if p.read == nil {
+		return p.entry, p.raw, nil
+	}
+	data, err := p.read()
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	if !p.compress {
+		return p.entry, data, nil
+	}
+	if len(data) > MaxEntrySize {
+		return Entry{}, nil, fmt.Errorf("too large (%d bytes)", len(data))
+	}
+	raw, err := compress(p.entry.Compression, data)
+	if err != nil {
+		return Entry{}, nil, fmt.Errorf("compress: %w", err)
+	}
+	e := p.entry
+	e.Size = uint32(len(data))
+	return e, raw, nil
+}
+
+// Writer acumula entradas para escribir un WAD nuevo. No es seguro para uso concurrente.
+type Writer struct {
+	entries map[uint64]pendingEntry
+}
+
+// NewWriter crea un Writer vacío.
+func NewWriter() *Writer {
+	return &Writer{entries: make(map[uint64]pendingEntry)}
+}
+
+// Len devuelve el número de entradas.
+func (w *Writer) Len() int { return len(w.entries) }
+
+// Add añade (o sustituye) la entrada hash con data, comprimida con c.
+func (w *Writer) Add(hash uint64, data []byte, c Compression) error {
+	if len(data) > MaxEntrySize {
+		return fmt.Errorf("entry %016x too large (%d bytes)", hash, len(data))
+	}
+	raw, err := compress(c, data)
+	if err != nil {
+		return fmt.Errorf("compress entry %016x: %w", hash, err)
+	}
+	w.entries[hash] = pendingEntry{
+		entry: Entry{PathHash: hash, CompressedSize: uint32(len(raw)), Size: uint32(len(data)), Compression: c},
+		raw:   raw,
+	}
+	return nil
+}
+
+// AddFunc es como Add, pero los datos sin comprimir los da read al escribir el WAD, de
+// modo que no hace falta tenerlos todos en memoria.
+func (w *Writer) AddFunc(hash uint64, c Compression, read func() ([]byte, error)) {
+	w.entries[hash] = pendingEntry{entry: Entry{PathHash: hash, Compression: c}, read: read, compress: true}
+}
+
+// AddRaw añade (o sustituye) una entrada ya comprimida, p. ej. copiada de otro WAD con
+// Reader.ReadRaw, sin volver a comprimirla. Se usan PathHash, Size, Compression y los
+// campos de subchunks de e.
+func (w *Writer) AddRaw(e Entry, raw []byte) {
+	e.CompressedSize = uint32(len(raw))
+	w.entries[e.PathHash] = pendingEntry{entry: e, raw: raw}
+}
+
+// AddRawFunc es como AddRaw, pero los datos guardados los da read al escribir el WAD.
+func (w *Writer) AddRawFunc(e Entry, read func() ([]byte, error)) {
+	w.entries[e.PathHash] = pendingEntry{entry: e, read: read}
+}
+
+// Remove quita la entrada hash si existe.
+func (w *Writer) Remove(hash uint64) {
+	delete(w.entries, hash)
+}
+
+// WriteTo escribe el WAD completo en out. Como la tabla va delante de los datos, junta
+// antes todos los datos en memoria; WriteFile los va escribiendo según los lee.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var data bytes.Buffer
+	base := w.dataOffset()
+	entries, err := w.writeData(&data, func(off int64, n int) ([]byte, error) {
+		return data.Bytes()[off-base : off-base+int64(n)], nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(encodeTOC(entries))
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := data.WriteTo(out)
+	return int64(n) + m, err
+}
+
+// WriteFile escribe el WAD en path de forma atómica (fichero temporal + rename). Las
+// entradas se leen y escriben de una en una y la tabla se escribe al final.
+func (w *Writer) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".wad-*")
+	if err != nil {
+		return err
+	}
+	if err := w.writeFile(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (w *Writer) writeFile(f *os.File) error {
+	if _, err := f.Seek(w.dataOffset(), io.SeekStart); err != nil {
+		return err
+	}
+	bw := bufio.NewWriterSize(f, 1<<20)
+	entries, err := w.writeData(bw, func(off int64, n int) ([]byte, error) {
+		if err := bw.Flush(); err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err := f.ReadAt(b, off)
+		return b, err
+	})
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(encodeTOC(entries), 0)
+	return err
+}
+
+// dataOffset es donde empiezan los datos: tras la cabecera y la tabla.
+func (w *Writer) dataOffset() int64 {
+	return int64(headerSize + entrySize*len(w.entries))
+}
+
+// writeData escribe en out los datos de las entradas, en el orden de la tabla y sin
+// repetir, y devuelve la tabla con offsets y checksums. readBack lee lo ya escrito (en
+// offsets del WAD) para comprobar si dos entradas guardan lo mismo.
+func (w *Writer) writeData(out io.Writer, readBack func(off int64, n int) ([]byte, error)) ([]Entry, error) {
+	// La tabla va ordenada por PathHash, como espera el juego (búsqueda binaria).
+	hashes := make([]uint64, 0, len(w.entries))
+	for h := range w.entries {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	offset := w.dataOffset()
+	entries := make([]Entry, 0, len(hashes))
+	firstBySum := make(map[uint64]int) // checksum -> índice de la primera entrada con esos datos
+	for _, h := range hashes {
+		e, raw, err := w.entries[h].load()
+		if err != nil {
+			return nil, fmt.Errorf("entry %016x: %w", h, err)
+		}
+		e.CompressedSize = uint32(len(raw))
+		e.Checksum = checksum(writeMinor, raw)
+		e.Duplicate = false
+		if j, ok := firstBySum[e.Checksum]; ok && entries[j].CompressedSize == e.CompressedSize {
+			prev, err := readBack(int64(entries[j].Offset), len(raw))
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(prev, raw) {
+				e.Offset = entries[j].Offset
+				e.Duplicate = true
+				entries = append(entries, e)
+				continue
+			}
+		}
+		if offset+int64(len(raw)) > 1<<32-1 {
+			return nil, fmt.Errorf("WAD too large (over 4 GiB)")
+		}
+		if _, err := out.Write(raw); err != nil {
+			return nil, err
+		}
+		firstBySum[e.Checksum] = len(entries)
+		e.Offset = uint32(offset)
+		offset += int64(len(raw))
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// encodeTOC devuelve la cabecera y la tabla de entries.
+func encodeTOC(entries []Entry) []byte {
+	hdr := make([]byte, headerSize, headerSize+entrySize*len(entries))
+	copy(hdr, "RW")
+	hdr[2], hdr[3] = writeMajor, writeMinor
+	binary.LittleEndian.PutUint32(hdr[headerSize-4:], uint32(len(entries)))
+	for _, e := range entries {
+		var b [entrySize]byte
+		binary.LittleEndian.PutUint64(b[0:], e.PathHash)
+		binary.LittleEndian.PutUint32(b[8:], e.Offset)
+		binary.LittleEndian.PutUint32(b[12:], e.CompressedSize)
+		binary.LittleEndian.PutUint32(b[16:], e.Size)
+		b[20] = uint8(e.Compression)&0x0f | e.SubchunkCount<<4
+		if e.Duplicate {
+			b[21] = 1
+		}
+		binary.LittleEndian.PutUint16(b[22:], e.FirstSubchunk)
+		binary.LittleEndian.PutUint64(b[24:], e.Checksum)
+		hdr = append(hdr, b[:]...)
+	}
+	return hdr
+}
+
+func compress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone, CompressionLink:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		_, enc := zstdCodecs()
+		return enc.EncodeAll(data, nil), nil
+	}
+	return nil, fmt.Errorf("cannot write compression %s", c)
+}
+
+// --- End of writer.go ---